@@ -3,7 +3,9 @@ package protean
 import (
 	"net/http"
 
+	"github.com/dogmatiq/protean/compression"
 	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/middleware"
 	"github.com/dogmatiq/protean/runtime"
 )
 
@@ -54,3 +56,63 @@ func WithOutputMediaType(mediaType string) ClientOption {
 		options.OutputMediaType = mediaType
 	}
 }
+
+// WithInputCompression is a ClientOption that compresses RPC input messages in
+// HTTP requests using the given algorithm.
+//
+// Only request bodies that are at least threshold bytes in size are
+// compressed; compression.DefaultThreshold is a sensible default. The server
+// must support the algorithm. By default, request bodies are not compressed.
+func WithInputCompression(a compression.Algorithm, threshold int) ClientOption {
+	if threshold < 0 {
+		panic("compression threshold must not be negative")
+	}
+
+	return func(options *runtime.ClientOptions) {
+		options.InputCompression = a
+		options.InputCompressionThreshold = threshold
+	}
+}
+
+// WithOutputCompression is a ClientOption that sets the compression algorithms
+// that the server may use when encoding RPC output messages in HTTP responses,
+// in order of preference.
+//
+// If no algorithms are given, compressed responses are not accepted. By
+// default, compression.Gzip and compression.Deflate are accepted.
+func WithOutputCompression(algorithms ...compression.Algorithm) ClientOption {
+	algorithms = append([]compression.Algorithm{}, algorithms...)
+
+	return func(options *runtime.ClientOptions) {
+		options.OutputCompression = algorithms
+	}
+}
+
+// WithMaxOutputSize is a ClientOption that sets the maximum size of a
+// decompressed HTTP response body, in bytes.
+//
+// It protects the client from compressed responses that expand to an
+// unreasonable size. By default, the limit is runtime.DefaultMaxOutputSize.
+func WithMaxOutputSize(n int) ClientOption {
+	if n <= 0 {
+		panic("maximum output size must be positive")
+	}
+
+	return func(options *runtime.ClientOptions) {
+		options.MaxOutputSize = n
+	}
+}
+
+// WithInterceptor is a ClientOption that adds an interceptor that is invoked
+// for each RPC call made by the client.
+//
+// Interceptors are invoked in the order that they are added.
+func WithInterceptor(i middleware.ClientInterceptor) ClientOption {
+	return func(options *runtime.ClientOptions) {
+		if options.Interceptor == nil {
+			options.Interceptor = i
+		} else {
+			options.Interceptor = middleware.ClientChain{options.Interceptor, i}
+		}
+	}
+}
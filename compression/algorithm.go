@@ -0,0 +1,126 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+)
+
+// DefaultThreshold is the default minimum size of a message body, in bytes,
+// before it is compressed.
+//
+// Compressing very small bodies is usually counter-productive, as the overhead
+// of the compression format outweighs any reduction in size.
+const DefaultThreshold = 1024
+
+// Algorithm is an HTTP content-coding that compresses message bodies, such as
+// gzip.
+type Algorithm interface {
+	// Name returns the name of the content-coding, as used in the
+	// Content-Encoding and Accept-Encoding HTTP headers.
+	Name() string
+
+	// NewReader returns a reader that decompresses data read from r.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+
+	// NewWriter returns a writer that compresses data written to it and writes
+	// the result to w. Closing the writer flushes any buffered data, but does
+	// not close w.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+}
+
+var (
+	// Gzip is the "gzip" content-coding.
+	Gzip Algorithm = gzipAlgorithm{}
+
+	// Deflate is the "deflate" content-coding, which is the zlib format
+	// described in RFC 1950.
+	Deflate Algorithm = deflateAlgorithm{}
+)
+
+// ErrTooLarge is returned by Decompress() when the decompressed data exceeds
+// the size limit.
+var ErrTooLarge = errors.New("the decompressed data exceeds the maximum allowable size")
+
+// Compress returns data compressed using the given algorithm.
+func Compress(a Algorithm, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, err := a.NewWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decompress returns data decompressed using the given algorithm.
+//
+// It returns ErrTooLarge if the decompressed data is larger than limit bytes.
+// The data is decompressed incrementally, so a small input that decompresses
+// to a very large output does not exhaust memory.
+func Decompress(a Algorithm, data []byte, limit int) ([]byte, error) {
+	r, err := a.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err = io.ReadAll(
+		io.LimitReader(
+			r,
+			int64(limit)+1,
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > limit {
+		return nil, ErrTooLarge
+	}
+
+	return data, nil
+}
+
+// gzipAlgorithm is an implementation of Algorithm for the "gzip"
+// content-coding.
+type gzipAlgorithm struct{}
+
+func (gzipAlgorithm) Name() string {
+	return "gzip"
+}
+
+func (gzipAlgorithm) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+func (gzipAlgorithm) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+// deflateAlgorithm is an implementation of Algorithm for the "deflate"
+// content-coding.
+type deflateAlgorithm struct{}
+
+func (deflateAlgorithm) Name() string {
+	return "deflate"
+}
+
+func (deflateAlgorithm) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return zlib.NewReader(r)
+}
+
+func (deflateAlgorithm) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zlib.NewWriter(w), nil
+}
@@ -0,0 +1,71 @@
+package compression_test
+
+import (
+	"strings"
+
+	. "github.com/dogmatiq/protean/compression"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("type Algorithm", func() {
+	DescribeTable(
+		"it round-trips data through Compress() and Decompress()",
+		func(a Algorithm) {
+			data := []byte(strings.Repeat("<data>", 1000))
+
+			compressed, err := Compress(a, data)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(len(compressed)).To(BeNumerically("<", len(data)))
+
+			decompressed, err := Decompress(a, compressed, len(data))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(decompressed).To(Equal(data))
+		},
+		Entry("gzip", Gzip),
+		Entry("deflate", Deflate),
+	)
+
+	Describe("func Decompress()", func() {
+		It("returns ErrTooLarge if the decompressed data exceeds the limit", func() {
+			compressed, err := Compress(Gzip, make([]byte, 1_000_000))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = Decompress(Gzip, compressed, 1000)
+			Expect(err).To(Equal(ErrTooLarge))
+		})
+
+		It("returns an error if the data is not compressed", func() {
+			_, err := Decompress(Gzip, []byte("<data>"), 1000)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("func Negotiate()", func() {
+	algorithms := []Algorithm{Gzip, Deflate}
+
+	DescribeTable(
+		"it returns the most preferable acceptable algorithm",
+		func(acceptEncoding, expect string) {
+			a, ok := Negotiate(acceptEncoding, algorithms)
+
+			if expect == "" {
+				Expect(ok).To(BeFalse())
+				return
+			}
+
+			Expect(ok).To(BeTrue())
+			Expect(a.Name()).To(Equal(expect))
+		},
+		Entry("no header", "", ""),
+		Entry("identity only", "identity", ""),
+		Entry("server preference on ties", "deflate, gzip", "gzip"),
+		Entry("client q-values", "gzip;q=0.5, deflate", "deflate"),
+		Entry("wildcard", "*", "gzip"),
+		Entry("wildcard with explicit exclusion", "*, gzip;q=0", "deflate"),
+		Entry("case-insensitive names", "GZIP", "gzip"),
+		Entry("unsupported algorithms", "br", ""),
+	)
+})
@@ -0,0 +1,3 @@
+// Package compression defines the HTTP content-codings that can be used to
+// compress RPC messages, both on the server-side and client-side.
+package compression
@@ -0,0 +1,62 @@
+package compression
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Negotiate returns the algorithm to use to compress a response, based on the
+// value of a request's Accept-Encoding header.
+//
+// algorithms is the set of algorithms supported by the server, in order of
+// preference. It returns false if none of the algorithms are acceptable to the
+// client, in which case the response should not be compressed.
+func Negotiate(acceptEncoding string, algorithms []Algorithm) (Algorithm, bool) {
+	var (
+		best  Algorithm
+		bestQ float64
+	)
+
+	for _, a := range algorithms {
+		q := acceptQuality(acceptEncoding, a.Name())
+		if q > bestQ {
+			best, bestQ = a, q
+		}
+	}
+
+	return best, best != nil
+}
+
+// acceptQuality returns the quality value that the given Accept-Encoding
+// header value assigns to the named content-coding.
+//
+// An explicit entry for the content-coding takes precedence over the "*"
+// wildcard.
+func acceptQuality(acceptEncoding, name string) float64 {
+	wildcard := 0.0
+
+	for _, entry := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(entry, ";")
+		coding = strings.TrimSpace(coding)
+
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+			if ok && strings.EqualFold(k, "q") {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+
+		if strings.EqualFold(coding, name) {
+			return q
+		}
+
+		if coding == "*" {
+			wildcard = q
+		}
+	}
+
+	return wildcard
+}
@@ -8,8 +8,10 @@ require (
 	github.com/dave/jennifer v1.7.1
 	github.com/dogmatiq/iago v0.4.0
 	github.com/elnormous/contenttype v1.0.4
+	github.com/gorilla/websocket v1.5.3
 	github.com/onsi/ginkgo v1.16.5
 	github.com/onsi/gomega v1.38.0
+	google.golang.org/genproto/googleapis/api v0.0.0-20250825161204-c5933d9347a5
 	google.golang.org/protobuf v1.36.8
 )
 
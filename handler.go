@@ -1,43 +1,104 @@
 package protean
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/dogmatiq/protean/compression"
+	"github.com/dogmatiq/protean/internal/openapi"
 	"github.com/dogmatiq/protean/internal/proteanpb"
 	"github.com/dogmatiq/protean/internal/protomime"
 	"github.com/dogmatiq/protean/middleware"
 	"github.com/dogmatiq/protean/rpcerror"
 	"github.com/dogmatiq/protean/runtime"
+	"github.com/gorilla/websocket"
 )
 
 // Handler is an http.Handler that maps HTTP requests to RPC calls.
 type Handler interface {
 	http.Handler
 	runtime.Registry
+
+	// Shutdown stops the handler gracefully, rejecting new calls and waiting
+	// for in-flight calls to complete.
+	//
+	// It returns ctx.Err() if ctx is canceled before all calls complete, in
+	// which case the contexts of the remaining calls are canceled.
+	Shutdown(ctx context.Context) error
+
+	// RegisterRoutes registers the handler with mux, using a separate
+	// method-specific pattern for each URL path that the handler serves, such
+	// as "POST /<prefix>/<package>/<service>/<method>".
+	//
+	// This allows the handler to share a mux with other handlers, while
+	// requests for unknown paths or unsupported HTTP methods are answered by
+	// the mux itself. The patterns reflect the services and options at the
+	// time of the call, so it must be called after all services have been
+	// registered.
+	//
+	// It panics if any of the patterns conflict with those already registered
+	// with mux.
+	RegisterRoutes(mux *http.ServeMux)
 }
 
 // handler is an implementation of Handler that handles RPC method calls made
 // via HTTP POST requests and "method-scoped" websocket connections.
 type handler struct {
-	services     map[string]runtime.Service
-	interceptor  middleware.ServerInterceptor
-	maxInputSize int
+	services           map[string]runtime.Service
+	interceptors       middleware.ServerChain
+	streamInterceptors middleware.StreamServerChain
+	maxInputSize       int
+	maxInputSizes      map[string]int
+	maxOutputSize      int
+	maxOutputSizes     map[string]int
+	maxTimeout         time.Duration
+	pathPrefix         string
+	getMethods         map[string]bool
+	jsonRPCPath        string
+	twirpPrefix        string
+	discovery          bool
+	openAPIInfo        *openapi.Info
+	restRoutes         []restRoute
+	corsPolicies       map[string]*CORSPolicy
+	authPolicies       map[string]*AuthenticationPolicy
+
+	compressionAlgorithms []compression.Algorithm
+	compressionThreshold  int
+
+	shutdown shutdown
 }
 
 // NewHandler returns a new HTTP handler that maps HTTP requests to RPC calls.
 func NewHandler(options ...HandlerOption) Handler {
 	h := &handler{
-		interceptor:  middleware.Validator{},
 		maxInputSize: DefaultMaxRPCInputSize,
+
+		compressionAlgorithms: defaultCompressionAlgorithms,
+		compressionThreshold:  compression.DefaultThreshold,
 	}
 
 	for _, opt := range options {
 		opt(h)
 	}
 
+	// The authenticator is always the first interceptor in the chain so that
+	// all other interceptors have access to the authenticated principal.
+	if len(h.authPolicies) != 0 {
+		a := authenticator{h}
+		h.interceptors = append(middleware.ServerChain{a}, h.interceptors...)
+		h.streamInterceptors = append(middleware.StreamServerChain{a}, h.streamInterceptors...)
+	}
+
+	// The validator is always the last interceptor in the chain so that it
+	// sees the messages exactly as they are passed to and produced by the RPC
+	// method.
+	h.interceptors = append(h.interceptors, middleware.Validator{})
+	h.streamInterceptors = append(h.streamInterceptors, middleware.Validator{})
+
 	return h
 }
 
@@ -54,11 +115,21 @@ func (h *handler) RegisterService(s runtime.Service) {
 	}
 
 	h.services[prefix] = s
+	h.addRESTRoutes(s)
 }
 
 // ServeHTTP handles an HTTP request.
 //
-// The request must use the POST HTTP method.
+// The request must use the POST HTTP method, or be a request to upgrade the
+// connection to a websocket.
+//
+// Unary methods may also be called using the GET HTTP method if they are
+// declared as having no side effects, by setting the "idempotency_level" option
+// to NO_SIDE_EFFECTS, or if they have been enabled using the WithGETEnabled()
+// option. The RPC input message is read from the URL query parameters, either
+// from the "in" parameter, which contains the JSON representation of the
+// message or the base64 encoding of its binary representation, or from
+// separate parameters for each field, such as "?customer.name=Jane".
 //
 // The request URL path is mapped to an RPC method using the following pattern:
 // /<package>/<service>/<method>, where <package> is the Protocol Buffers
@@ -74,13 +145,238 @@ func (h *handler) RegisterService(s runtime.Service) {
 //
 // The RPC output message is written to the response body, encoded as per the
 // request's Accept header, which need not be the same as the input encoding.
+//
+// The request body may be compressed using any of the algorithms configured by
+// the WithCompressionAlgorithms() option, as indicated by the Content-Encoding
+// header; by default gzip and deflate are supported. The maximum input size
+// applies to the decompressed message. The response body of a unary call is
+// compressed if the client's Accept-Encoding header permits it and the body is
+// at least as large as the threshold set by WithCompressionThreshold().
+//
+// Methods that use streaming inputs or outputs must be called via a websocket
+// or a POST request with a framed request body, as described below.
+// Websocket connections are "method-scoped", meaning that each connection is
+// used for a single call to the RPC method identified by the request URL path.
+//
+// The client must request one of the following websocket sub-protocols, which
+// determines the encoding used for each frame:
+//   - protean.v1+proto (binary format, sent as binary frames)
+//   - protean.v1+json (as per google.golang.org/protobuf/encoding/protojson)
+//   - protean.v1+text (as per google.golang.org/protobuf/encoding/prototext)
+//
+// Each frame sent by the client contains a protean.v1.ClientFrame message,
+// which holds either an RPC input message or a marker indicating that no more
+// input messages will be sent. Each frame sent by the server contains a
+// protean.v1.ServerFrame message, which holds either an RPC output message or,
+// in the final frame, the outcome of the call.
+//
+// Server streaming methods may also be called using server-sent events by
+// making a GET or POST request that accepts the text/event-stream media type.
+// For GET requests the RPC input message is read from the "in" query parameter,
+// which contains either the JSON representation of the message or the base64
+// encoding of its binary representation. Each RPC output message is sent as an
+// unnamed event. The "encoding" query parameter selects between JSON (the
+// default) and base64-encoded binary ("binary") data. A final "done" or
+// "rpcerror" event indicates the outcome of the call; the latter contains a
+// protean.v1.Error message.
+//
+// Any method may also be called using a POST request with a framed request
+// body. The request body is a stream of protean.v1.ClientFrame messages, and
+// the response body is a stream of protean.v1.ServerFrame messages, exactly as
+// per the websocket transport. Frames are read and written as they become
+// available, so full-duplex streaming is possible over HTTP/2. The framing is
+// determined by the request's Content-Type:
+//   - application/vnd.protean.stream+proto (binary format, each frame preceded
+//     by its length as a 32-bit big-endian unsigned integer)
+//   - application/vnd.protean.stream+ndjson (JSON format, one frame per line)
+//
+// The end of the request body implicitly ends the RPC input stream.
+//
+// Any method may also be called by gRPC clients. gRPC requests are identified by
+// the application/grpc, application/grpc+proto or application/grpc+json
+// media-types, and use the gRPC URL path pattern:
+// /<package>.<service>/<method>. The outcome of the call is conveyed by the
+// grpc-status and grpc-message trailers. Message compression is not supported.
+// Most gRPC clients require HTTP/2, which in turn requires either TLS or an
+// HTTP server that accepts unencrypted HTTP/2 ("h2c") connections.
+//
+// Unary and server streaming methods may also be called by gRPC-Web clients,
+// using the application/grpc-web and application/grpc-web-text media-types. As
+// per the gRPC-Web protocol the trailers are sent in the final frame of the
+// response body.
+//
+// Any method may also be called by Connect clients. Connect requests are
+// identified by the Connect-Protocol-Version header, the application/connect+*
+// streaming media-types, or for GET requests, the "connect=v1" query
+// parameter. Like gRPC, Connect uses the /<package>.<service>/<method> URL path
+// pattern. Unary request bodies may be compressed as per the Content-Encoding
+// header and unary responses are compressed as per the Accept-Encoding header.
+// Compressed GET requests and streaming calls are not supported, and are
+// rejected if the "compression" query parameter or Connect-Content-Encoding
+// header specifies anything other than "identity".
+//
+// If the WithJSONRPCEndpoint() option is used, unary methods may also be called
+// by making a JSON-RPC 2.0 request to the configured endpoint.
+//
+// Unary methods that are annotated with "google.api.http" rules may also be
+// called using REST-style requests, such as "GET /v1/users/{id}". The HTTP
+// method and URL path template of each rule, including any additional
+// bindings, are matched against the request. Path variables, query parameters
+// and the request body, as nominated by the rule's "body" field, are bound to
+// the RPC input message. The RPC output message, or the field nominated by the
+// rule's "response_body" field, is written to the response body as JSON.
+//
+// If the WithTwirpPrefix() option is used, unary methods may also be called by
+// Twirp clients, using the /<prefix>/<package>.<service>/<method> URL path
+// pattern. Errors that occur during such calls are reported using Twirp's JSON
+// error format.
+//
+// If the WithDiscoveryEndpoint() option is used, GET requests made to
+// DiscoveryPath are answered with a protean.v1.ServiceList message that
+// describes each of the registered services and their methods. If the
+// "descriptors" query parameter is "true", the message also contains a
+// FileDescriptorSet with the definitions of the services.
+//
+// If the WithOpenAPIEndpoint() option is used, GET requests made to
+// OpenAPIPath are answered with an OpenAPI 3.1 document that describes the
+// unary methods of the registered services, as called via HTTP POST requests.
+//
+// Once Shutdown() has been called, all requests other than CORS preflight
+// requests are rejected with a Retry-After header and an rpcerror.Unavailable
+// error, written in the error format of the request's transport.
+//
+// If the WithCORS() option is used, CORS preflight requests made to the URL
+// path of any known RPC method are answered as per the applicable CORSPolicy,
+// and the Access-Control-* headers are added to the responses of cross-origin
+// requests, including error responses.
+//
+// If the request has a Protean-Timeout header, the RPC call is given a
+// deadline of that many milliseconds, limited by the WithMaxTimeout() option.
+// Calls that do not complete before their deadline fail with an
+// rpcerror.DeadlineExceeded error, which is sent with a "504 Gateway Timeout"
+// status.
+//
+// Regardless of the transport, RPC methods may use RequestMetadata() to inspect
+// the request, and SetResponseHeader() and related functions to add headers to
+// the response.
 func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w, r, finish := beginMetadata(w, r)
+	defer finish()
+
+	r, ok := h.stripPathPrefix(w, r)
+	if !ok {
+		return
+	}
+
+	// CORS is handled before requests are rejected due to shutdown, so that
+	// browsers can read the error response and its Retry-After header.
+	if p, t, ok := h.corsPolicy(r); ok {
+		if isPreflightRequest(r) {
+			writePreflightResponse(w, r, p, t, h.authenticationHeaders())
+			return
+		}
+
+		setCORSHeaders(w, r, p)
+	}
+
+	ctx, done, ok := h.shutdown.begin(r.Context())
+	if !ok {
+		h.writeShutdownError(w, r)
+		return
+	}
+	defer done()
+	r = r.WithContext(ctx)
+
+	ctx, cancel, ok := h.applyTimeout(w, r)
+	if !ok {
+		return
+	}
+	defer cancel()
+	r = r.WithContext(ctx)
+
+	if h.discovery && r.URL.Path == DiscoveryPath {
+		h.serveDiscovery(w, r)
+		return
+	}
+
+	if h.openAPIInfo != nil && r.URL.Path == OpenAPIPath {
+		h.serveOpenAPI(w, r)
+		return
+	}
+
+	if isGRPCRequest(r) {
+		h.serveGRPC(w, r)
+		return
+	}
+
+	if isConnectRequest(r) {
+		h.serveConnect(w, r)
+		return
+	}
+
+	if h.isTwirpRequest(r) {
+		h.serveTwirp(w, r)
+		return
+	}
+
+	if h.jsonRPCPath != "" && r.URL.Path == h.jsonRPCPath {
+		h.serveJSONRPC(w, r)
+		return
+	}
+
+	if route, values, allowed, ok := h.matchRESTRoute(r); ok {
+		h.serveREST(w, r, route, values)
+		return
+	} else if len(allowed) != 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		httpError(
+			w,
+			http.StatusMethodNotAllowed,
+			protomime.JSONMediaTypes[0],
+			protomime.JSONMarshaler,
+			rpcerror.New(
+				rpcerror.NotImplemented,
+				"the HTTP method must be %s",
+				strings.Join(allowed, " or "),
+			),
+		)
+		return
+	}
+
 	service, method, ok := h.resolveMethod(w, r)
 	if !ok {
 		return
 	}
 
+	// Authenticate the call before dispatching to the transport, as the
+	// streaming transports send the response headers before the RPC method
+	// is invoked.
+	r, ok = h.authenticateHTTPRequest(w, r, method)
+	if !ok {
+		return
+	}
+
+	if websocket.IsWebSocketUpgrade(r) {
+		h.serveWebSocket(w, r, method)
+		return
+	}
+
+	if r.Method == http.MethodPost && isStreamRequest(r) {
+		h.serveStream(w, r, method)
+		return
+	}
+
+	if method.OutputIsStream() && !method.InputIsStream() && acceptsEventStream(r) {
+		h.serveEventStream(w, r, service, method)
+		return
+	}
+
 	if method.InputIsStream() || method.OutputIsStream() {
+		transports := "a websocket or a framed request body"
+		if !method.InputIsStream() {
+			transports = "a websocket, server-sent events or a framed request body"
+		}
+
 		httpError(
 			w,
 			http.StatusNotImplemented,
@@ -88,10 +384,11 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			protomime.TextMarshaler,
 			rpcerror.New(
 				rpcerror.NotImplemented,
-				"the '%s.%s' service does contain an RPC method named '%s', but is not supported by this server because it uses streaming inputs or outputs",
+				"the '%s.%s' service does contain an RPC method named '%s', but it uses streaming inputs or outputs and must be called via %s",
 				service.Package(),
 				service.Name(),
 				method.Name(),
+				transports,
 			),
 		)
 		return
@@ -101,21 +398,34 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// method exists and is supported.
 	w.Header().Set("Accept-Post", acceptPostHeader)
 
-	if r.Method != http.MethodPost {
-		httpError(
-			w,
-			http.StatusMethodNotAllowed,
-			protomime.TextMediaTypes[0],
-			protomime.TextMarshaler,
-			rpcerror.New(
-				rpcerror.NotImplemented,
-				"the HTTP method must be POST",
-			),
-		)
+	allowsGET := h.allowsGET(service, method)
+
+	switch r.Method {
+	case http.MethodPost:
+		h.servePOST(w, r, method)
 		return
+	case http.MethodGet:
+		if allowsGET {
+			h.serveGET(w, r, method)
+			return
+		}
+	}
+
+	message := "the HTTP method must be POST"
+	if allowsGET {
+		message = "the HTTP method must be GET or POST"
 	}
 
-	h.servePOST(w, r, method)
+	httpError(
+		w,
+		http.StatusMethodNotAllowed,
+		protomime.TextMediaTypes[0],
+		protomime.TextMarshaler,
+		rpcerror.New(
+			rpcerror.NotImplemented,
+			message,
+		),
+	)
 }
 
 // resolveMethod looks up the RPC method based on the request URL.
@@ -234,6 +544,8 @@ func httpError(
 		panic(err)
 	}
 
+	setRetryAfterHeader(w, rpcErr)
+
 	w.Header().Set("Cache-Control", "no-store")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
 	w.Header().Set("Content-Type", protomime.FormatMediaType(mediaType, &protoErr))
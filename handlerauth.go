@@ -0,0 +1,374 @@
+package protean
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/middleware"
+	"github.com/dogmatiq/protean/rpcerror"
+	"github.com/dogmatiq/protean/runtime"
+	"google.golang.org/protobuf/proto"
+)
+
+// CredentialType is an enumeration of the types of credentials that RPC
+// clients may present.
+type CredentialType int
+
+const (
+	// BearerTokenCredentials is a token sent in the Authorization header
+	// using the "Bearer" scheme.
+	BearerTokenCredentials CredentialType = iota + 1
+
+	// APIKeyCredentials is an API key sent in a request header or URL query
+	// parameter.
+	APIKeyCredentials
+
+	// BasicCredentials is a username and password sent in the Authorization
+	// header using the "Basic" scheme.
+	BasicCredentials
+)
+
+// Credentials are the credentials presented by an RPC client.
+type Credentials struct {
+	// Type is the type of the credentials.
+	Type CredentialType
+
+	// Token is the bearer token or API key. It is empty for Basic credentials.
+	Token string
+
+	// Username and Password are the components of Basic credentials. They are
+	// empty for other types of credentials.
+	Username, Password string
+}
+
+// AuthenticationPolicy describes how the handler authenticates RPC calls.
+//
+// At least one type of credentials must be enabled.
+type AuthenticationPolicy struct {
+	// Verify returns the principal identified by the given credentials.
+	//
+	// If it returns an rpcerror.Error, that error is sent to the client. Any
+	// other error causes the call to fail with an rpcerror.Unauthenticated
+	// error, without revealing the underlying error to the client.
+	Verify func(ctx context.Context, c Credentials) (middleware.Principal, error)
+
+	// Bearer enables bearer tokens sent in the Authorization header.
+	Bearer bool
+
+	// Basic enables usernames and passwords sent in the Authorization header.
+	Basic bool
+
+	// APIKeyHeader is the name of a request header that contains an API key,
+	// such as "X-API-Key". If it is empty, API keys are not read from the
+	// request headers.
+	APIKeyHeader string
+
+	// APIKeyParameter is the name of a URL query parameter that contains an
+	// API key. If it is empty, API keys are not read from the query string.
+	//
+	// Query parameters allow browsers to authenticate websocket connections,
+	// which can not carry custom headers.
+	APIKeyParameter string
+
+	// Optional permits calls that do not present any credentials. Such calls
+	// are not associated with a principal. Calls that present invalid
+	// credentials are still rejected.
+	Optional bool
+
+	// Realm is the authentication realm sent in the WWW-Authenticate header
+	// when a call is rejected. If it is empty, no realm is sent.
+	Realm string
+}
+
+// credentials returns the credentials presented in the HTTP request described
+// by md.
+//
+// It returns false if the request does not contain any credentials of the
+// types enabled by the policy. It returns an error if the credentials are
+// malformed.
+func (p *AuthenticationPolicy) credentials(md *callMetadata) (Credentials, bool, error) {
+	if v := md.request.Header.Get("Authorization"); v != "" {
+		scheme, value, _ := strings.Cut(v, " ")
+		value = strings.TrimSpace(value)
+
+		switch {
+		case p.Bearer && strings.EqualFold(scheme, "Bearer"):
+			if value == "" {
+				return Credentials{}, false, newAuthenticationError("the bearer token is empty")
+			}
+
+			return Credentials{
+				Type:  BearerTokenCredentials,
+				Token: value,
+			}, true, nil
+
+		case p.Basic && strings.EqualFold(scheme, "Basic"):
+			r := http.Request{Header: http.Header{"Authorization": {v}}}
+
+			username, password, ok := r.BasicAuth()
+			if !ok {
+				return Credentials{}, false, newAuthenticationError("the basic credentials are malformed")
+			}
+
+			return Credentials{
+				Type:     BasicCredentials,
+				Username: username,
+				Password: password,
+			}, true, nil
+		}
+	}
+
+	if p.APIKeyHeader != "" {
+		if v := md.request.Header.Get(p.APIKeyHeader); v != "" {
+			return Credentials{
+				Type:  APIKeyCredentials,
+				Token: v,
+			}, true, nil
+		}
+	}
+
+	if p.APIKeyParameter != "" {
+		if v := md.url.Query().Get(p.APIKeyParameter); v != "" {
+			return Credentials{
+				Type:  APIKeyCredentials,
+				Token: v,
+			}, true, nil
+		}
+	}
+
+	return Credentials{}, false, nil
+}
+
+// challenges returns the values of the WWW-Authenticate header sent when a call
+// is rejected.
+func (p *AuthenticationPolicy) challenges() []string {
+	params := ""
+	if p.Realm != "" {
+		params = ` realm="` + strings.ReplaceAll(p.Realm, `"`, `\"`) + `"`
+	}
+
+	var values []string
+
+	if p.Bearer {
+		values = append(values, "Bearer"+params)
+	}
+
+	if p.Basic {
+		values = append(values, "Basic"+params)
+	}
+
+	return values
+}
+
+// newAuthenticationError returns an rpcerror.Unauthenticated error with the
+// given message.
+func newAuthenticationError(format string, args ...any) rpcerror.Error {
+	return rpcerror.New(rpcerror.Unauthenticated, format, args...)
+}
+
+// authenticatedKey is the context key used to record that the request
+// associated with the context has already been authenticated according to a
+// specific policy.
+type authenticatedKey struct{}
+
+// authenticationPolicy returns the authentication policy that applies to the
+// given method, if any.
+func (h *handler) authenticationPolicy(service, method string) (*AuthenticationPolicy, bool) {
+	for _, k := range []string{
+		service + "/" + method,
+		service,
+		"",
+	} {
+		if p, ok := h.authPolicies[k]; ok {
+			return p, true
+		}
+	}
+
+	return nil, false
+}
+
+// authenticationHeaders returns the names of the request headers that contain
+// API keys, as configured by the handler's authentication policies.
+func (h *handler) authenticationHeaders() []string {
+	var names []string
+
+	for _, p := range h.authPolicies {
+		if p.APIKeyHeader != "" {
+			names = append(names, http.CanonicalHeaderKey(p.APIKeyHeader))
+		}
+	}
+
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// authenticate authenticates a call to the given method.
+//
+// service is the fully-qualified service name. It returns a context that is
+// associated with the authenticated principal, if any.
+func (h *handler) authenticate(
+	ctx context.Context,
+	service, method string,
+) (context.Context, error) {
+	p, ok := h.authenticationPolicy(service, method)
+	if !ok {
+		return ctx, nil
+	}
+
+	if ctx.Value(authenticatedKey{}) == p {
+		return ctx, nil
+	}
+
+	authCtx, err := p.authenticate(ctx)
+	if err != nil {
+		for _, v := range p.challenges() {
+			_ = AddResponseHeader(ctx, "WWW-Authenticate", v)
+		}
+
+		return nil, err
+	}
+
+	return context.WithValue(authCtx, authenticatedKey{}, p), nil
+}
+
+// authenticate returns a copy of ctx that is associated with the principal
+// identified by the credentials in the HTTP request.
+func (p *AuthenticationPolicy) authenticate(ctx context.Context) (context.Context, error) {
+	md, ok := ctx.Value(metadataKey{}).(*callMetadata)
+	if !ok {
+		// CODE COVERAGE: This condition can not be reproduced, as all calls
+		// made via the handler are associated with call metadata.
+		return nil, newAuthenticationError("the RPC call requires authentication")
+	}
+
+	creds, ok, err := p.credentials(md)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		if p.Optional {
+			return ctx, nil
+		}
+
+		return nil, newAuthenticationError("the RPC call requires authentication")
+	}
+
+	principal, err := p.Verify(ctx, creds)
+	if err != nil {
+		if rpcErr, ok := err.(rpcerror.Error); ok {
+			return nil, rpcErr
+		}
+
+		return nil, newAuthenticationError("the supplied credentials are invalid")
+	}
+
+	if principal == nil {
+		return nil, newAuthenticationError("the supplied credentials are invalid")
+	}
+
+	return middleware.WithPrincipal(ctx, principal), nil
+}
+
+// authenticateRequest authenticates a call to m before the transport begins
+// writing the response, so that failures can be reported using the HTTP
+// response status and the WWW-Authenticate header.
+//
+// It returns false if authentication fails, in which case rpcErr describes the
+// failure and the WWW-Authenticate header has already been added to the
+// response. The authenticator interceptor does not authenticate the call again
+// when it is made using the returned request's context.
+func (h *handler) authenticateRequest(
+	r *http.Request,
+	m runtime.Method,
+) (_ *http.Request, rpcErr rpcerror.Error, ok bool) {
+	if len(h.authPolicies) == 0 {
+		return r, rpcerror.Error{}, true
+	}
+
+	service := string(m.Descriptor().Parent().FullName())
+
+	ctx, err := h.authenticate(r.Context(), service, m.Name())
+	if err != nil {
+		return nil, err.(rpcerror.Error), false
+	}
+
+	return r.WithContext(ctx), rpcerror.Error{}, true
+}
+
+// authenticateHTTPRequest authenticates a call to m made using any of the
+// transports that report errors using an HTTP error response.
+//
+// It returns false if authentication fails, in which case an error has already
+// been written to w, encoded as per the request's Accept header.
+func (h *handler) authenticateHTTPRequest(
+	w http.ResponseWriter,
+	r *http.Request,
+	m runtime.Method,
+) (*http.Request, bool) {
+	authReq, rpcErr, ok := h.authenticateRequest(r, m)
+	if ok {
+		return authReq, true
+	}
+
+	marshaler, mediaType, ok, _ := marshalerByNegotiation(r)
+	if !ok {
+		marshaler = protomime.TextMarshaler
+		mediaType = protomime.TextMediaTypes[0]
+	}
+
+	httpError(
+		w,
+		httpStatusFromErrorCode(rpcErr.Code()),
+		mediaType,
+		marshaler,
+		rpcErr,
+	)
+
+	return nil, false
+}
+
+// authenticator is an implementation of middleware.ServerInterceptor and
+// middleware.StreamServerInterceptor that authenticates each RPC call.
+//
+// Calls made via the handler's transports are authenticated before the
+// response is started; the interceptor authenticates calls that are made by
+// invoking runtime.Method.NewCall() directly, and those made via transports
+// that do not resolve the method up-front, such as JSON-RPC, Twirp and
+// REST-style requests.
+type authenticator struct {
+	handler *handler
+}
+
+// InterceptUnaryRPC authenticates the call before calling next().
+func (a authenticator) InterceptUnaryRPC(
+	ctx context.Context,
+	info middleware.UnaryServerInfo,
+	in proto.Message,
+	next func(ctx context.Context) (out proto.Message, err error),
+) (proto.Message, error) {
+	ctx, err := a.handler.authenticate(ctx, info.Package+"."+info.Service, info.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	return next(ctx)
+}
+
+// InterceptStreamRPC authenticates the call before calling next().
+func (a authenticator) InterceptStreamRPC(
+	ctx context.Context,
+	info middleware.StreamServerInfo,
+	stream middleware.ServerStream,
+	next func(ctx context.Context, stream middleware.ServerStream) error,
+) error {
+	ctx, err := a.handler.authenticate(ctx, info.Package+"."+info.Service, info.Method)
+	if err != nil {
+		return err
+	}
+
+	return next(ctx, stream)
+}
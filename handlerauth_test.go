@@ -0,0 +1,503 @@
+package protean_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/middleware"
+	"github.com/dogmatiq/protean/rpcerror"
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("type Handler (authentication)", func() {
+	var (
+		service *testservice.Stub
+		policy  AuthenticationPolicy
+	)
+
+	BeforeEach(func() {
+		service = &testservice.Stub{
+			UnaryFunc: func(
+				ctx context.Context,
+				in *testservice.Input,
+			) (*testservice.Output, error) {
+				p, ok := middleware.PrincipalFromContext(ctx)
+				if !ok {
+					return &testservice.Output{Data: "<anonymous>"}, nil
+				}
+
+				return &testservice.Output{Data: p.ID()}, nil
+			},
+			ServerStreamFunc: func(
+				ctx context.Context,
+				in *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				defer close(outputs)
+
+				p, _ := middleware.PrincipalFromContext(ctx)
+
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case outputs <- &testservice.Output{Data: p.ID()}:
+					return nil
+				}
+			},
+		}
+
+		policy = AuthenticationPolicy{
+			Verify: func(
+				_ context.Context,
+				c Credentials,
+			) (middleware.Principal, error) {
+				switch c.Type {
+				case BearerTokenCredentials:
+					switch c.Token {
+					case "<token>":
+						return testPrincipal("<bearer-user>"), nil
+					case "<scoped-token>":
+						return scopedTestPrincipal{"test:read", "test:list"}, nil
+					}
+				case APIKeyCredentials:
+					if c.Token == "<key>" {
+						return testPrincipal("<api-key-user>"), nil
+					}
+				case BasicCredentials:
+					if c.Username == "<user>" && c.Password == "<pass>" {
+						return testPrincipal("<basic-user>"), nil
+					}
+				}
+
+				return nil, errors.New("<invalid>")
+			},
+			Bearer:          true,
+			Basic:           true,
+			APIKeyHeader:    "X-API-Key",
+			APIKeyParameter: "api_key",
+			Realm:           "<realm>",
+		}
+	})
+
+	// serve makes a POST request to the given target, allowing the caller to
+	// add credentials to the request.
+	serve := func(
+		handler Handler,
+		target string,
+		setup func(r *http.Request),
+	) *httptest.ResponseRecorder {
+		testservice.RegisterProteanTestService(handler, service)
+
+		request := httptest.NewRequest(
+			http.MethodPost,
+			target,
+			strings.NewReader(`{"data":"<input>"}`),
+		)
+		request.Header.Set("Content-Type", "application/json")
+		setup(request)
+
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+
+		return response
+	}
+
+	Describe("func WithAuthentication()", func() {
+		DescribeTable(
+			"it makes the authenticated principal available to the RPC method",
+			func(target string, setup func(r *http.Request), expect string) {
+				response := serve(NewHandler(WithAuthentication(policy)), target, setup)
+
+				Expect(response).To(HaveHTTPStatus(http.StatusOK))
+				Expect(response.Body.String()).To(MatchJSON(`{"data":"` + expect + `"}`))
+			},
+			Entry(
+				"bearer token",
+				"/protean.test/TestService/Unary",
+				func(r *http.Request) { r.Header.Set("Authorization", "Bearer <token>") },
+				"<bearer-user>",
+			),
+			Entry(
+				"basic credentials",
+				"/protean.test/TestService/Unary",
+				func(r *http.Request) { r.SetBasicAuth("<user>", "<pass>") },
+				"<basic-user>",
+			),
+			Entry(
+				"API key header",
+				"/protean.test/TestService/Unary",
+				func(r *http.Request) { r.Header.Set("X-API-Key", "<key>") },
+				"<api-key-user>",
+			),
+			Entry(
+				"API key query parameter",
+				"/protean.test/TestService/Unary?api_key="+url.QueryEscape("<key>"),
+				func(*http.Request) {},
+				"<api-key-user>",
+			),
+		)
+
+		DescribeTable(
+			"it responds with an HTTP '401 Unauthorized' status if the call is not authenticated",
+			func(setup func(r *http.Request), expect rpcerror.Error) {
+				response := serve(
+					NewHandler(WithAuthentication(policy)),
+					"/protean.test/TestService/Unary",
+					setup,
+				)
+
+				Expect(response.Header().Values("WWW-Authenticate")).To(ConsistOf(
+					`Bearer realm="<realm>"`,
+					`Basic realm="<realm>"`,
+				))
+
+				expectError(
+					response,
+					http.StatusUnauthorized,
+					"application/json; x-proto=protean.v1.Error",
+					expect,
+				)
+			},
+			Entry(
+				"no credentials",
+				func(*http.Request) {},
+				rpcerror.New(rpcerror.Unauthenticated, "the RPC call requires authentication"),
+			),
+			Entry(
+				"invalid credentials",
+				func(r *http.Request) { r.Header.Set("Authorization", "Bearer <wrong>") },
+				rpcerror.New(rpcerror.Unauthenticated, "the supplied credentials are invalid"),
+			),
+			Entry(
+				"empty bearer token",
+				func(r *http.Request) { r.Header.Set("Authorization", "Bearer ") },
+				rpcerror.New(rpcerror.Unauthenticated, "the bearer token is empty"),
+			),
+			Entry(
+				"malformed basic credentials",
+				func(r *http.Request) { r.Header.Set("Authorization", "Basic <not-base64>") },
+				rpcerror.New(rpcerror.Unauthenticated, "the basic credentials are malformed"),
+			),
+			Entry(
+				"unsupported authorization scheme",
+				func(r *http.Request) { r.Header.Set("Authorization", "Digest <digest>") },
+				rpcerror.New(rpcerror.Unauthenticated, "the RPC call requires authentication"),
+			),
+		)
+
+		It("sends the rpcerror.Error returned by the verifier", func() {
+			policy.Verify = func(
+				context.Context,
+				Credentials,
+			) (middleware.Principal, error) {
+				return nil, rpcerror.New(rpcerror.PermissionDenied, "<error>")
+			}
+
+			response := serve(
+				NewHandler(WithAuthentication(policy)),
+				"/protean.test/TestService/Unary",
+				func(r *http.Request) { r.Header.Set("Authorization", "Bearer <token>") },
+			)
+
+			expectError(
+				response,
+				http.StatusForbidden,
+				"application/json; x-proto=protean.v1.Error",
+				rpcerror.New(rpcerror.PermissionDenied, "<error>"),
+			)
+		})
+
+		It("permits calls without credentials if the policy is optional", func() {
+			policy.Optional = true
+
+			response := serve(
+				NewHandler(WithAuthentication(policy)),
+				"/protean.test/TestService/Unary",
+				func(*http.Request) {},
+			)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response.Body.String()).To(MatchJSON(`{"data":"<anonymous>"}`))
+		})
+
+		It("does not authenticate calls to methods that are not targeted by the policy", func() {
+			response := serve(
+				NewHandler(WithAuthentication(policy, "protean.test.TestService/ServerStream")),
+				"/protean.test/TestService/Unary",
+				func(*http.Request) {},
+			)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response.Body.String()).To(MatchJSON(`{"data":"<anonymous>"}`))
+		})
+
+		It("authenticates the call before invoking other interceptors", func() {
+			var principal middleware.Principal
+
+			handler := NewHandler(
+				WithServerInterceptor(&middleware.RateLimiter{
+					Limit: middleware.RateLimit{Rate: 1, Burst: 1},
+					Key: func(ctx context.Context, _ string) string {
+						principal, _ = middleware.PrincipalFromContext(ctx)
+						return middleware.KeyByPrincipal(ctx, "")
+					},
+				}),
+				WithAuthentication(policy),
+			)
+
+			response := serve(
+				handler,
+				"/protean.test/TestService/Unary",
+				func(r *http.Request) { r.Header.Set("Authorization", "Bearer <token>") },
+			)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(principal).To(Equal(testPrincipal("<bearer-user>")))
+		})
+
+		It("authenticates calls to streaming methods", func() {
+			handler := NewHandler(WithAuthentication(policy))
+			testservice.RegisterProteanTestService(handler, service)
+
+			request := httptest.NewRequest(
+				http.MethodPost,
+				"/protean.test/TestService/ServerStream",
+				strings.NewReader(`{"data":"<input>"}`),
+			)
+			request.Header.Set("Content-Type", "application/json")
+			request.Header.Set("Accept", "text/event-stream")
+			request.Header.Set("Authorization", "Bearer <token>")
+
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, request)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response.Body.String()).To(Equal(
+				"data: " + marshalJSON(&testservice.Output{Data: "<bearer-user>"}) + "\n\n" +
+					"event: done\ndata: \n\n",
+			))
+		})
+
+		DescribeTable(
+			"it responds with an HTTP '401 Unauthorized' status before starting a stream",
+			func(method, target, contentType, accept string) {
+				handler := NewHandler(WithAuthentication(policy))
+				testservice.RegisterProteanTestService(handler, service)
+
+				request := httptest.NewRequest(method, target, nil)
+				if contentType != "" {
+					request.Header.Set("Content-Type", contentType)
+				}
+				if accept != "" {
+					request.Header.Set("Accept", accept)
+				}
+
+				response := httptest.NewRecorder()
+				handler.ServeHTTP(response, request)
+
+				Expect(response.Header().Values("WWW-Authenticate")).To(ConsistOf(
+					`Bearer realm="<realm>"`,
+					`Basic realm="<realm>"`,
+				))
+
+				expectError(
+					response,
+					http.StatusUnauthorized,
+					"text/plain; charset=utf-8; x-proto=protean.v1.Error",
+					rpcerror.New(rpcerror.Unauthenticated, "the RPC call requires authentication"),
+				)
+			},
+			Entry(
+				"server-sent events",
+				http.MethodGet,
+				"/protean.test/TestService/ServerStream?in="+url.QueryEscape(`{"data":"<input>"}`),
+				"",
+				"text/event-stream",
+			),
+			Entry(
+				"framed request body",
+				http.MethodPost,
+				"/protean.test/TestService/BidirectionalStream",
+				"application/vnd.protean.stream+ndjson",
+				"",
+			),
+		)
+
+		It("responds with an HTTP '401 Unauthorized' status before starting a Connect stream", func() {
+			handler := NewHandler(WithAuthentication(policy))
+			testservice.RegisterProteanTestService(handler, service)
+
+			request := httptest.NewRequest(
+				http.MethodPost,
+				"/protean.test.TestService/ServerStream",
+				nil,
+			)
+			request.Header.Set("Content-Type", "application/connect+json")
+
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, request)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusUnauthorized))
+			Expect(response.Header().Values("WWW-Authenticate")).To(ConsistOf(
+				`Bearer realm="<realm>"`,
+				`Basic realm="<realm>"`,
+			))
+			Expect(response.Body.String()).To(MatchJSON(`{
+				"code": "unauthenticated",
+				"message": "the RPC call requires authentication"
+			}`))
+		})
+
+		When("the call is made via a websocket", func() {
+			var (
+				ctx    context.Context
+				cancel context.CancelFunc
+				server *httptest.Server
+			)
+
+			BeforeEach(func() {
+				ctx, cancel = context.WithCancel(context.Background())
+
+				handler := NewHandler(WithAuthentication(policy))
+				testservice.RegisterProteanTestService(handler, service)
+
+				server = httptest.NewServer(handler)
+			})
+
+			AfterEach(func() {
+				cancel()
+				server.Close()
+			})
+
+			// dial opens a websocket connection to the Unary method.
+			dial := func(query string) (*websocket.Conn, *http.Response, error) {
+				dialer := websocket.Dialer{
+					Subprotocols: []string{"protean.v1+json"},
+				}
+
+				return dialer.DialContext(
+					ctx,
+					"ws"+strings.TrimPrefix(server.URL, "http")+"/protean.test/TestService/Unary"+query,
+					nil,
+				)
+			}
+
+			It("authenticates the connection before it is upgraded", func() {
+				conn, res, err := dial("?api_key=" + url.QueryEscape("<key>"))
+				Expect(err).ShouldNot(HaveOccurred())
+				res.Body.Close()
+				defer conn.Close()
+
+				sendInput(conn, "application/json", &testservice.Input{Data: "<input>"})
+
+				expectOutput(conn, "application/json", "<api-key-user>")
+				expectDone(conn, "application/json")
+			})
+
+			It("responds with an HTTP '401 Unauthorized' status if the connection is not authenticated", func() {
+				_, res, err := dial("")
+				Expect(err).To(MatchError(websocket.ErrBadHandshake))
+				defer res.Body.Close()
+
+				Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+				Expect(res.Header.Values("WWW-Authenticate")).To(ConsistOf(
+					`Bearer realm="<realm>"`,
+					`Basic realm="<realm>"`,
+				))
+			})
+		})
+
+		When("the authorizer is installed", func() {
+			// serve calls the NoSideEffects method, which declares required
+			// scopes, using the given bearer token.
+			serve := func(token string) *httptest.ResponseRecorder {
+				handler := NewHandler(
+					WithAuthentication(policy),
+					WithServerInterceptor(middleware.Authorizer{}),
+				)
+
+				return serve(
+					handler,
+					"/protean.test/TestService/NoSideEffects",
+					func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) },
+				)
+			}
+
+			BeforeEach(func() {
+				service.NoSideEffectsFunc = func(
+					context.Context,
+					*testservice.Input,
+				) (*testservice.Output, error) {
+					return &testservice.Output{Data: "<output>"}, nil
+				}
+			})
+
+			It("permits calls by principals that have the method's required scopes", func() {
+				response := serve("<scoped-token>")
+
+				Expect(response).To(HaveHTTPStatus(http.StatusOK))
+				Expect(response.Body.String()).To(MatchJSON(`{"data":"<output>"}`))
+			})
+
+			It("responds with an HTTP '403 Forbidden' status if the principal does not have the required scopes", func() {
+				response := serve("<token>")
+
+				expectError(
+					response,
+					http.StatusForbidden,
+					"application/json; x-proto=protean.v1.Error",
+					rpcerror.New(
+						rpcerror.PermissionDenied,
+						"the caller has not been granted any scopes",
+					),
+				)
+			})
+		})
+
+		It("panics if the policy does not have a Verify function", func() {
+			Expect(func() {
+				WithAuthentication(AuthenticationPolicy{Bearer: true})
+			}).To(PanicWith("authentication policy must have a Verify function"))
+		})
+
+		It("panics if the policy does not enable any credentials", func() {
+			Expect(func() {
+				WithAuthentication(AuthenticationPolicy{Verify: policy.Verify})
+			}).To(PanicWith("authentication policy must enable at least one type of credentials"))
+		})
+	})
+})
+
+// testPrincipal is a middleware.Principal identified by a string.
+type testPrincipal string
+
+func (p testPrincipal) ID() string {
+	return string(p)
+}
+
+// scopedTestPrincipal is a middleware.ScopedPrincipal that has been granted a
+// fixed set of scopes.
+type scopedTestPrincipal []string
+
+func (p scopedTestPrincipal) ID() string {
+	return "<scoped-user>"
+}
+
+func (p scopedTestPrincipal) HasScope(scope string) bool {
+	for _, s := range p {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
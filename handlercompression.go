@@ -0,0 +1,128 @@
+package protean
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dogmatiq/protean/compression"
+	"github.com/dogmatiq/protean/rpcerror"
+)
+
+// defaultCompressionAlgorithms is the set of compression algorithms that the
+// handler supports by default, in order of preference.
+var defaultCompressionAlgorithms = []compression.Algorithm{
+	compression.Gzip,
+	compression.Deflate,
+}
+
+// compressionAlgorithm returns the compression algorithm with the given
+// content-coding name.
+func (h *handler) compressionAlgorithm(name string) (compression.Algorithm, bool) {
+	for _, a := range h.compressionAlgorithms {
+		if strings.EqualFold(a.Name(), name) {
+			return a, true
+		}
+	}
+
+	return nil, false
+}
+
+// acceptEncodingHeader returns the value to use for the Accept-Encoding header
+// in HTTP responses, which lists the content-codings that the handler accepts
+// in request bodies.
+func (h *handler) acceptEncodingHeader() string {
+	names := []string{"identity"}
+	for _, a := range h.compressionAlgorithms {
+		names = append(names, a.Name())
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// errUnsupportedContentEncoding is the cause of the error returned by
+// decompressRequestBody() when the request body uses a content-coding that the
+// handler does not support.
+var errUnsupportedContentEncoding = errors.New("unsupported content-coding")
+
+// decompressRequestBody decompresses the request body in data as per the
+// request's Content-Encoding header.
+//
+// limit is the maximum size of the RPC input message, in bytes. It applies to
+// the decompressed data, such that a small request body can not be used to
+// exhaust the server's memory.
+func (h *handler) decompressRequestBody(
+	r *http.Request,
+	data []byte,
+	limit int,
+) ([]byte, rpcerror.Error, bool) {
+	enc := strings.TrimSpace(r.Header.Get("Content-Encoding"))
+	if isIdentityEncoding(enc) {
+		return data, rpcerror.Error{}, true
+	}
+
+	a, ok := h.compressionAlgorithm(enc)
+	if !ok {
+		return nil, rpcerror.New(
+			rpcerror.NotImplemented,
+			"the server does not support the '%s' content-coding supplied by the client",
+			enc,
+		).WithCause(errUnsupportedContentEncoding), false
+	}
+
+	data, err := compression.Decompress(a, data, limit)
+	if errors.Is(err, compression.ErrTooLarge) {
+		return nil, newInputSizeLimitError(
+			limit,
+			"the decompressed RPC input message length exceeds the maximum allowable size",
+		), false
+	}
+	if err != nil {
+		return nil, rpcerror.New(
+			rpcerror.InvalidInput,
+			"the request body could not be decompressed using the '%s' content-coding",
+			a.Name(),
+		), false
+	}
+
+	return data, rpcerror.Error{}, true
+}
+
+// compressResponseBody compresses the response body in data using the
+// algorithm negotiated from the request's Accept-Encoding header.
+//
+// It sets the Content-Encoding and Vary headers as appropriate and returns the
+// data to write to the response body. Bodies smaller than the compression
+// threshold are not compressed.
+func (h *handler) compressResponseBody(
+	w http.ResponseWriter,
+	r *http.Request,
+	data []byte,
+) []byte {
+	if len(h.compressionAlgorithms) == 0 {
+		return data
+	}
+
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	if len(data) < h.compressionThreshold {
+		return data
+	}
+
+	a, ok := compression.Negotiate(
+		strings.Join(r.Header.Values("Accept-Encoding"), ","),
+		h.compressionAlgorithms,
+	)
+	if !ok {
+		return data
+	}
+
+	compressed, err := compression.Compress(a, data)
+	if err != nil || len(compressed) >= len(data) {
+		return data
+	}
+
+	w.Header().Set("Content-Encoding", a.Name())
+
+	return compressed
+}
@@ -0,0 +1,174 @@
+package protean_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/compression"
+	"github.com/dogmatiq/protean/internal/testservice"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("type Handler (compression)", func() {
+	var (
+		handler  Handler
+		response *httptest.ResponseRecorder
+	)
+
+	small := strings.Repeat("<data>", 50)
+	large := strings.Repeat("<data>", 1000)
+
+	// setup creates the handler under test with the given options.
+	setup := func(options ...HandlerOption) {
+		handler = NewHandler(options...)
+
+		testservice.RegisterProteanTestService(
+			handler,
+			&testservice.Stub{
+				UnaryFunc: func(
+					_ context.Context,
+					in *testservice.Input,
+				) (*testservice.Output, error) {
+					return &testservice.Output{
+						Data: in.GetData(),
+					}, nil
+				},
+			},
+		)
+	}
+
+	// post makes a POST request with the given body, compressed using the
+	// given algorithm, if any.
+	post := func(body []byte, a compression.Algorithm, acceptEncoding string) {
+		req := httptest.NewRequest(
+			http.MethodPost,
+			"/protean.test/TestService/Unary",
+			bytes.NewReader(body),
+		)
+		req.Header.Set("Content-Type", "application/json")
+
+		if a != nil {
+			req.Header.Set("Content-Encoding", a.Name())
+		}
+
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+
+		handler.ServeHTTP(response, req)
+	}
+
+	// compress compresses data using the given algorithm.
+	compress := func(a compression.Algorithm, data string) []byte {
+		compressed, err := compression.Compress(a, []byte(data))
+		Expect(err).ShouldNot(HaveOccurred())
+		return compressed
+	}
+
+	BeforeEach(func() {
+		response = httptest.NewRecorder()
+		setup()
+	})
+
+	Describe("func ServeHTTP()", func() {
+		It("decompresses request bodies as per the Content-Encoding header", func() {
+			post(compress(compression.Deflate, `{"data":"hello"}`), compression.Deflate, "")
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Body.String()).To(MatchJSON(`{"data":"hello"}`))
+		})
+
+		It("compresses response bodies as per the Accept-Encoding header", func() {
+			post([]byte(`{"data":"`+large+`"}`), nil, "deflate;q=0.5, gzip")
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header().Get("Content-Encoding")).To(Equal("gzip"))
+			Expect(response.Header().Get("Vary")).To(Equal("Accept-Encoding"))
+
+			data, err := compression.Decompress(compression.Gzip, response.Body.Bytes(), 1_000_000)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(data)).To(MatchJSON(`{"data":"` + large + `"}`))
+		})
+
+		It("does not compress response bodies that are smaller than the threshold", func() {
+			post([]byte(`{"data":"`+small+`"}`), nil, "gzip")
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header()).NotTo(HaveKey("Content-Encoding"))
+			Expect(response.Body.String()).To(MatchJSON(`{"data":"` + small + `"}`))
+		})
+
+		It("does not compress response bodies if the client does not accept compression", func() {
+			post([]byte(`{"data":"`+large+`"}`), nil, "")
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header()).NotTo(HaveKey("Content-Encoding"))
+		})
+
+		It("uses the threshold set by WithCompressionThreshold()", func() {
+			setup(WithCompressionThreshold(100))
+
+			post([]byte(`{"data":"`+small+`"}`), nil, "gzip")
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header().Get("Content-Encoding")).To(Equal("gzip"))
+		})
+
+		It("responds with a '415 Unsupported Media Type' status if the content-coding is not supported", func() {
+			setup(WithCompressionAlgorithms(compression.Gzip))
+
+			post(compress(compression.Deflate, `{"data":"hello"}`), compression.Deflate, "")
+
+			Expect(response.Code).To(Equal(http.StatusUnsupportedMediaType))
+			Expect(response.Header().Get("Accept-Encoding")).To(Equal("identity, gzip"))
+		})
+
+		It("responds with a '400 Bad Request' status if the request body can not be decompressed", func() {
+			post([]byte(`{"data":"hello"}`), compression.Gzip, "")
+
+			Expect(response.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("responds with a '413 Request Entity Too Large' status if the decompressed request body is too large", func() {
+			setup(WithMaxRPCInputSize(1000))
+
+			body := compress(compression.Gzip, `{"data":"`+large+`"}`)
+			Expect(len(body)).To(BeNumerically("<", 1000))
+
+			post(body, compression.Gzip, "")
+
+			Expect(response.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		})
+
+		It("disables compression if WithCompressionAlgorithms() is used without any algorithms", func() {
+			setup(WithCompressionAlgorithms())
+
+			post([]byte(`{"data":"`+large+`"}`), nil, "gzip")
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header()).NotTo(HaveKey("Content-Encoding"))
+		})
+
+		It("decompresses Twirp request bodies", func() {
+			setup(WithTwirpPrefix("/twirp"))
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/twirp/protean.test.TestService/Unary",
+				bytes.NewReader(compress(compression.Gzip, `{"data":"hello"}`)),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Content-Encoding", "gzip")
+
+			handler.ServeHTTP(response, req)
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Body.String()).To(MatchJSON(`{"data":"hello"}`))
+		})
+	})
+})
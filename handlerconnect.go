@@ -0,0 +1,494 @@
+package protean
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/rpcerror"
+	"github.com/dogmatiq/protean/runtime"
+	"google.golang.org/protobuf/proto"
+)
+
+// connectUnaryMediaTypes maps each of the media types supported for Connect
+// unary requests to the media type used to encode the RPC messages.
+var connectUnaryMediaTypes = map[string]string{
+	"application/proto": protomime.BinaryMediaTypes[0],
+	"application/json":  protomime.JSONMediaTypes[0],
+}
+
+// connectStreamMediaTypes maps each of the media types supported for Connect
+// streaming requests to the media type used to encode the RPC messages.
+var connectStreamMediaTypes = map[string]string{
+	"application/connect+proto": protomime.BinaryMediaTypes[0],
+	"application/connect+json":  protomime.JSONMediaTypes[0],
+}
+
+// connectGETEncodings maps the values of the "encoding" query parameter used
+// by Connect GET requests to the media type used to encode the RPC messages.
+var connectGETEncodings = map[string]string{
+	"proto": protomime.BinaryMediaTypes[0],
+	"json":  protomime.JSONMediaTypes[0],
+}
+
+// connectEndStreamFlag is the bit within a Connect envelope's flags that
+// indicates that the envelope contains the end-of-stream message.
+const connectEndStreamFlag = 0x02
+
+// isConnectRequest returns true if r is a request made using the Connect
+// protocol.
+func isConnectRequest(r *http.Request) bool {
+	if r.Header.Get("Connect-Protocol-Version") != "" {
+		return true
+	}
+
+	if r.Method == http.MethodGet {
+		return r.URL.Query().Get("connect") == "v1"
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(mediaType, "application/connect+")
+}
+
+// serveConnect serves an RPC request made using the Connect protocol.
+//
+// Like gRPC, Connect requests identify the RPC method using the
+// "/<package>.<service>/<method>" pattern.
+//
+// See https://connectrpc.com/docs/protocol.
+func (h *handler) serveConnect(w http.ResponseWriter, r *http.Request) {
+	service, method, rpcErr, ok := h.resolveQualifiedMethod(r.URL.Path)
+	if !ok {
+		writeConnectError(w, http.StatusNotFound, rpcErr)
+		return
+	}
+
+	if v := r.Header.Get("Connect-Protocol-Version"); v != "" && v != "1" {
+		writeConnectError(
+			w,
+			http.StatusBadRequest,
+			rpcerror.New(
+				rpcerror.InvalidInput,
+				"the Connect-Protocol-Version header must be '1'",
+			),
+		)
+		return
+	}
+
+	r, rpcErr, ok = h.authenticateRequest(r, method)
+	if !ok {
+		writeConnectError(w, connectHTTPStatus(rpcErr.Code()), rpcErr)
+		return
+	}
+
+	ctx := r.Context()
+
+	if v := r.Header.Get("Connect-Timeout-Ms"); v != "" {
+		timeout, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || len(v) > 10 {
+			writeConnectError(
+				w,
+				http.StatusBadRequest,
+				rpcerror.New(
+					rpcerror.InvalidInput,
+					"the Connect-Timeout-Ms header is invalid",
+				),
+			)
+			return
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Millisecond)
+		defer cancel()
+	}
+
+	r = r.WithContext(ctx)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if messageMediaType, ok := connectStreamMediaTypes[mediaType]; ok {
+		h.serveConnectStream(w, r, method, mediaType, messageMediaType)
+		return
+	}
+
+	if method.InputIsStream() || method.OutputIsStream() {
+		writeConnectError(
+			w,
+			http.StatusUnsupportedMediaType,
+			rpcerror.New(
+				rpcerror.NotImplemented,
+				"the '%s' RPC method uses streaming inputs or outputs and must be called using a Connect streaming media-type",
+				method.Name(),
+			),
+		)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		h.serveConnectUnaryPOST(w, r, method, mediaType)
+	case http.MethodGet:
+		if h.allowsGET(service, method) {
+			h.serveConnectUnaryGET(w, r, method)
+			return
+		}
+		fallthrough
+	default:
+		writeConnectError(
+			w,
+			http.StatusMethodNotAllowed,
+			rpcerror.New(
+				rpcerror.NotImplemented,
+				"the HTTP method must be POST",
+			),
+		)
+	}
+}
+
+// serveConnectUnaryPOST serves a Connect unary RPC request made using the HTTP
+// POST method.
+func (h *handler) serveConnectUnaryPOST(
+	w http.ResponseWriter,
+	r *http.Request,
+	method runtime.Method,
+	mediaType string,
+) {
+	messageMediaType, ok := connectUnaryMediaTypes[mediaType]
+	if !ok {
+		writeConnectError(
+			w,
+			http.StatusUnsupportedMediaType,
+			rpcerror.New(
+				rpcerror.NotImplemented,
+				"the server does not support the '%s' media-type supplied by the client",
+				mediaType,
+			),
+		)
+		return
+	}
+
+	data, rpcErr, ok := h.readUnaryInput(r, 0, h.maxInputSizeFor(method))
+	if !ok {
+		writeConnectError(w, connectHTTPStatus(rpcErr.Code()), rpcErr)
+		return
+	}
+
+	h.callConnectUnary(w, r, method, messageMediaType, mediaType, data)
+}
+
+// serveConnectUnaryGET serves a Connect unary RPC request made using the HTTP
+// GET method.
+//
+// The RPC input message is encoded in the "message" query parameter, as per
+// the "encoding" and "base64" query parameters.
+func (h *handler) serveConnectUnaryGET(
+	w http.ResponseWriter,
+	r *http.Request,
+	method runtime.Method,
+) {
+	q := r.URL.Query()
+
+	messageMediaType, ok := connectGETEncodings[q.Get("encoding")]
+	if !ok {
+		writeConnectError(
+			w,
+			http.StatusUnsupportedMediaType,
+			rpcerror.New(
+				rpcerror.NotImplemented,
+				"the 'encoding' query parameter must be either 'proto' or 'json'",
+			),
+		)
+		return
+	}
+
+	if !isIdentityEncoding(q.Get("compression")) {
+		writeConnectError(
+			w,
+			http.StatusNotImplemented,
+			rpcerror.New(
+				rpcerror.NotImplemented,
+				"the server does not support the '%s' compression algorithm",
+				q.Get("compression"),
+			),
+		)
+		return
+	}
+
+	data := []byte(q.Get("message"))
+
+	if q.Get("base64") == "1" {
+		var err error
+		data, err = decodeBase64(string(data))
+		if err != nil {
+			writeConnectError(
+				w,
+				http.StatusBadRequest,
+				rpcerror.New(
+					rpcerror.InvalidInput,
+					"the 'message' query parameter is not valid base64",
+				),
+			)
+			return
+		}
+	}
+
+	mediaType := "application/" + q.Get("encoding")
+	h.callConnectUnary(w, r, method, messageMediaType, mediaType, data)
+}
+
+// callConnectUnary calls a unary RPC method with the RPC input message in data
+// and writes the Connect response to w.
+func (h *handler) callConnectUnary(
+	w http.ResponseWriter,
+	r *http.Request,
+	method runtime.Method,
+	messageMediaType string,
+	mediaType string,
+	data []byte,
+) {
+	data, rpcErr, ok := h.invokeUnary(r.Context(), method, messageMediaType, data)
+	if !ok {
+		writeConnectError(w, connectHTTPStatus(rpcErr.Code()), rpcErr)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", mediaType)
+	data = h.compressResponseBody(w, r, data)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// serveConnectStream serves a Connect streaming RPC request.
+//
+// Any kind of RPC method may be called using the streaming protocol.
+func (h *handler) serveConnectStream(
+	w http.ResponseWriter,
+	r *http.Request,
+	method runtime.Method,
+	mediaType string,
+	messageMediaType string,
+) {
+	if r.Method != http.MethodPost {
+		writeConnectError(
+			w,
+			http.StatusMethodNotAllowed,
+			rpcerror.New(
+				rpcerror.NotImplemented,
+				"the HTTP method must be POST",
+			),
+		)
+		return
+	}
+
+	if !isIdentityEncoding(r.Header.Get("Connect-Content-Encoding")) {
+		writeConnectError(
+			w,
+			http.StatusNotImplemented,
+			rpcerror.New(
+				rpcerror.NotImplemented,
+				"the server does not support the '%s' message encoding",
+				r.Header.Get("Connect-Content-Encoding"),
+			),
+		)
+		return
+	}
+
+	marshaler, _ := protomime.MarshalerForMediaType(messageMediaType)
+	unmarshaler, _ := protomime.UnmarshalerForMediaType(messageMediaType)
+
+	rc := http.NewResponseController(w)
+	_ = rc.EnableFullDuplex()
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(http.StatusOK)
+	_ = rc.Flush()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	c := &streamCall{
+		stream: &connectFrameStream{
+			grpcFrameStream{
+				r:           r,
+				w:           w,
+				rc:          rc,
+				body:        bufio.NewReader(r.Body),
+				protocol:    grpcProtocol{MessageMediaType: messageMediaType},
+				marshaler:   marshaler,
+				unmarshaler: unmarshaler,
+				maxSize:     h.maxInputSizeFor(method),
+			},
+		},
+		frameName: "Connect envelope",
+		method:    method,
+		cancel:    cancel,
+		call:      h.newCall(ctx, method),
+	}
+
+	c.run()
+}
+
+// connectFrameStream is a frameStream that exchanges Connect streaming
+// envelopes over HTTP request and response bodies.
+//
+// Connect envelopes are identical to gRPC's length-prefixed messages, except
+// that the outcome of the call is sent in a final "end-of-stream" envelope
+// instead of in the trailers.
+type connectFrameStream struct {
+	grpcFrameStream
+}
+
+// connectEndStream is the JSON message sent in the final envelope of a Connect
+// streaming response.
+type connectEndStream struct {
+	Error *connectError `json:"error,omitempty"`
+}
+
+// WriteDone writes the end-of-stream envelope for a call that succeeded.
+func (s *connectFrameStream) WriteDone() error {
+	return s.writeEndStream(connectEndStream{})
+}
+
+// WriteError writes the end-of-stream envelope for a call that failed.
+func (s *connectFrameStream) WriteError(rpcErr rpcerror.Error) error {
+	return s.writeEndStream(
+		connectEndStream{
+			Error: newConnectError(rpcErr),
+		},
+	)
+}
+
+// writeEndStream writes the end-of-stream envelope.
+func (s *connectFrameStream) writeEndStream(m connectEndStream) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return s.writeFrame(connectEndStreamFlag, data)
+}
+
+// connectError is the JSON representation of an error in the Connect
+// protocol.
+type connectError struct {
+	Code    string               `json:"code"`
+	Message string               `json:"message,omitempty"`
+	Details []connectErrorDetail `json:"details,omitempty"`
+}
+
+// connectErrorDetail is the JSON representation of an error detail message
+// in the Connect protocol.
+type connectErrorDetail struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// newConnectError returns the Connect representation of an RPC error.
+func newConnectError(rpcErr rpcerror.Error) *connectError {
+	e := &connectError{
+		Code:    connectCodeFromErrorCode(rpcErr.Code()),
+		Message: rpcErr.Message(),
+	}
+
+	if details, ok, err := rpcErr.Details(); ok && err == nil {
+		if data, err := proto.Marshal(details); err == nil {
+			e.Details = append(
+				e.Details,
+				connectErrorDetail{
+					Type:  string(proto.MessageName(details)),
+					Value: base64.RawStdEncoding.EncodeToString(data),
+				},
+			)
+		}
+	}
+
+	return e
+}
+
+// writeConnectError writes a Connect unary error response to w.
+func writeConnectError(
+	w http.ResponseWriter,
+	status int,
+	rpcErr rpcerror.Error,
+) {
+	data, err := json.Marshal(newConnectError(rpcErr))
+	if err != nil {
+		panic(err)
+	}
+
+	setRetryAfterHeader(w, rpcErr)
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", protomime.JSONMediaTypes[0])
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+// isIdentityEncoding returns true if the given content encoding or compression
+// algorithm name refers to uncompressed data.
+func isIdentityEncoding(enc string) bool {
+	return enc == "" || enc == "identity"
+}
+
+// connectHTTPStatus returns the HTTP status to send when a Connect unary call
+// fails with an error with the given code.
+func connectHTTPStatus(c rpcerror.Code) int {
+	switch c {
+	case rpcerror.Canceled:
+		return 499 // non-standard "Client Closed Request" status
+	case rpcerror.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	}
+
+	return httpStatusFromErrorCode(c)
+}
+
+// connectCodeFromErrorCode returns the Connect error code to send when an error
+// with the given code occurs.
+func connectCodeFromErrorCode(c rpcerror.Code) string {
+	switch c {
+	case rpcerror.DeadlineExceeded:
+		return "deadline_exceeded"
+	case rpcerror.Canceled:
+		return "canceled"
+	case rpcerror.InvalidInput:
+		return "invalid_argument"
+	case rpcerror.Unauthenticated:
+		return "unauthenticated"
+	case rpcerror.PermissionDenied:
+		return "permission_denied"
+	case rpcerror.NotFound:
+		return "not_found"
+	case rpcerror.AlreadyExists:
+		return "already_exists"
+	case rpcerror.ResourceExhausted:
+		return "resource_exhausted"
+	case rpcerror.FailedPrecondition:
+		return "failed_precondition"
+	case rpcerror.Aborted:
+		return "aborted"
+	case rpcerror.Unavailable:
+		return "unavailable"
+	case rpcerror.NotImplemented:
+		return "unimplemented"
+	}
+
+	return "unknown"
+}
@@ -0,0 +1,355 @@
+package protean_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/rpcerror"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ = Describe("type Handler (Connect)", func() {
+	var (
+		ctx      context.Context
+		cancel   context.CancelFunc
+		handler  Handler
+		service  *testservice.Stub
+		response *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		format.TruncatedDiff = false
+
+		ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+
+		handler = NewHandler()
+
+		service = &testservice.Stub{
+			UnaryFunc: func(
+				_ context.Context,
+				in *testservice.Input,
+			) (*testservice.Output, error) {
+				return &testservice.Output{
+					Data: strings.ToUpper(in.GetData()),
+				}, nil
+			},
+			NoSideEffectsFunc: func(
+				_ context.Context,
+				in *testservice.Input,
+			) (*testservice.Output, error) {
+				return &testservice.Output{
+					Data: strings.ToUpper(in.GetData()),
+				}, nil
+			},
+			ServerStreamFunc: func(
+				ctx context.Context,
+				in *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				defer close(outputs)
+
+				for _, r := range in.GetData() {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case outputs <- &testservice.Output{Data: string(r)}:
+					}
+				}
+
+				return nil
+			},
+		}
+
+		testservice.RegisterProteanTestService(handler, service)
+
+		response = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		format.TruncatedDiff = true
+		cancel()
+	})
+
+	// post makes a Connect POST request to the given RPC method.
+	post := func(method, contentType string, body []byte) {
+		req := httptest.NewRequest(
+			http.MethodPost,
+			"/protean.test.TestService/"+method,
+			bytes.NewReader(body),
+		).WithContext(ctx)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Connect-Protocol-Version", "1")
+
+		handler.ServeHTTP(response, req)
+	}
+
+	// expectConnectError asserts that the response is a Connect unary error
+	// response.
+	expectConnectError := func(status int, code, message string) map[string]any {
+		Expect(response.Code).To(Equal(status))
+		Expect(response.Header().Get("Content-Type")).To(Equal("application/json"))
+
+		var body map[string]any
+		err := json.Unmarshal(response.Body.Bytes(), &body)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(body).To(HaveKeyWithValue("code", code))
+		Expect(body).To(HaveKeyWithValue("message", message))
+
+		return body
+	}
+
+	Describe("func ServeHTTP()", func() {
+		It("calls unary methods using JSON", func() {
+			post("Unary", "application/json", []byte(`{"data":"hello"}`))
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header().Get("Content-Type")).To(Equal("application/json"))
+			Expect(response.Body.String()).To(MatchJSON(`{"data":"HELLO"}`))
+		})
+
+		It("calls unary methods using protocol buffers", func() {
+			data, err := proto.Marshal(&testservice.Input{Data: "hello"})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			post("Unary", "application/proto", data)
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header().Get("Content-Type")).To(Equal("application/proto"))
+
+			out := &testservice.Output{}
+			err = proto.Unmarshal(response.Body.Bytes(), out)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(out.GetData()).To(Equal("HELLO"))
+		})
+
+		It("calls methods without side-effects using GET", func() {
+			q := url.Values{
+				"connect":  {"v1"},
+				"encoding": {"json"},
+				"message":  {`{"data":"hello"}`},
+			}
+
+			req := httptest.NewRequest(
+				http.MethodGet,
+				"/protean.test.TestService/NoSideEffects?"+q.Encode(),
+				nil,
+			).WithContext(ctx)
+
+			handler.ServeHTTP(response, req)
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Body.String()).To(MatchJSON(`{"data":"HELLO"}`))
+		})
+
+		It("accepts base64 encoded messages in GET requests", func() {
+			data, err := proto.Marshal(&testservice.Input{Data: "hello"})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			q := url.Values{
+				"connect":  {"v1"},
+				"encoding": {"proto"},
+				"base64":   {"1"},
+				"message":  {base64.RawURLEncoding.EncodeToString(data)},
+			}
+
+			req := httptest.NewRequest(
+				http.MethodGet,
+				"/protean.test.TestService/NoSideEffects?"+q.Encode(),
+				nil,
+			).WithContext(ctx)
+
+			handler.ServeHTTP(response, req)
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header().Get("Content-Type")).To(Equal("application/proto"))
+
+			out := &testservice.Output{}
+			err = proto.Unmarshal(response.Body.Bytes(), out)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(out.GetData()).To(Equal("HELLO"))
+		})
+
+		It("does not allow GET requests to methods that have side-effects", func() {
+			req := httptest.NewRequest(
+				http.MethodGet,
+				"/protean.test.TestService/Unary?connect=v1&encoding=json&message=%7B%7D",
+				nil,
+			).WithContext(ctx)
+
+			handler.ServeHTTP(response, req)
+
+			expectConnectError(
+				http.StatusMethodNotAllowed,
+				"unimplemented",
+				"the HTTP method must be POST",
+			)
+		})
+
+		It("maps the error returned by the RPC method to a Connect error", func() {
+			service.UnaryFunc = func(
+				context.Context,
+				*testservice.Input,
+			) (*testservice.Output, error) {
+				return nil, rpcerror.New(
+					rpcerror.NotFound,
+					"<error>",
+				).WithDetails(
+					&testservice.Output{Data: "<details>"},
+				)
+			}
+
+			post("Unary", "application/json", []byte(`{"data":"hello"}`))
+
+			body := expectConnectError(http.StatusNotFound, "not_found", "<error>")
+
+			data, err := proto.Marshal(&testservice.Output{Data: "<details>"})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(body).To(HaveKeyWithValue(
+				"details",
+				[]any{
+					map[string]any{
+						"type":  "protean.test.Output",
+						"value": base64.RawStdEncoding.EncodeToString(data),
+					},
+				},
+			))
+		})
+
+		It("responds with an unimplemented error if the method does not exist", func() {
+			post("Unknown", "application/json", []byte(`{}`))
+
+			expectConnectError(
+				http.StatusNotFound,
+				"unimplemented",
+				"the 'protean.test.TestService' service does not contain an RPC method named 'Unknown'",
+			)
+		})
+
+		It("responds with an invalid_argument error if the input message is malformed", func() {
+			post("Unary", "application/json", []byte(`}`))
+
+			expectConnectError(
+				http.StatusBadRequest,
+				"invalid_argument",
+				"the RPC input message could not be unmarshaled from the request",
+			)
+		})
+
+		It("responds with an invalid_argument error if the protocol version is not supported", func() {
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/protean.test.TestService/Unary",
+				strings.NewReader(`{}`),
+			).WithContext(ctx)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Connect-Protocol-Version", "2")
+
+			handler.ServeHTTP(response, req)
+
+			expectConnectError(
+				http.StatusBadRequest,
+				"invalid_argument",
+				"the Connect-Protocol-Version header must be '1'",
+			)
+		})
+
+		It("applies the deadline specified by the Connect-Timeout-Ms header", func() {
+			service.UnaryFunc = func(
+				ctx context.Context,
+				_ *testservice.Input,
+			) (*testservice.Output, error) {
+				<-ctx.Done()
+				return nil, rpcerror.New(rpcerror.DeadlineExceeded, "<timeout>")
+			}
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/protean.test.TestService/Unary",
+				strings.NewReader(`{"data":"hello"}`),
+			).WithContext(ctx)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Connect-Protocol-Version", "1")
+			req.Header.Set("Connect-Timeout-Ms", "10")
+
+			handler.ServeHTTP(response, req)
+
+			expectConnectError(
+				http.StatusGatewayTimeout,
+				"deadline_exceeded",
+				"<timeout>",
+			)
+		})
+
+		It("calls streaming methods using enveloped messages", func() {
+			var body bytes.Buffer
+			writeGRPCInput(&body, "abc")
+
+			post("ServerStream", "application/connect+proto", body.Bytes())
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header().Get("Content-Type")).To(Equal("application/connect+proto"))
+
+			res := response.Result()
+			expectGRPCOutput(res, "a")
+			expectGRPCOutput(res, "b")
+			expectGRPCOutput(res, "c")
+			expectConnectEndStream(res, `{}`)
+		})
+
+		It("sends errors in the end-of-stream message", func() {
+			service.ServerStreamFunc = func(
+				_ context.Context,
+				_ *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				close(outputs)
+				return rpcerror.New(rpcerror.Unavailable, "<error>")
+			}
+
+			var body bytes.Buffer
+			writeGRPCInput(&body, "abc")
+
+			post("ServerStream", "application/connect+proto", body.Bytes())
+
+			expectConnectEndStream(
+				response.Result(),
+				`{"error":{"code":"unavailable","message":"<error>"}}`,
+			)
+		})
+	})
+})
+
+// expectConnectEndStream asserts that the next envelope in the response body
+// is the last, and that it is an end-of-stream message with the given JSON
+// content.
+func expectConnectEndStream(res *http.Response, content string) {
+	header := make([]byte, 5)
+	_, err := io.ReadFull(res.Body, header)
+	Expect(err).ShouldNot(HaveOccurred())
+	Expect(header[0]).To(Equal(byte(0x02)))
+
+	message := make([]byte, binary.BigEndian.Uint32(header[1:]))
+	_, err = io.ReadFull(res.Body, message)
+	Expect(err).ShouldNot(HaveOccurred())
+	Expect(message).To(MatchJSON(content))
+
+	data, err := io.ReadAll(res.Body)
+	Expect(err).ShouldNot(HaveOccurred())
+	Expect(data).To(BeEmpty())
+}
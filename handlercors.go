@@ -0,0 +1,299 @@
+package protean
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSPolicy describes which cross-origin requests are permitted by the
+// handler.
+//
+// See https://fetch.spec.whatwg.org/#http-cors-protocol.
+type CORSPolicy struct {
+	// AllowedOrigins is the list of origins, such as "https://example.org",
+	// that may make cross-origin requests. The special value "*" allows any
+	// origin.
+	AllowedOrigins []string
+
+	// AllowOrigin is a predicate function that returns true if the given origin
+	// may make cross-origin requests. It is consulted for origins that are not
+	// in AllowedOrigins.
+	AllowOrigin func(origin string) bool
+
+	// AllowCredentials indicates whether the browser may send credentials,
+	// such as cookies, with cross-origin requests.
+	//
+	// Cross-origin websocket connections are only permitted if it is true and
+	// the origin is allowed explicitly, rather than via the "*" wildcard.
+	AllowCredentials bool
+
+	// AllowedHeaders is a list of request headers that the client may send, in
+	// addition to those used by the handler's transports, such as Content-Type.
+	AllowedHeaders []string
+
+	// ExposedHeaders is a list of response headers that the client may read,
+	// in addition to those used by the handler's transports.
+	ExposedHeaders []string
+
+	// MaxAge is the length of time that the browser may cache the result of a
+	// preflight request. If it is zero, the browser's default is used.
+	MaxAge time.Duration
+}
+
+// corsAllowedHeaders is the set of request headers that are always allowed in
+// cross-origin requests, as they are used by the handler's transports.
+var corsAllowedHeaders = []string{
+	"Accept",
+	"Authorization",
+	"Content-Type",
+	"Content-Encoding",
+	"Connect-Protocol-Version",
+	"Connect-Timeout-Ms",
+	"Connect-Content-Encoding",
+	"Connect-Accept-Encoding",
+	"Grpc-Timeout",
+	"Grpc-Encoding",
+	"Grpc-Accept-Encoding",
+	"Protean-Timeout",
+	"X-Grpc-Web",
+	"X-User-Agent",
+}
+
+// corsExposedHeaders is the set of response headers that are always exposed to
+// cross-origin requests, as they are used by the handler's transports.
+var corsExposedHeaders = []string{
+	"Grpc-Status",
+	"Grpc-Message",
+	"Retry-After",
+}
+
+// allowsOrigin returns true if the policy permits cross-origin requests from
+// the given origin.
+func (p *CORSPolicy) allowsOrigin(origin string) bool {
+	for _, o := range p.AllowedOrigins {
+		if o == "*" || o == origin {
+			return true
+		}
+	}
+
+	return p.AllowOrigin != nil && p.AllowOrigin(origin)
+}
+
+// allowOriginHeader returns the value of the Access-Control-Allow-Origin header
+// to send in response to a request from the given origin.
+func (p *CORSPolicy) allowOriginHeader(origin string) string {
+	if !p.AllowCredentials {
+		for _, o := range p.AllowedOrigins {
+			if o == "*" {
+				return "*"
+			}
+		}
+	}
+
+	return origin
+}
+
+// corsTarget describes the RPC method (or methods) that a request refers to,
+// for the purposes of selecting a CORS policy.
+type corsTarget struct {
+	service     string
+	method      string
+	httpMethods []string
+}
+
+// corsPolicy returns the CORS policy that applies to r, if any.
+//
+// It returns false if r does not refer to a known RPC method, or if no policy
+// applies to that method.
+func (h *handler) corsPolicy(r *http.Request) (*CORSPolicy, corsTarget, bool) {
+	if len(h.corsPolicies) == 0 {
+		return nil, corsTarget{}, false
+	}
+
+	t, ok := h.resolveCORSTarget(r)
+	if !ok {
+		return nil, corsTarget{}, false
+	}
+
+	for _, k := range []string{
+		t.service + "/" + t.method,
+		t.service,
+		"",
+	} {
+		if p, ok := h.corsPolicies[k]; ok {
+			return p, t, true
+		}
+	}
+
+	return nil, corsTarget{}, false
+}
+
+// resolveCORSTarget returns the RPC method that r refers to, regardless of the
+// transport that is being used.
+func (h *handler) resolveCORSTarget(r *http.Request) (corsTarget, bool) {
+	if h.jsonRPCPath != "" && r.URL.Path == h.jsonRPCPath {
+		// The method is given in the request body, so only the default policy
+		// can be used.
+		return corsTarget{
+			httpMethods: []string{http.MethodPost},
+		}, true
+	}
+
+	var t corsTarget
+	p := r.URL.EscapedPath()
+
+	for _, route := range h.restRoutes {
+		if _, ok := route.template.match(p); ok {
+			if t.httpMethods == nil {
+				t.service = string(route.service.Descriptor().FullName())
+				t.method = route.method.Name()
+			}
+			t.httpMethods = append(t.httpMethods, route.httpMethod)
+		}
+	}
+
+	if t.httpMethods != nil {
+		return t, true
+	}
+
+	p = r.URL.Path
+	if h.twirpPrefix != "" {
+		p = strings.TrimPrefix(p, h.twirpPrefix)
+	}
+
+	if s, m, _, ok := h.resolveQualifiedMethod(p); ok {
+		return corsTarget{
+			service:     s.Package() + "." + s.Name(),
+			method:      m.Name(),
+			httpMethods: []string{http.MethodGet, http.MethodPost},
+		}, true
+	}
+
+	serviceName, methodName, ok := parsePath(r.URL.Path)
+	if !ok {
+		return corsTarget{}, false
+	}
+
+	s, ok := h.services[serviceName]
+	if !ok {
+		return corsTarget{}, false
+	}
+
+	if _, ok := s.MethodByName(methodName); !ok {
+		return corsTarget{}, false
+	}
+
+	return corsTarget{
+		service:     serviceName,
+		method:      methodName,
+		httpMethods: []string{http.MethodGet, http.MethodPost},
+	}, true
+}
+
+// isPreflightRequest returns true if r is a CORS preflight request.
+func isPreflightRequest(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// writePreflightResponse writes the response to a CORS preflight request.
+//
+// If the policy does not permit the request no CORS headers are sent, which
+// causes the browser to block the actual request. authHeaders is the set of
+// additional request headers that may contain credentials.
+func writePreflightResponse(
+	w http.ResponseWriter,
+	r *http.Request,
+	p *CORSPolicy,
+	t corsTarget,
+	authHeaders []string,
+) {
+	origin := r.Header.Get("Origin")
+
+	w.Header().Add("Vary", "Origin")
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	if p.allowsOrigin(origin) {
+		w.Header().Set("Access-Control-Allow-Origin", p.allowOriginHeader(origin))
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(t.httpMethods, ", "))
+		w.Header().Set(
+			"Access-Control-Allow-Headers",
+			strings.Join(slices.Concat(corsAllowedHeaders, authHeaders, p.AllowedHeaders), ", "),
+		)
+
+		if p.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if p.MaxAge > 0 {
+			w.Header().Set(
+				"Access-Control-Max-Age",
+				strconv.Itoa(int(p.MaxAge/time.Second)),
+			)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setCORSHeaders sets the CORS headers for an actual (non-preflight)
+// cross-origin request.
+//
+// The headers are set before the request is dispatched to a transport, so
+// that they are included in both successful and error responses.
+func setCORSHeaders(w http.ResponseWriter, r *http.Request, p *CORSPolicy) {
+	origin := r.Header.Get("Origin")
+
+	w.Header().Add("Vary", "Origin")
+
+	if origin == "" || !p.allowsOrigin(origin) {
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", p.allowOriginHeader(origin))
+	w.Header().Set(
+		"Access-Control-Expose-Headers",
+		strings.Join(slices.Concat(corsExposedHeaders, p.ExposedHeaders), ", "),
+	)
+
+	if p.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// checkWebSocketOrigin returns true if a websocket connection may be
+// established for r.
+//
+// Connections from the same origin are always permitted. Browsers send cookies
+// with every websocket handshake, and websocket connections are not subject to
+// CORS, so cross-origin connections are only permitted if the applicable CORS
+// policy allows credentials and explicitly allows the origin, either by listing
+// it in AllowedOrigins or via the AllowOrigin function. The "*" wildcard does
+// not permit cross-origin websocket connections.
+func (h *handler) checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	p, _, ok := h.corsPolicy(r)
+	if !ok || !p.AllowCredentials {
+		return false
+	}
+
+	if slices.Contains(p.AllowedOrigins, origin) {
+		return true
+	}
+
+	return p.AllowOrigin != nil && p.AllowOrigin(origin)
+}
@@ -0,0 +1,323 @@
+package protean_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/middleware"
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("type Handler (CORS)", func() {
+	var (
+		handler  Handler
+		response *httptest.ResponseRecorder
+	)
+
+	// setup creates the handler under test with the given options.
+	setup := func(options ...HandlerOption) {
+		handler = NewHandler(options...)
+
+		testservice.RegisterProteanTestService(
+			handler,
+			&testservice.Stub{
+				UnaryFunc: func(
+					_ context.Context,
+					in *testservice.Input,
+				) (*testservice.Output, error) {
+					return &testservice.Output{
+						Data: strings.ToUpper(in.GetData()),
+					}, nil
+				},
+			},
+		)
+	}
+
+	// preflight makes a CORS preflight request to the given URL path.
+	preflight := func(path, origin string) {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "content-type")
+
+		handler.ServeHTTP(response, req)
+	}
+
+	// post makes a cross-origin POST request to the given URL path.
+	post := func(path, origin, body string) {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+
+		handler.ServeHTTP(response, req)
+	}
+
+	BeforeEach(func() {
+		response = httptest.NewRecorder()
+	})
+
+	Describe("func ServeHTTP()", func() {
+		It("answers preflight requests for known RPC methods", func() {
+			setup(
+				WithCORS(CORSPolicy{
+					AllowedOrigins:   []string{"https://example.org"},
+					AllowCredentials: true,
+					AllowedHeaders:   []string{"X-Request-Id"},
+					MaxAge:           10 * time.Minute,
+				}),
+			)
+
+			preflight("/protean.test/TestService/Unary", "https://example.org")
+
+			Expect(response.Code).To(Equal(http.StatusNoContent))
+			Expect(response.Header().Get("Access-Control-Allow-Origin")).To(Equal("https://example.org"))
+			Expect(response.Header().Get("Access-Control-Allow-Methods")).To(Equal("GET, POST"))
+			Expect(response.Header().Get("Access-Control-Allow-Headers")).To(ContainSubstring("Content-Type"))
+			Expect(response.Header().Get("Access-Control-Allow-Headers")).To(ContainSubstring("Content-Encoding"))
+			Expect(response.Header().Get("Access-Control-Allow-Headers")).To(ContainSubstring("Connect-Content-Encoding"))
+			Expect(response.Header().Get("Access-Control-Allow-Headers")).To(ContainSubstring("Grpc-Encoding"))
+			Expect(response.Header().Get("Access-Control-Allow-Headers")).To(ContainSubstring("Authorization"))
+			Expect(response.Header().Get("Access-Control-Allow-Headers")).To(ContainSubstring("X-Request-Id"))
+			Expect(response.Header().Get("Access-Control-Allow-Credentials")).To(Equal("true"))
+			Expect(response.Header().Get("Access-Control-Max-Age")).To(Equal("600"))
+		})
+
+		It("allows the headers that contain API keys in preflight requests", func() {
+			setup(
+				WithCORS(CORSPolicy{
+					AllowedOrigins: []string{"https://example.org"},
+				}),
+				WithAuthentication(AuthenticationPolicy{
+					Verify: func(
+						context.Context,
+						Credentials,
+					) (middleware.Principal, error) {
+						return nil, nil
+					},
+					APIKeyHeader: "x-api-key",
+				}),
+			)
+
+			preflight("/protean.test/TestService/Unary", "https://example.org")
+
+			Expect(response.Code).To(Equal(http.StatusNoContent))
+			Expect(response.Header().Get("Access-Control-Allow-Headers")).To(ContainSubstring("X-Api-Key"))
+		})
+
+		It("answers preflight requests made to REST routes with the methods of the matching routes", func() {
+			setup(
+				WithCORS(CORSPolicy{
+					AllowedOrigins: []string{"*"},
+				}),
+			)
+
+			preflight("/v1/items/123", "https://example.org")
+
+			Expect(response.Code).To(Equal(http.StatusNoContent))
+			Expect(response.Header().Get("Access-Control-Allow-Origin")).To(Equal("*"))
+			Expect(response.Header().Get("Access-Control-Allow-Methods")).To(Equal("POST, GET"))
+		})
+
+		It("answers preflight requests made to gRPC/Connect URL paths", func() {
+			setup(
+				WithCORS(CORSPolicy{
+					AllowOrigin: func(origin string) bool {
+						return strings.HasSuffix(origin, ".example.org")
+					},
+				}),
+			)
+
+			preflight("/protean.test.TestService/Unary", "https://app.example.org")
+
+			Expect(response.Code).To(Equal(http.StatusNoContent))
+			Expect(response.Header().Get("Access-Control-Allow-Origin")).To(Equal("https://app.example.org"))
+		})
+
+		It("does not send CORS headers if the origin is not allowed", func() {
+			setup(
+				WithCORS(CORSPolicy{
+					AllowedOrigins: []string{"https://example.org"},
+				}),
+			)
+
+			preflight("/protean.test/TestService/Unary", "https://example.com")
+
+			Expect(response.Code).To(Equal(http.StatusNoContent))
+			Expect(response.Header()).NotTo(HaveKey("Access-Control-Allow-Origin"))
+		})
+
+		It("does not answer preflight requests for unknown RPC methods", func() {
+			setup(
+				WithCORS(CORSPolicy{
+					AllowedOrigins: []string{"*"},
+				}),
+			)
+
+			preflight("/protean.test/TestService/Unknown", "https://example.org")
+
+			Expect(response.Code).To(Equal(http.StatusNotFound))
+			Expect(response.Header()).NotTo(HaveKey("Access-Control-Allow-Origin"))
+		})
+
+		It("does not answer preflight requests if WithCORS() is not used", func() {
+			setup()
+
+			preflight("/protean.test/TestService/Unary", "https://example.org")
+
+			Expect(response.Code).To(Equal(http.StatusMethodNotAllowed))
+			Expect(response.Header()).NotTo(HaveKey("Access-Control-Allow-Origin"))
+		})
+
+		It("adds CORS headers to successful responses", func() {
+			setup(
+				WithCORS(CORSPolicy{
+					AllowedOrigins: []string{"https://example.org"},
+					ExposedHeaders: []string{"X-Request-Id"},
+				}),
+			)
+
+			post("/protean.test/TestService/Unary", "https://example.org", `{"data":"hello"}`)
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header().Get("Access-Control-Allow-Origin")).To(Equal("https://example.org"))
+			Expect(response.Header().Get("Access-Control-Expose-Headers")).To(ContainSubstring("X-Request-Id"))
+			Expect(response.Header().Get("Access-Control-Expose-Headers")).To(ContainSubstring("Retry-After"))
+			Expect(response.Header().Get("Vary")).To(Equal("Origin"))
+		})
+
+		It("adds CORS headers to error responses", func() {
+			setup(
+				WithCORS(CORSPolicy{
+					AllowedOrigins: []string{"https://example.org"},
+				}),
+			)
+
+			post("/protean.test/TestService/Unary", "https://example.org", `}`)
+
+			Expect(response.Code).To(Equal(http.StatusBadRequest))
+			Expect(response.Header().Get("Access-Control-Allow-Origin")).To(Equal("https://example.org"))
+		})
+
+		It("prefers method-specific policies over service-specific and default policies", func() {
+			setup(
+				WithCORS(CORSPolicy{
+					AllowedOrigins: []string{"https://default.example.org"},
+				}),
+				WithCORS(
+					CORSPolicy{
+						AllowedOrigins: []string{"https://service.example.org"},
+					},
+					"protean.test.TestService",
+				),
+				WithCORS(
+					CORSPolicy{
+						AllowedOrigins: []string{"https://method.example.org"},
+					},
+					"protean.test.TestService/Unary",
+				),
+			)
+
+			preflight("/protean.test/TestService/Unary", "https://service.example.org")
+			Expect(response.Header()).NotTo(HaveKey("Access-Control-Allow-Origin"))
+
+			response = httptest.NewRecorder()
+			preflight("/protean.test/TestService/Unary", "https://method.example.org")
+			Expect(response.Header().Get("Access-Control-Allow-Origin")).To(Equal("https://method.example.org"))
+
+			response = httptest.NewRecorder()
+			preflight("/protean.test/TestService/NoSideEffects", "https://service.example.org")
+			Expect(response.Header().Get("Access-Control-Allow-Origin")).To(Equal("https://service.example.org"))
+		})
+
+		DescribeTable(
+			"it checks the origin of websocket connections",
+			func(p CORSPolicy, origin string, expectAllowed bool) {
+				setup(WithCORS(p))
+
+				server := httptest.NewServer(handler)
+				defer server.Close()
+
+				if origin == "<same>" {
+					origin = server.URL
+				}
+
+				dialer := websocket.Dialer{
+					Subprotocols: []string{"protean.v1+json"},
+				}
+
+				conn, res, err := dialer.Dial(
+					"ws"+strings.TrimPrefix(server.URL, "http")+"/protean.test/TestService/Unary",
+					http.Header{"Origin": {origin}},
+				)
+
+				if expectAllowed {
+					Expect(err).ShouldNot(HaveOccurred())
+					conn.Close()
+				} else {
+					Expect(err).To(MatchError(websocket.ErrBadHandshake))
+					Expect(res.StatusCode).To(Equal(http.StatusForbidden))
+				}
+
+				res.Body.Close()
+			},
+			Entry(
+				"same origin",
+				CORSPolicy{},
+				"<same>",
+				true,
+			),
+			Entry(
+				"explicitly allowed origin with credentials",
+				CORSPolicy{
+					AllowedOrigins:   []string{"https://example.org"},
+					AllowCredentials: true,
+				},
+				"https://example.org",
+				true,
+			),
+			Entry(
+				"origin allowed by predicate with credentials",
+				CORSPolicy{
+					AllowOrigin:      func(o string) bool { return o == "https://example.org" },
+					AllowCredentials: true,
+				},
+				"https://example.org",
+				true,
+			),
+			Entry(
+				"explicitly allowed origin without credentials",
+				CORSPolicy{
+					AllowedOrigins: []string{"https://example.org"},
+				},
+				"https://example.org",
+				false,
+			),
+			Entry(
+				"wildcard origin without credentials",
+				CORSPolicy{
+					AllowedOrigins: []string{"*"},
+				},
+				"https://attacker.example.com",
+				false,
+			),
+			Entry(
+				"wildcard origin with credentials",
+				CORSPolicy{
+					AllowedOrigins:   []string{"*"},
+					AllowCredentials: true,
+				},
+				"https://attacker.example.com",
+				false,
+			),
+		)
+	})
+})
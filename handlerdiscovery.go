@@ -0,0 +1,176 @@
+package protean
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/dogmatiq/protean/internal/proteanpb"
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/rpcerror"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// DiscoveryPath is the URL path of the discovery endpoint, which is enabled by
+// the WithDiscoveryEndpoint() option.
+const DiscoveryPath = "/.well-known/protean/services"
+
+// serveDiscovery serves a request to the discovery endpoint.
+//
+// The response body is a protean.v1.ServiceList message, encoded as per the
+// request's Accept header. If the "descriptors" query parameter is "true" the
+// message includes a FileDescriptorSet containing the definitions of the
+// registered services.
+func (h *handler) serveDiscovery(w http.ResponseWriter, r *http.Request) {
+	marshaler, outputMediaType, ok := negotiateOutputMediaType(w, r)
+	if !ok {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		httpError(
+			w,
+			http.StatusMethodNotAllowed,
+			outputMediaType,
+			marshaler,
+			rpcerror.New(
+				rpcerror.NotImplemented,
+				"the HTTP method must be GET",
+			),
+		)
+		return
+	}
+
+	var includeDescriptors bool
+	if v := r.URL.Query().Get("descriptors"); v != "" {
+		var err error
+		includeDescriptors, err = strconv.ParseBool(v)
+		if err != nil {
+			httpError(
+				w,
+				http.StatusBadRequest,
+				outputMediaType,
+				marshaler,
+				rpcerror.New(
+					rpcerror.InvalidInput,
+					"the 'descriptors' query parameter must be a boolean",
+				),
+			)
+			return
+		}
+	}
+
+	list := h.serviceList(includeDescriptors)
+
+	data, err := marshaler.Marshal(list)
+	if err != nil {
+		// CODE COVERAGE: This condition can not be reproduced, as the service
+		// list is always a valid message.
+		httpError(
+			w,
+			http.StatusInternalServerError,
+			outputMediaType,
+			marshaler,
+			rpcerror.New(
+				rpcerror.Unknown,
+				"the service list could not be marshaled to the response body",
+			),
+		)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Vary", "Accept")
+	w.Header().Set("Content-Type", protomime.FormatMediaType(outputMediaType, list))
+	data = h.compressResponseBody(w, r, data)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// serviceList returns a description of the services registered with the
+// handler.
+func (h *handler) serviceList(includeDescriptors bool) *proteanpb.ServiceList {
+	list := &proteanpb.ServiceList{}
+
+	var names []string
+	for n := range h.services {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var files fileDescriptorSet
+
+	for _, n := range names {
+		s := h.services[n]
+		sd := s.Descriptor()
+
+		info := &proteanpb.ServiceInfo{
+			Name: string(sd.FullName()),
+		}
+
+		methods := sd.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			md := methods.Get(i)
+
+			m, ok := s.MethodByName(string(md.Name()))
+			if !ok {
+				continue
+			}
+
+			info.Methods = append(info.Methods, &proteanpb.MethodInfo{
+				Name:           m.Name(),
+				InputType:      string(md.Input().FullName()),
+				OutputType:     string(md.Output().FullName()),
+				InputIsStream:  m.InputIsStream(),
+				OutputIsStream: m.OutputIsStream(),
+				HasSideEffects: m.HasSideEffects(),
+				RequiredScopes: m.RequiredScopes(),
+			})
+		}
+
+		list.Services = append(list.Services, info)
+
+		if includeDescriptors {
+			files.add(sd.ParentFile())
+		}
+	}
+
+	if includeDescriptors {
+		list.FileDescriptorSet = &descriptorpb.FileDescriptorSet{
+			File: files.protos,
+		}
+	}
+
+	return list
+}
+
+// fileDescriptorSet builds a FileDescriptorSet in which each file appears
+// after all of its dependencies.
+type fileDescriptorSet struct {
+	seen   map[string]bool
+	protos []*descriptorpb.FileDescriptorProto
+}
+
+// add adds fd and its transitive dependencies to the set.
+func (s *fileDescriptorSet) add(fd protoreflect.FileDescriptor) {
+	if s.seen == nil {
+		s.seen = map[string]bool{}
+	}
+
+	if s.seen[fd.Path()] {
+		return
+	}
+	s.seen[fd.Path()] = true
+
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		s.add(imports.Get(i).FileDescriptor)
+	}
+
+	s.protos = append(s.protos, protodesc.ToFileDescriptorProto(fd))
+}
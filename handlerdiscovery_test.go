@@ -0,0 +1,134 @@
+package protean_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/testservice"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+var _ = Describe("type Handler (discovery)", func() {
+	var (
+		handler  Handler
+		response *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		handler = NewHandler(WithDiscoveryEndpoint())
+		testservice.RegisterProteanTestService(handler, &testservice.Stub{})
+
+		response = httptest.NewRecorder()
+	})
+
+	// get makes a GET request to the discovery endpoint.
+	get := func(query string) {
+		req := httptest.NewRequest(http.MethodGet, DiscoveryPath+query, nil)
+		req.Header.Set("Accept", "application/json")
+
+		handler.ServeHTTP(response, req)
+	}
+
+	Describe("func ServeHTTP()", func() {
+		It("lists the registered services and methods", func() {
+			get("")
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header().Get("Content-Type")).To(Equal("application/json; x-proto=protean.v1.ServiceList"))
+			Expect(response.Body.String()).To(MatchJSON(`{
+				"services": [
+					{
+						"name": "protean.test.TestService",
+						"methods": [
+							{
+								"name": "Unary",
+								"input_type": "protean.test.Input",
+								"output_type": "protean.test.Output",
+								"has_side_effects": true
+							},
+							{
+								"name": "NoSideEffects",
+								"input_type": "protean.test.Input",
+								"output_type": "protean.test.Output",
+								"required_scopes": ["test:read", "test:list"]
+							},
+							{
+								"name": "ClientStream",
+								"input_type": "protean.test.Input",
+								"output_type": "protean.test.Output",
+								"input_is_stream": true,
+								"has_side_effects": true
+							},
+							{
+								"name": "ServerStream",
+								"input_type": "protean.test.Input",
+								"output_type": "protean.test.Output",
+								"output_is_stream": true,
+								"has_side_effects": true
+							},
+							{
+								"name": "BidirectionalStream",
+								"input_type": "protean.test.Input",
+								"output_type": "protean.test.Output",
+								"input_is_stream": true,
+								"output_is_stream": true,
+								"has_side_effects": true,
+								"required_scopes": ["test:stream"]
+							}
+						]
+					}
+				]
+			}`))
+		})
+
+		It("includes a FileDescriptorSet that defines the services if requested", func() {
+			get("?descriptors=true")
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+
+			var body struct {
+				FileDescriptorSet json.RawMessage `json:"file_descriptor_set"`
+			}
+			err := json.Unmarshal(response.Body.Bytes(), &body)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			var set descriptorpb.FileDescriptorSet
+			err = protojson.Unmarshal(body.FileDescriptorSet, &set)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			files, err := protodesc.NewFiles(&set)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			d, err := files.FindDescriptorByName("protean.test.TestService")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(d.FullName()).To(BeEquivalentTo("protean.test.TestService"))
+		})
+
+		It("responds with a '400 Bad Request' status if the descriptors parameter is invalid", func() {
+			get("?descriptors=maybe")
+
+			Expect(response.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("responds with a '405 Method Not Allowed' status if the HTTP method is not GET", func() {
+			req := httptest.NewRequest(http.MethodPost, DiscoveryPath, nil)
+			handler.ServeHTTP(response, req)
+
+			Expect(response.Code).To(Equal(http.StatusMethodNotAllowed))
+			Expect(response.Header().Get("Allow")).To(Equal("GET"))
+		})
+
+		It("does not serve the discovery endpoint if WithDiscoveryEndpoint() is not used", func() {
+			handler = NewHandler()
+			get("")
+
+			Expect(response.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+})
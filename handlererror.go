@@ -1,6 +1,12 @@
 package protean
 
-import "github.com/dogmatiq/protean/rpcerror"
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/dogmatiq/protean/rpcerror"
+)
 
 // unimplementedServiceError returns the RPC error that should be sent to the
 // client when it calls a method from an unrecognized service.
@@ -23,3 +29,25 @@ func unimplementedMethodError(serviceName, methodName string) rpcerror.Error {
 		methodName,
 	)
 }
+
+// setRetryAfterHeader sets the Retry-After header of the response to the
+// number of seconds that the client should wait before retrying, if rpcErr has
+// an rpcerror.RetryInfo details value.
+func setRetryAfterHeader(w http.ResponseWriter, rpcErr rpcerror.Error) {
+	details, ok, err := rpcErr.Details()
+	if err != nil || !ok {
+		return
+	}
+
+	info, ok := details.(*rpcerror.RetryInfo)
+	if !ok || info.GetRetryDelay() == nil {
+		return
+	}
+
+	seconds := math.Ceil(info.GetRetryDelay().AsDuration().Seconds())
+	if seconds < 0 {
+		seconds = 0
+	}
+
+	w.Header().Set("Retry-After", strconv.FormatFloat(seconds, 'f', 0, 64))
+}
@@ -0,0 +1,129 @@
+package protean
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/runtime"
+	"google.golang.org/protobuf/proto"
+)
+
+// serveGET serves an RPC request made using the HTTP GET method.
+//
+// The RPC input message is read from the URL query parameters, as per
+// unmarshalQueryInput().
+func (h *handler) serveGET(
+	w http.ResponseWriter,
+	r *http.Request,
+	method runtime.Method,
+) {
+	marshaler, outputMediaType, ok := negotiateOutputMediaType(w, r)
+	if !ok {
+		return
+	}
+
+	out, data, ok := h.callUnary(
+		w,
+		r,
+		method,
+		func(in proto.Message) error {
+			return unmarshalQueryInput(r.URL.Query(), in)
+		},
+		"query string",
+		outputMediaType,
+		marshaler,
+	)
+	if !ok {
+		return
+	}
+
+	contentType := protomime.FormatMediaType(outputMediaType, out)
+
+	// Unlike POST requests, the response to a GET request may be stored by
+	// browsers and shared caches, but it must be revalidated before each use.
+	// The ETag allows the cache to revalidate without transferring the body.
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Vary", "Accept")
+
+	// The ETag is computed from the uncompressed body, but includes the
+	// content-coding, as each encoding of the body is a distinct
+	// representation.
+	body := h.compressResponseBody(w, r, data)
+	etag := entityTag(contentType, w.Header().Get("Content-Encoding"), data)
+	w.Header().Set("ETag", etag)
+
+	if ifNoneMatch(r, etag) {
+		w.Header().Del("Content-Encoding")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Add("Content-Type", contentType)
+	w.Header().Add("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// allowsGET returns true if the given method may be called using the HTTP GET
+// method.
+//
+// Unary methods are called using GET by the native and Connect transports.
+// Server streaming methods are called using GET by the server-sent events
+// transport.
+func (h *handler) allowsGET(s runtime.Service, m runtime.Method) bool {
+	if m.InputIsStream() {
+		return false
+	}
+
+	if !m.HasSideEffects() {
+		return true
+	}
+
+	return h.getMethods[fullMethodName(s, m)]
+}
+
+// fullMethodName returns the fully-qualified name of an RPC method in the
+// "<package>.<service>/<method>" form.
+func fullMethodName(s runtime.Service, m runtime.Method) string {
+	return s.Package() + "." + s.Name() + "/" + m.Name()
+}
+
+// entityTag returns a strong HTTP entity tag for a response body.
+//
+// data is the uncompressed body. contentEncoding is the content-coding used to
+// send the body, if any, which is appended to the tag, such as "<hash>-gzip".
+func entityTag(contentType, contentEncoding string, data []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(contentType))
+	hash.Write([]byte{0})
+	hash.Write(data)
+
+	tag := base64.RawURLEncoding.EncodeToString(hash.Sum(nil)[:18])
+	if contentEncoding != "" {
+		tag += "-" + contentEncoding
+	}
+
+	return `"` + tag + `"`
+}
+
+// ifNoneMatch returns true if the request's If-None-Match header matches the
+// given entity tag.
+func ifNoneMatch(r *http.Request, etag string) bool {
+	for _, header := range r.Header.Values("If-None-Match") {
+		for _, v := range strings.Split(header, ",") {
+			v = strings.TrimSpace(v)
+			v = strings.TrimPrefix(v, "W/")
+
+			if v == "*" || v == etag {
+				return true
+			}
+		}
+	}
+
+	return false
+}
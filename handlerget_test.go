@@ -0,0 +1,268 @@
+package protean_test
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/compression"
+	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/rpcerror"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ = Describe("type Handler (HTTP GET)", func() {
+	var (
+		ctx      context.Context
+		cancel   context.CancelFunc
+		handler  Handler
+		received *testservice.Input
+		service  *testservice.Stub
+		request  *http.Request
+		response *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		format.TruncatedDiff = false
+
+		ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+
+		handler = NewHandler()
+
+		received = nil
+		service = &testservice.Stub{
+			NoSideEffectsFunc: func(
+				_ context.Context,
+				in *testservice.Input,
+			) (*testservice.Output, error) {
+				received = in
+				return &testservice.Output{Data: "<output>"}, nil
+			},
+		}
+
+		testservice.RegisterProteanTestService(handler, service)
+
+		request = httptest.NewRequest(
+			http.MethodGet,
+			"/protean.test/TestService/NoSideEffects?data=%3Cinput%3E",
+			nil,
+		).WithContext(ctx)
+
+		response = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		format.TruncatedDiff = true
+		cancel()
+	})
+
+	Describe("func ServeHTTP()", func() {
+		When("the method has no side effects", func() {
+			It("responds with the RPC output message", func() {
+				handler.ServeHTTP(response, request)
+
+				Expect(response).To(HaveHTTPStatus(http.StatusOK))
+				Expect(response).To(HaveHTTPHeaderWithValue("Content-Type", "application/json; x-proto=protean.test.Output"))
+				Expect(response).To(HaveHTTPHeaderWithValue("Cache-Control", "no-cache"))
+				Expect(response).To(HaveHTTPHeaderWithValue("Vary", "Accept"))
+				Expect(response.Header().Get("ETag")).NotTo(BeEmpty())
+
+				data, err := io.ReadAll(response.Body)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				var out testservice.Output
+				err = protojson.Unmarshal(data, &out)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				Expect(out.GetData()).To(Equal("<output>"))
+			})
+
+			It("responds using the media type from the Accept header", func() {
+				request.Header.Set("Accept", "application/vnd.google.protobuf")
+
+				handler.ServeHTTP(response, request)
+
+				Expect(response).To(HaveHTTPStatus(http.StatusOK))
+				Expect(response).To(HaveHTTPHeaderWithValue("Content-Type", "application/vnd.google.protobuf; x-proto=protean.test.Output"))
+			})
+
+			It("responds with an HTTP '304 Not Modified' status if the ETag matches", func() {
+				handler.ServeHTTP(response, request)
+				etag := response.Header().Get("ETag")
+
+				request.Header.Set("If-None-Match", etag)
+				response = httptest.NewRecorder()
+
+				handler.ServeHTTP(response, request)
+
+				Expect(response).To(HaveHTTPStatus(http.StatusNotModified))
+				Expect(response).To(HaveHTTPHeaderWithValue("ETag", etag))
+				Expect(response.Body.Len()).To(BeZero())
+			})
+
+			It("compresses the response as per the Accept-Encoding header", func() {
+				service.NoSideEffectsFunc = func(
+					context.Context,
+					*testservice.Input,
+				) (*testservice.Output, error) {
+					return &testservice.Output{Data: strings.Repeat("x", 4096)}, nil
+				}
+
+				handler.ServeHTTP(response, request)
+				identityTag := response.Header().Get("ETag")
+
+				request.Header.Set("Accept-Encoding", "gzip")
+				response = httptest.NewRecorder()
+
+				handler.ServeHTTP(response, request)
+
+				Expect(response).To(HaveHTTPStatus(http.StatusOK))
+				Expect(response).To(HaveHTTPHeaderWithValue("Content-Encoding", "gzip"))
+				Expect(response.Header().Values("Vary")).To(ConsistOf("Accept", "Accept-Encoding"))
+
+				gzipTag := response.Header().Get("ETag")
+				Expect(gzipTag).To(HaveSuffix(`-gzip"`))
+				Expect(gzipTag).NotTo(Equal(identityTag))
+
+				data, err := compression.Decompress(compression.Gzip, response.Body.Bytes(), 1_000_000)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				var out testservice.Output
+				err = protojson.Unmarshal(data, &out)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(out.GetData()).To(HaveLen(4096))
+
+				request.Header.Set("If-None-Match", gzipTag)
+				response = httptest.NewRecorder()
+
+				handler.ServeHTTP(response, request)
+
+				Expect(response).To(HaveHTTPStatus(http.StatusNotModified))
+				Expect(response).To(HaveHTTPHeaderWithValue("ETag", gzipTag))
+			})
+
+			DescribeTable(
+				"it unmarshals the RPC input message from the query string",
+				func(query func() string, expect *testservice.Input) {
+					request.URL.RawQuery = query()
+
+					handler.ServeHTTP(response, request)
+
+					Expect(response).To(HaveHTTPStatus(http.StatusOK))
+					Expect(proto.Equal(received, expect)).To(BeTrue(), "unexpected input: %s", received)
+				},
+				Entry(
+					"field parameters",
+					func() string { return "id=<id>&data=<data>" },
+					&testservice.Input{Id: "<id>", Data: "<data>"},
+				),
+				Entry(
+					"JSON in parameter",
+					func() string { return "in=" + url.QueryEscape(`{"id":"<id>","data":"<data>"}`) },
+					&testservice.Input{Id: "<id>", Data: "<data>"},
+				),
+				Entry(
+					"binary in parameter",
+					func() string {
+						data, err := proto.Marshal(&testservice.Input{Id: "<id>", Data: "<data>"})
+						Expect(err).ShouldNot(HaveOccurred())
+						return "in=" + base64.RawURLEncoding.EncodeToString(data)
+					},
+					&testservice.Input{Id: "<id>", Data: "<data>"},
+				),
+				Entry(
+					"field parameters override the in parameter",
+					func() string { return "data=<override>&in=" + url.QueryEscape(`{"id":"<id>","data":"<data>"}`) },
+					&testservice.Input{Id: "<id>", Data: "<override>"},
+				),
+			)
+
+			DescribeTable(
+				"it responds with an HTTP '400 Bad Request' status if the query string is invalid",
+				func(query string) {
+					request.URL.RawQuery = query
+
+					handler.ServeHTTP(response, request)
+
+					expectError(
+						response,
+						http.StatusBadRequest,
+						"application/json; x-proto=protean.v1.Error",
+						rpcerror.New(
+							rpcerror.Unknown,
+							"the RPC input message could not be unmarshaled from the query string",
+						),
+					)
+
+					Expect(received).To(BeNil())
+				},
+				Entry("unknown field", "unknown=value"),
+				Entry("multiple values for a singular field", "data=a&data=b"),
+				Entry("nested path into a scalar field", "data.x=value"),
+				Entry("malformed JSON", "in="+url.QueryEscape("{garbage")),
+				Entry("malformed base64", "in=%21%21"),
+			)
+		})
+
+		When("the method may have side effects", func() {
+			BeforeEach(func() {
+				request.URL.Path = "/protean.test/TestService/Unary"
+			})
+
+			It("responds with an HTTP '405 Method Not Allowed' status", func() {
+				handler.ServeHTTP(response, request)
+
+				expectError(
+					response,
+					http.StatusMethodNotAllowed,
+					"text/plain; charset=utf-8; x-proto=protean.v1.Error",
+					rpcerror.New(
+						rpcerror.NotImplemented,
+						"the HTTP method must be POST",
+					),
+				)
+			})
+
+			It("allows GET requests if the method is enabled via WithGETEnabled()", func() {
+				handler = NewHandler(
+					WithGETEnabled("protean.test.TestService/Unary"),
+				)
+				testservice.RegisterProteanTestService(handler, service)
+
+				service.UnaryFunc = service.NoSideEffectsFunc
+
+				handler.ServeHTTP(response, request)
+
+				Expect(response).To(HaveHTTPStatus(http.StatusOK))
+				Expect(received.GetData()).To(Equal("<input>"))
+			})
+		})
+
+		It("responds with an HTTP '405 Method Not Allowed' status for other HTTP methods", func() {
+			request.Method = http.MethodPut
+
+			handler.ServeHTTP(response, request)
+
+			expectError(
+				response,
+				http.StatusMethodNotAllowed,
+				"text/plain; charset=utf-8; x-proto=protean.v1.Error",
+				rpcerror.New(
+					rpcerror.NotImplemented,
+					"the HTTP method must be GET or POST",
+				),
+			)
+		})
+	})
+})
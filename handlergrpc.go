@@ -0,0 +1,506 @@
+package protean
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/rpcerror"
+	"github.com/dogmatiq/protean/runtime"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcProtocol describes a variant of the gRPC protocol.
+type grpcProtocol struct {
+	// MessageMediaType is the media type used to encode each gRPC message.
+	MessageMediaType string
+
+	// Web is true if the protocol is gRPC-Web, which sends the gRPC trailers
+	// in the response body instead of as HTTP trailers.
+	Web bool
+
+	// Text is true if the request and response bodies are base64 encoded, as
+	// per the application/grpc-web-text media type.
+	Text bool
+}
+
+// grpcMediaTypes maps each of the supported gRPC and gRPC-Web media types to
+// the protocol variant they represent.
+var grpcMediaTypes = map[string]grpcProtocol{
+	"application/grpc":                {MessageMediaType: protomime.BinaryMediaTypes[0]},
+	"application/grpc+proto":          {MessageMediaType: protomime.BinaryMediaTypes[0]},
+	"application/grpc+json":           {MessageMediaType: protomime.JSONMediaTypes[0]},
+	"application/grpc-web":            {MessageMediaType: protomime.BinaryMediaTypes[0], Web: true},
+	"application/grpc-web+proto":      {MessageMediaType: protomime.BinaryMediaTypes[0], Web: true},
+	"application/grpc-web+json":       {MessageMediaType: protomime.JSONMediaTypes[0], Web: true},
+	"application/grpc-web-text":       {MessageMediaType: protomime.BinaryMediaTypes[0], Web: true, Text: true},
+	"application/grpc-web-text+proto": {MessageMediaType: protomime.BinaryMediaTypes[0], Web: true, Text: true},
+}
+
+// grpcFrameHeaderSize is the size of the header that precedes each gRPC
+// message, consisting of a single "flags" byte followed by the message length
+// as a 32-bit big-endian unsigned integer.
+const grpcFrameHeaderSize = 1 + streamFrameHeaderSize
+
+// grpcCompressedFlag is the bit within the gRPC message header's flags that
+// indicates that the message is compressed.
+const grpcCompressedFlag = 0x01
+
+// grpcWebTrailersFlag is the bit within the gRPC message header's flags that
+// indicates that a gRPC-Web frame contains the trailers, rather than a message.
+const grpcWebTrailersFlag = 0x80
+
+// These constants are the gRPC status codes.
+//
+// See https://github.com/grpc/grpc/blob/master/doc/statuscodes.md.
+const (
+	grpcOK                 = 0
+	grpcCanceled           = 1
+	grpcUnknown            = 2
+	grpcInvalidArgument    = 3
+	grpcDeadlineExceeded   = 4
+	grpcNotFound           = 5
+	grpcAlreadyExists      = 6
+	grpcPermissionDenied   = 7
+	grpcResourceExhausted  = 8
+	grpcFailedPrecondition = 9
+	grpcAborted            = 10
+	grpcUnimplemented      = 12
+	grpcUnavailable        = 14
+	grpcUnauthenticated    = 16
+)
+
+// isGRPCRequest returns true if r is a request made using the gRPC or gRPC-Web
+// protocols.
+func isGRPCRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/grpc" ||
+		strings.HasPrefix(mediaType, "application/grpc+") ||
+		strings.HasPrefix(mediaType, "application/grpc-web")
+}
+
+// serveGRPC serves an RPC request made using the gRPC or gRPC-Web protocols.
+//
+// gRPC requests identify the RPC method using the "/<package>.<service>/<method>"
+// pattern, rather than the pattern used by the other transports.
+func (h *handler) serveGRPC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpError(
+			w,
+			http.StatusMethodNotAllowed,
+			protomime.TextMediaTypes[0],
+			protomime.TextMarshaler,
+			rpcerror.New(
+				rpcerror.NotImplemented,
+				"the HTTP method must be POST when using gRPC",
+			),
+		)
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	protocol, ok := grpcMediaTypes[mediaType]
+	if !ok {
+		httpError(
+			w,
+			http.StatusUnsupportedMediaType,
+			protomime.TextMediaTypes[0],
+			protomime.TextMarshaler,
+			rpcerror.New(
+				rpcerror.Unknown,
+				"the server does not support the '%s' media-type supplied by the client",
+				mediaType,
+			),
+		)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Grpc-Accept-Encoding", "identity")
+
+	_, method, rpcErr, ok := h.resolveQualifiedMethod(r.URL.Path)
+	if !ok {
+		writeGRPCTrailersOnly(w, rpcErr)
+		return
+	}
+
+	r, rpcErr, ok = h.authenticateRequest(r, method)
+	if !ok {
+		writeGRPCTrailersOnly(w, rpcErr)
+		return
+	}
+
+	if protocol.Web && method.InputIsStream() {
+		writeGRPCTrailersOnly(
+			w,
+			rpcerror.New(
+				rpcerror.NotImplemented,
+				"gRPC-Web does not support client streaming or bidirectional streaming RPC methods",
+			),
+		)
+		return
+	}
+
+	if enc := r.Header.Get("Grpc-Encoding"); enc != "" && enc != "identity" {
+		writeGRPCTrailersOnly(
+			w,
+			rpcerror.New(
+				rpcerror.NotImplemented,
+				"the server does not support the '%s' gRPC message encoding",
+				enc,
+			),
+		)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	if v := r.Header.Get("Grpc-Timeout"); v != "" {
+		timeout, ok := parseGRPCTimeout(v)
+		if !ok {
+			writeGRPCTrailersOnly(
+				w,
+				rpcerror.New(
+					rpcerror.Unknown,
+					"the grpc-timeout header is invalid",
+				),
+			)
+			return
+		}
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	marshaler, _ := protomime.MarshalerForMediaType(protocol.MessageMediaType)
+	unmarshaler, _ := protomime.UnmarshalerForMediaType(protocol.MessageMediaType)
+
+	var body io.Reader = r.Body
+	if protocol.Text {
+		body = &base64QuantumReader{r: bufio.NewReader(r.Body)}
+	}
+
+	rc := http.NewResponseController(w)
+	_ = rc.EnableFullDuplex()
+
+	w.WriteHeader(http.StatusOK)
+	_ = rc.Flush()
+
+	c := &streamCall{
+		stream: &grpcFrameStream{
+			r:           r,
+			w:           w,
+			rc:          rc,
+			body:        bufio.NewReader(body),
+			protocol:    protocol,
+			marshaler:   marshaler,
+			unmarshaler: unmarshaler,
+			maxSize:     h.maxInputSizeFor(method),
+		},
+		frameName: "gRPC message",
+		method:    method,
+		cancel:    cancel,
+		call:      h.newCall(ctx, method),
+	}
+
+	c.run()
+}
+
+// resolveQualifiedMethod looks up the RPC method based on a request path that
+// follows the "/<package>.<service>/<method>" pattern used by gRPC and Connect.
+//
+// If the method can not be found it returns an error that describes the
+// problem.
+func (h *handler) resolveQualifiedMethod(p string) (runtime.Service, runtime.Method, rpcerror.Error, bool) {
+	serviceName, p, ok := nextPathSegment(p)
+	if !ok {
+		return nil, nil, rpcerror.New(
+			rpcerror.NotImplemented,
+			"the request URI must follow the '/<package>.<service>/<method>' pattern",
+		), false
+	}
+
+	methodName, p, ok := nextPathSegment(p)
+	if !ok || p != "" {
+		return nil, nil, rpcerror.New(
+			rpcerror.NotImplemented,
+			"the request URI must follow the '/<package>.<service>/<method>' pattern",
+		), false
+	}
+
+	service, ok := h.services[serviceName]
+	if !ok {
+		return nil, nil, unimplementedServiceError(serviceName), false
+	}
+
+	method, ok := service.MethodByName(methodName)
+	if !ok {
+		return nil, nil, unimplementedMethodError(serviceName, methodName), false
+	}
+
+	return service, method, rpcerror.Error{}, true
+}
+
+// grpcFrameStream is a frameStream that exchanges length-prefixed gRPC
+// messages over HTTP request and response bodies.
+//
+// The outcome of the call is sent to the client using HTTP trailers, or for
+// gRPC-Web, in a final frame within the response body.
+type grpcFrameStream struct {
+	r           *http.Request
+	w           http.ResponseWriter
+	rc          *http.ResponseController
+	body        *bufio.Reader
+	protocol    grpcProtocol
+	marshaler   protomime.Marshaler
+	unmarshaler protomime.Unmarshaler
+	maxSize     int
+}
+
+// ReadInput reads the next gRPC message sent by the client.
+func (s *grpcFrameStream) ReadInput() (runtime.Unmarshaler, bool, error) {
+	flags, err := s.body.ReadByte()
+	if err != nil {
+		return nil, false, err
+	}
+
+	data, err := readLengthPrefixedFrame(s.body, s.maxSize)
+	if errors.Is(err, io.EOF) {
+		return nil, false, errTruncatedFrame
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if flags&grpcCompressedFlag != 0 {
+		return nil, false, rpcerror.New(
+			rpcerror.Unknown,
+			"the client sent a compressed message without specifying a message encoding",
+		)
+	}
+
+	return func(in proto.Message) error {
+		return s.unmarshaler.Unmarshal(data, in)
+	}, false, nil
+}
+
+// WriteOutput writes a gRPC message containing an RPC output message.
+func (s *grpcFrameStream) WriteOutput(out proto.Message) error {
+	data, err := s.marshaler.Marshal(out)
+	if err != nil {
+		return err
+	}
+
+	return s.writeFrame(0, data)
+}
+
+// WriteDone sends the trailers that indicate that the RPC method succeeded.
+func (s *grpcFrameStream) WriteDone() error {
+	return s.writeTrailers(grpcOK, "")
+}
+
+// WriteError sends the trailers that indicate that the RPC method failed.
+func (s *grpcFrameStream) WriteError(rpcErr rpcerror.Error) error {
+	return s.writeTrailers(
+		grpcStatusFromErrorCode(rpcErr.Code()),
+		encodeGRPCMessage(rpcErr.Message()),
+	)
+}
+
+// writeTrailers sends the gRPC trailers to the client.
+func (s *grpcFrameStream) writeTrailers(status int, message string) error {
+	if !s.protocol.Web {
+		s.w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(status))
+		if message != "" {
+			s.w.Header().Set(http.TrailerPrefix+"Grpc-Message", message)
+		}
+		return nil
+	}
+
+	trailers := "grpc-status: " + strconv.Itoa(status) + "\r\n"
+	if message != "" {
+		trailers += "grpc-message: " + message + "\r\n"
+	}
+
+	return s.writeFrame(grpcWebTrailersFlag, []byte(trailers))
+}
+
+// writeFrame writes a frame to the response body and flushes it to the
+// client.
+func (s *grpcFrameStream) writeFrame(flags byte, data []byte) error {
+	frame := make([]byte, grpcFrameHeaderSize, grpcFrameHeaderSize+len(data))
+	frame[0] = flags
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	frame = append(frame, data...)
+
+	if s.protocol.Text {
+		frame = []byte(base64.StdEncoding.EncodeToString(frame))
+	}
+
+	if _, err := s.w.Write(frame); err != nil {
+		return err
+	}
+
+	return s.rc.Flush()
+}
+
+// Close stops reading from the request body.
+func (s *grpcFrameStream) Close() {
+	_ = s.rc.SetReadDeadline(time.Now())
+	_ = s.r.Body.Close()
+}
+
+// base64QuantumReader is an io.Reader that decodes base64 data that may
+// consist of several separately padded base64 strings, as is permitted for
+// application/grpc-web-text request bodies.
+type base64QuantumReader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+// Read decodes the next base64 data into p.
+func (r *base64QuantumReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		// Each 4-byte "quantum" of base64 data can be decoded independently,
+		// regardless of whether it contains padding.
+		var quantum [4]byte
+
+		for n := 0; n < len(quantum); {
+			c, err := r.r.ReadByte()
+			if err != nil {
+				if err == io.EOF && n != 0 {
+					err = io.ErrUnexpectedEOF
+				}
+				return 0, err
+			}
+
+			if c == '\r' || c == '\n' || c == ' ' || c == '\t' {
+				continue
+			}
+
+			quantum[n] = c
+			n++
+		}
+
+		data, err := base64.StdEncoding.DecodeString(string(quantum[:]))
+		if err != nil {
+			return 0, rpcerror.New(
+				rpcerror.Unknown,
+				"the request body is not valid base64",
+			)
+		}
+
+		r.buf = data
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+
+	return n, nil
+}
+
+// writeGRPCTrailersOnly writes a gRPC "Trailers-Only" response, which conveys
+// an error in the HTTP response headers without sending any messages.
+func writeGRPCTrailersOnly(w http.ResponseWriter, rpcErr rpcerror.Error) {
+	w.Header().Set("Grpc-Status", strconv.Itoa(grpcStatusFromErrorCode(rpcErr.Code())))
+	w.Header().Set("Grpc-Message", encodeGRPCMessage(rpcErr.Message()))
+	w.Header().Set("Content-Length", "0")
+	w.WriteHeader(http.StatusOK)
+}
+
+// parseGRPCTimeout parses the value of the grpc-timeout header.
+func parseGRPCTimeout(v string) (time.Duration, bool) {
+	if len(v) < 2 || len(v) > 9 {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(v[:len(v)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+
+	var unit time.Duration
+	switch v[len(v)-1] {
+	case 'H':
+		unit = time.Hour
+	case 'M':
+		unit = time.Minute
+	case 'S':
+		unit = time.Second
+	case 'm':
+		unit = time.Millisecond
+	case 'u':
+		unit = time.Microsecond
+	case 'n':
+		unit = time.Nanosecond
+	default:
+		return 0, false
+	}
+
+	return time.Duration(n) * unit, true
+}
+
+// encodeGRPCMessage percent-encodes an error message for use in the
+// grpc-message header.
+func encodeGRPCMessage(m string) string {
+	var w strings.Builder
+
+	for i := 0; i < len(m); i++ {
+		c := m[i]
+		if c >= ' ' && c <= '~' && c != '%' {
+			w.WriteByte(c)
+		} else {
+			fmt.Fprintf(&w, "%%%02X", c)
+		}
+	}
+
+	return w.String()
+}
+
+// grpcStatusFromErrorCode returns the gRPC status code to send when an error
+// with the given code occurs.
+func grpcStatusFromErrorCode(c rpcerror.Code) int {
+	switch c {
+	case rpcerror.DeadlineExceeded:
+		return grpcDeadlineExceeded
+	case rpcerror.Canceled:
+		return grpcCanceled
+	case rpcerror.InvalidInput:
+		return grpcInvalidArgument
+	case rpcerror.Unauthenticated:
+		return grpcUnauthenticated
+	case rpcerror.PermissionDenied:
+		return grpcPermissionDenied
+	case rpcerror.NotFound:
+		return grpcNotFound
+	case rpcerror.AlreadyExists:
+		return grpcAlreadyExists
+	case rpcerror.ResourceExhausted:
+		return grpcResourceExhausted
+	case rpcerror.FailedPrecondition:
+		return grpcFailedPrecondition
+	case rpcerror.Aborted:
+		return grpcAborted
+	case rpcerror.Unavailable:
+		return grpcUnavailable
+	case rpcerror.NotImplemented:
+		return grpcUnimplemented
+	}
+
+	return grpcUnknown
+}
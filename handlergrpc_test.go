@@ -0,0 +1,482 @@
+package protean_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/rpcerror"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ = Describe("type Handler (gRPC)", func() {
+	var (
+		ctx     context.Context
+		cancel  context.CancelFunc
+		handler Handler
+		service *testservice.Stub
+		server  *httptest.Server
+	)
+
+	BeforeEach(func() {
+		format.TruncatedDiff = false
+
+		ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+
+		handler = NewHandler()
+
+		service = &testservice.Stub{
+			UnaryFunc: func(
+				_ context.Context,
+				in *testservice.Input,
+			) (*testservice.Output, error) {
+				return &testservice.Output{
+					Data: strings.ToUpper(in.GetData()),
+				}, nil
+			},
+			ClientStreamFunc: func(
+				ctx context.Context,
+				inputs <-chan *testservice.Input,
+			) (*testservice.Output, error) {
+				var data []string
+
+				for {
+					select {
+					case <-ctx.Done():
+						return nil, ctx.Err()
+					case in, ok := <-inputs:
+						if !ok {
+							return &testservice.Output{
+								Data: strings.Join(data, ","),
+							}, nil
+						}
+
+						data = append(data, in.GetData())
+					}
+				}
+			},
+			ServerStreamFunc: func(
+				ctx context.Context,
+				in *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				defer close(outputs)
+
+				for _, r := range in.GetData() {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case outputs <- &testservice.Output{Data: string(r)}:
+					}
+				}
+
+				return nil
+			},
+			BidirectionalStreamFunc: func(
+				ctx context.Context,
+				inputs <-chan *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				defer close(outputs)
+
+				for {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case in, ok := <-inputs:
+						if !ok {
+							return nil
+						}
+
+						select {
+						case <-ctx.Done():
+							return ctx.Err()
+						case outputs <- &testservice.Output{Data: strings.ToUpper(in.GetData())}:
+						}
+					}
+				}
+			},
+		}
+
+		testservice.RegisterProteanTestService(handler, service)
+
+		server = httptest.NewUnstartedServer(handler)
+		server.EnableHTTP2 = true
+		server.StartTLS()
+	})
+
+	AfterEach(func() {
+		format.TruncatedDiff = true
+		cancel()
+
+		server.Close()
+	})
+
+	// call makes a gRPC request to the given RPC method.
+	call := func(method string, body io.Reader) *http.Response {
+		req, err := http.NewRequestWithContext(
+			ctx,
+			http.MethodPost,
+			server.URL+"/protean.test.TestService/"+method,
+			body,
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Content-Type", "application/grpc")
+		req.Header.Set("TE", "trailers")
+
+		res, err := server.Client().Do(req)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(res.ProtoMajor).To(Equal(2))
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(res.Header.Get("Content-Type")).To(Equal("application/grpc"))
+
+		return res
+	}
+
+	// inputs returns a request body containing a gRPC message for each of the
+	// given RPC input messages.
+	inputs := func(data ...string) io.Reader {
+		var buf bytes.Buffer
+		for _, d := range data {
+			writeGRPCInput(&buf, d)
+		}
+		return &buf
+	}
+
+	Describe("func ServeHTTP()", func() {
+		It("calls unary methods", func() {
+			res := call("Unary", inputs("hello"))
+			defer res.Body.Close()
+
+			expectGRPCOutput(res, "HELLO")
+			expectGRPCStatus(res, "0", "")
+		})
+
+		It("calls client streaming methods", func() {
+			res := call("ClientStream", inputs("a", "b", "c"))
+			defer res.Body.Close()
+
+			expectGRPCOutput(res, "a,b,c")
+			expectGRPCStatus(res, "0", "")
+		})
+
+		It("calls server streaming methods", func() {
+			res := call("ServerStream", inputs("abc"))
+			defer res.Body.Close()
+
+			expectGRPCOutput(res, "a")
+			expectGRPCOutput(res, "b")
+			expectGRPCOutput(res, "c")
+			expectGRPCStatus(res, "0", "")
+		})
+
+		It("calls bidirectional streaming methods", func() {
+			pr, pw := io.Pipe()
+			defer pw.Close()
+
+			res := call("BidirectionalStream", pr)
+			defer res.Body.Close()
+
+			writeGRPCInput(pw, "a")
+			expectGRPCOutput(res, "A")
+
+			writeGRPCInput(pw, "b")
+			expectGRPCOutput(res, "B")
+
+			pw.Close()
+			expectGRPCStatus(res, "0", "")
+		})
+
+		It("maps the error returned by the RPC method to a gRPC status", func() {
+			service.UnaryFunc = func(
+				context.Context,
+				*testservice.Input,
+			) (*testservice.Output, error) {
+				return nil, rpcerror.New(rpcerror.NotFound, "<error> %d%%", 100)
+			}
+
+			res := call("Unary", inputs("hello"))
+			defer res.Body.Close()
+
+			expectGRPCStatus(res, "5", "<error> 100%25")
+		})
+
+		It("responds with an UNIMPLEMENTED status if the method does not exist", func() {
+			res := call("Unknown", inputs("hello"))
+			defer res.Body.Close()
+
+			Expect(res.Header.Get("Grpc-Status")).To(Equal("12"))
+			Expect(res.Header.Get("Grpc-Message")).To(Equal(
+				"the 'protean.test.TestService' service does not contain an RPC method named 'Unknown'",
+			))
+		})
+
+		It("responds with an UNIMPLEMENTED status if the message encoding is not supported", func() {
+			req, err := http.NewRequestWithContext(
+				ctx,
+				http.MethodPost,
+				server.URL+"/protean.test.TestService/Unary",
+				inputs("hello"),
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			req.Header.Set("Content-Type", "application/grpc")
+			req.Header.Set("Grpc-Encoding", "gzip")
+
+			res, err := server.Client().Do(req)
+			Expect(err).ShouldNot(HaveOccurred())
+			defer res.Body.Close()
+
+			Expect(res.Header.Get("Grpc-Status")).To(Equal("12"))
+			Expect(res.Header.Get("Grpc-Message")).To(Equal(
+				"the server does not support the 'gzip' gRPC message encoding",
+			))
+		})
+
+		It("sends an error status if the request body ends part way through a message", func() {
+			res := call("ClientStream", bytes.NewReader([]byte{0, 0, 0, 0, 10, 1}))
+			defer res.Body.Close()
+
+			expectGRPCStatus(res, "2", "the request body ended part way through a frame")
+		})
+	})
+})
+
+// writeGRPCInput writes a gRPC message containing an RPC input message with
+// the given data to w.
+func writeGRPCInput(w io.Writer, data string) {
+	message, err := proto.Marshal(&testservice.Input{Data: data})
+	Expect(err).ShouldNot(HaveOccurred())
+
+	header := make([]byte, 5)
+	binary.BigEndian.PutUint32(header[1:], uint32(len(message)))
+
+	_, err = w.Write(append(header, message...))
+	Expect(err).ShouldNot(HaveOccurred())
+}
+
+// expectGRPCOutput asserts that the next gRPC message in the response body
+// contains an RPC output message with the given data.
+func expectGRPCOutput(res *http.Response, data string) {
+	header := make([]byte, 5)
+	_, err := io.ReadFull(res.Body, header)
+	Expect(err).ShouldNot(HaveOccurred())
+	Expect(header[0]).To(BeZero())
+
+	message := make([]byte, binary.BigEndian.Uint32(header[1:]))
+	_, err = io.ReadFull(res.Body, message)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	out := &testservice.Output{}
+	err = proto.Unmarshal(message, out)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	Expect(out.GetData()).To(Equal(data))
+}
+
+// expectGRPCStatus asserts that the response body contains no more messages
+// and that the response trailers contain the given gRPC status.
+func expectGRPCStatus(res *http.Response, status, message string) {
+	data, err := io.ReadAll(res.Body)
+	Expect(err).ShouldNot(HaveOccurred())
+	Expect(data).To(BeEmpty())
+
+	Expect(res.Trailer.Get("Grpc-Status")).To(Equal(status))
+	Expect(res.Trailer.Get("Grpc-Message")).To(Equal(message))
+}
+
+var _ = Describe("type Handler (gRPC-Web)", func() {
+	var (
+		ctx      context.Context
+		cancel   context.CancelFunc
+		handler  Handler
+		service  *testservice.Stub
+		request  *http.Request
+		response *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		format.TruncatedDiff = false
+
+		ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+
+		handler = NewHandler()
+
+		service = &testservice.Stub{
+			UnaryFunc: func(
+				_ context.Context,
+				in *testservice.Input,
+			) (*testservice.Output, error) {
+				return &testservice.Output{
+					Data: strings.ToUpper(in.GetData()),
+				}, nil
+			},
+			ServerStreamFunc: func(
+				ctx context.Context,
+				in *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				defer close(outputs)
+
+				for _, r := range in.GetData() {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case outputs <- &testservice.Output{Data: string(r)}:
+					}
+				}
+
+				return nil
+			},
+		}
+
+		testservice.RegisterProteanTestService(handler, service)
+
+		var body bytes.Buffer
+		writeGRPCInput(&body, "abc")
+
+		request = httptest.NewRequest(
+			http.MethodPost,
+			"/protean.test.TestService/Unary",
+			&body,
+		).WithContext(ctx)
+		request.Header.Set("Content-Type", "application/grpc-web+proto")
+
+		response = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		format.TruncatedDiff = true
+		cancel()
+	})
+
+	Describe("func ServeHTTP()", func() {
+		It("sends the trailers in the response body", func() {
+			handler.ServeHTTP(response, request)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response).To(HaveHTTPHeaderWithValue("Content-Type", "application/grpc-web+proto"))
+
+			res := response.Result()
+			expectGRPCOutput(res, "ABC")
+			expectGRPCWebTrailers(res, "grpc-status: 0\r\n")
+		})
+
+		It("calls server streaming methods", func() {
+			request.URL.Path = "/protean.test.TestService/ServerStream"
+
+			handler.ServeHTTP(response, request)
+
+			res := response.Result()
+			expectGRPCOutput(res, "a")
+			expectGRPCOutput(res, "b")
+			expectGRPCOutput(res, "c")
+			expectGRPCWebTrailers(res, "grpc-status: 0\r\n")
+		})
+
+		It("sends the error returned by the RPC method in the trailers", func() {
+			service.UnaryFunc = func(
+				context.Context,
+				*testservice.Input,
+			) (*testservice.Output, error) {
+				return nil, rpcerror.New(rpcerror.PermissionDenied, "<error>")
+			}
+
+			handler.ServeHTTP(response, request)
+
+			expectGRPCWebTrailers(
+				response.Result(),
+				"grpc-status: 7\r\ngrpc-message: <error>\r\n",
+			)
+		})
+
+		It("supports base64 encoded request and response bodies", func() {
+			var input bytes.Buffer
+			writeGRPCInput(&input, "ab")
+
+			// Split the request body into separately padded base64 chunks, as
+			// permitted by the gRPC-Web specification.
+			data := input.Bytes()
+			request.Body = io.NopCloser(strings.NewReader(
+				base64.StdEncoding.EncodeToString(data[:4]) +
+					base64.StdEncoding.EncodeToString(data[4:]),
+			))
+			request.URL.Path = "/protean.test.TestService/ServerStream"
+			request.Header.Set("Content-Type", "application/grpc-web-text")
+
+			handler.ServeHTTP(response, request)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response).To(HaveHTTPHeaderWithValue("Content-Type", "application/grpc-web-text"))
+
+			decoded := decodeBase64Chunks(response.Body.String())
+
+			res := &http.Response{Body: io.NopCloser(bytes.NewReader(decoded))}
+			expectGRPCOutput(res, "a")
+			expectGRPCOutput(res, "b")
+			expectGRPCWebTrailers(res, "grpc-status: 0\r\n")
+		})
+
+		It("does not support client streaming methods", func() {
+			request.URL.Path = "/protean.test.TestService/ClientStream"
+
+			handler.ServeHTTP(response, request)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response).To(HaveHTTPHeaderWithValue("Grpc-Status", "12"))
+			Expect(response).To(HaveHTTPHeaderWithValue(
+				"Grpc-Message",
+				"gRPC-Web does not support client streaming or bidirectional streaming RPC methods",
+			))
+			Expect(response.Body.Len()).To(BeZero())
+		})
+	})
+})
+
+// expectGRPCWebTrailers asserts that the response body contains a gRPC-Web
+// trailers frame with the given content, and nothing else.
+func expectGRPCWebTrailers(res *http.Response, trailers string) {
+	data, err := io.ReadAll(res.Body)
+	Expect(err).ShouldNot(HaveOccurred())
+	Expect(len(data)).To(BeNumerically(">=", 5))
+
+	Expect(data[0]).To(Equal(byte(0x80)))
+	Expect(binary.BigEndian.Uint32(data[1:5])).To(BeEquivalentTo(len(data) - 5))
+	Expect(string(data[5:])).To(Equal(trailers))
+}
+
+// decodeBase64Chunks decodes a sequence of separately padded base64 strings.
+func decodeBase64Chunks(data string) []byte {
+	var decoded []byte
+
+	for data != "" {
+		// Find the end of the next chunk, which is either the end of the data
+		// or the end of the padding.
+		end := len(data)
+		if i := strings.Index(data, "="); i != -1 {
+			end = i
+			for end < len(data) && data[end] == '=' {
+				end++
+			}
+		}
+
+		chunk, err := base64.StdEncoding.DecodeString(data[:end])
+		Expect(err).ShouldNot(HaveOccurred())
+
+		decoded = append(decoded, chunk...)
+		data = data[end:]
+	}
+
+	return decoded
+}
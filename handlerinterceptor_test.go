@@ -0,0 +1,274 @@
+package protean_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/proteanpb"
+	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/middleware"
+	"github.com/dogmatiq/protean/rpcerror"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ = Describe("type Handler (interceptors)", func() {
+	var service *testservice.Stub
+
+	BeforeEach(func() {
+		service = &testservice.Stub{
+			UnaryFunc: func(
+				_ context.Context,
+				in *testservice.Input,
+			) (*testservice.Output, error) {
+				return &testservice.Output{Data: in.GetData()}, nil
+			},
+			ServerStreamFunc: func(
+				ctx context.Context,
+				in *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				defer close(outputs)
+
+				for _, r := range in.GetData() {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case outputs <- &testservice.Output{Data: string(r)}:
+					}
+				}
+
+				return nil
+			},
+		}
+	})
+
+	Describe("func WithServerInterceptor()", func() {
+		It("invokes the interceptor before the validator", func() {
+			handler := NewHandler(
+				WithServerInterceptor(unaryInterceptorFunc(func(
+					ctx context.Context,
+					info middleware.UnaryServerInfo,
+					in proto.Message,
+					next func(context.Context) (proto.Message, error),
+				) (proto.Message, error) {
+					Expect(info).To(Equal(middleware.UnaryServerInfo{
+						Package: "protean.test",
+						Service: "TestService",
+						Method:  "Unary",
+					}))
+
+					// Populate the otherwise invalid input message.
+					in.(*testservice.Input).Data = "<input>"
+
+					return next(ctx)
+				})),
+			)
+			testservice.RegisterProteanTestService(handler, service)
+
+			request := httptest.NewRequest(
+				http.MethodPost,
+				"/protean.test/TestService/Unary",
+				strings.NewReader(`{}`),
+			)
+			request.Header.Set("Content-Type", "application/json")
+
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, request)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response.Body.String()).To(MatchJSON(`{"data":"<input>"}`))
+		})
+	})
+
+	When("a call is rejected by a rate limiter", func() {
+		It("sets the Retry-After header", func() {
+			handler := NewHandler(
+				WithServerInterceptor(&middleware.RateLimiter{
+					Limit: middleware.RateLimit{
+						Rate:  0.1, // one call every 10 seconds
+						Burst: 1,
+					},
+				}),
+			)
+			testservice.RegisterProteanTestService(handler, service)
+
+			// serve calls the Unary method and returns the response.
+			serve := func() *httptest.ResponseRecorder {
+				request := httptest.NewRequest(
+					http.MethodPost,
+					"/protean.test/TestService/Unary",
+					strings.NewReader(`{"data":"<input>"}`),
+				)
+				request.Header.Set("Content-Type", "application/json")
+
+				response := httptest.NewRecorder()
+				handler.ServeHTTP(response, request)
+
+				return response
+			}
+
+			response := serve()
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response.Header().Get("Retry-After")).To(BeEmpty())
+
+			response = serve()
+			Expect(response).To(HaveHTTPHeaderWithValue("Retry-After", "10"))
+			expectError(
+				response,
+				http.StatusTooManyRequests,
+				"application/json; x-proto=protean.v1.Error",
+				rpcerror.New(
+					rpcerror.ResourceExhausted,
+					"the rate limit has been exceeded",
+				),
+			)
+		})
+	})
+
+	Describe("func WithStreamServerInterceptor()", func() {
+		// serve calls the ServerStream method with the given input data via
+		// server-sent events, using a POST request.
+		serve := func(handler Handler, data string) *httptest.ResponseRecorder {
+			testservice.RegisterProteanTestService(handler, service)
+
+			request := httptest.NewRequest(
+				http.MethodPost,
+				"/protean.test/TestService/ServerStream",
+				strings.NewReader(`{"data":"`+data+`"}`),
+			)
+			request.Header.Set("Content-Type", "application/json")
+			request.Header.Set("Accept", "text/event-stream")
+
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, request)
+
+			return response
+		}
+
+		It("allows the interceptor to modify each message", func() {
+			handler := NewHandler(
+				WithStreamServerInterceptor(streamInterceptorFunc(func(
+					ctx context.Context,
+					info middleware.StreamServerInfo,
+					stream middleware.ServerStream,
+					next func(context.Context, middleware.ServerStream) error,
+				) error {
+					Expect(info).To(Equal(middleware.StreamServerInfo{
+						Package:        "protean.test",
+						Service:        "TestService",
+						Method:         "ServerStream",
+						OutputIsStream: true,
+					}))
+
+					return next(ctx, outputRewriter{stream})
+				})),
+			)
+
+			response := serve(handler, "ab")
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response.Body.String()).To(Equal(
+				"data: " + marshalJSON(&testservice.Output{Data: "<a>"}) + "\n\n" +
+					"data: " + marshalJSON(&testservice.Output{Data: "<b>"}) + "\n\n" +
+					"event: done\ndata: \n\n",
+			))
+		})
+
+		It("sends the error returned by the interceptor", func() {
+			handler := NewHandler(
+				WithStreamServerInterceptor(streamInterceptorFunc(func(
+					context.Context,
+					middleware.StreamServerInfo,
+					middleware.ServerStream,
+					func(context.Context, middleware.ServerStream) error,
+				) error {
+					return rpcerror.New(rpcerror.PermissionDenied, "<error>")
+				})),
+			)
+
+			response := serve(handler, "ab")
+
+			var protoErr proteanpb.Error
+			err := rpcerror.ToProto(rpcerror.New(rpcerror.PermissionDenied, "<error>"), &protoErr)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(response.Body.String()).To(Equal(
+				"event: rpcerror\ndata: " + marshalJSON(&protoErr) + "\n\n",
+			))
+		})
+
+		It("validates streamed messages by default", func() {
+			response := serve(NewHandler(), "")
+
+			var protoErr proteanpb.Error
+			err := rpcerror.ToProto(
+				rpcerror.New(
+					rpcerror.InvalidInput,
+					"the RPC input message is invalid: input data must not be empty",
+				),
+				&protoErr,
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(response.Body.String()).To(Equal(
+				"event: rpcerror\ndata: " + marshalJSON(&protoErr) + "\n\n",
+			))
+		})
+	})
+})
+
+// unaryInterceptorFunc is an adaptor that allows a function to be used as a
+// middleware.ServerInterceptor.
+type unaryInterceptorFunc func(
+	ctx context.Context,
+	info middleware.UnaryServerInfo,
+	in proto.Message,
+	next func(context.Context) (proto.Message, error),
+) (proto.Message, error)
+
+func (fn unaryInterceptorFunc) InterceptUnaryRPC(
+	ctx context.Context,
+	info middleware.UnaryServerInfo,
+	in proto.Message,
+	next func(context.Context) (proto.Message, error),
+) (proto.Message, error) {
+	return fn(ctx, info, in, next)
+}
+
+// streamInterceptorFunc is an adaptor that allows a function to be used as a
+// middleware.StreamServerInterceptor.
+type streamInterceptorFunc func(
+	ctx context.Context,
+	info middleware.StreamServerInfo,
+	stream middleware.ServerStream,
+	next func(context.Context, middleware.ServerStream) error,
+) error
+
+func (fn streamInterceptorFunc) InterceptStreamRPC(
+	ctx context.Context,
+	info middleware.StreamServerInfo,
+	stream middleware.ServerStream,
+	next func(context.Context, middleware.ServerStream) error,
+) error {
+	return fn(ctx, info, stream, next)
+}
+
+// outputRewriter is a middleware.ServerStream that wraps each output message's
+// data in angle brackets.
+type outputRewriter struct {
+	middleware.ServerStream
+}
+
+func (s outputRewriter) SendOutput(ctx context.Context, out proto.Message) (proto.Message, error) {
+	return s.ServerStream.SendOutput(
+		ctx,
+		&testservice.Output{
+			Data: "<" + out.(*testservice.Output).GetData() + ">",
+		},
+	)
+}
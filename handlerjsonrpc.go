@@ -0,0 +1,380 @@
+package protean
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dogmatiq/protean/internal/proteanpb"
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/rpcerror"
+	"github.com/dogmatiq/protean/runtime"
+	"google.golang.org/protobuf/proto"
+)
+
+// These constants are the error codes defined by the JSON-RPC 2.0
+// specification.
+//
+// See https://www.jsonrpc.org/specification#error_object.
+const (
+	jsonRPCParseError     = -32700
+	jsonRPCInvalidRequest = -32600
+	jsonRPCMethodNotFound = -32601
+	jsonRPCInvalidParams  = -32602
+)
+
+// jsonRPCVersion is the value of the "jsonrpc" property in JSON-RPC 2.0
+// requests and responses.
+const jsonRPCVersion = "2.0"
+
+// jsonRPCRequest is a JSON-RPC 2.0 request object.
+type jsonRPCRequest struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+
+	// ID is the request ID. It is nil if the request is a notification, which
+	// is distinct from an explicit "null" ID.
+	ID json.RawMessage `json:"id"`
+}
+
+// jsonRPCResponse is a JSON-RPC 2.0 response object.
+type jsonRPCResponse struct {
+	Version string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// jsonRPCError is a JSON-RPC 2.0 error object.
+type jsonRPCError struct {
+	Code    int32           `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// jsonRPCNullID is the ID used in responses to requests with an ID that can not
+// be determined.
+var jsonRPCNullID = json.RawMessage("null")
+
+// serveJSONRPC serves a JSON-RPC 2.0 request, which may be a batch request.
+//
+// Each JSON-RPC "method" is the fully-qualified name of a unary RPC method, in
+// the "<package>.<service>/<method>" form, and its "params" property is the
+// JSON representation of the RPC input message.
+func (h *handler) serveJSONRPC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpError(
+			w,
+			http.StatusMethodNotAllowed,
+			protomime.TextMediaTypes[0],
+			protomime.TextMarshaler,
+			rpcerror.New(
+				rpcerror.NotImplemented,
+				"the HTTP method must be POST",
+			),
+		)
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if !protomime.IsJSON(mediaType) {
+		httpError(
+			w,
+			http.StatusUnsupportedMediaType,
+			protomime.TextMediaTypes[0],
+			protomime.TextMarshaler,
+			rpcerror.New(
+				rpcerror.Unknown,
+				"JSON-RPC requests must use the 'application/json' media-type",
+			),
+		)
+		return
+	}
+
+	// The request may contain the input messages for any of the unary methods,
+	// so the body is limited to the largest size permitted for any method.
+	// Each call's params are then checked against the limit for its method.
+	limit := h.maxUnaryInputSize()
+
+	contentLength, ok := h.parseContentLength(w, r, limit)
+	if !ok {
+		return
+	}
+
+	body, ok := h.readRequestBody(
+		w,
+		r,
+		contentLength,
+		limit,
+		protomime.TextMediaTypes[0],
+		protomime.TextMarshaler,
+	)
+	if !ok {
+		return
+	}
+
+	var data []byte
+
+	if body = bytes.TrimSpace(body); len(body) != 0 && body[0] == '[' {
+		data = h.callJSONRPCBatch(r, body)
+	} else if res, ok := h.callJSONRPC(r, body); ok {
+		data, _ = json.Marshal(res)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if data == nil {
+		// All of the requests were notifications, so there is nothing to
+		// respond with.
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", protomime.JSONMediaTypes[0])
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// maxJSONRPCBatchConcurrency is the maximum number of RPC calls made
+// concurrently on behalf of a single JSON-RPC batch request.
+const maxJSONRPCBatchConcurrency = 8
+
+// callJSONRPCBatch calls the RPC methods in a JSON-RPC batch request
+// concurrently, up to maxJSONRPCBatchConcurrency at a time.
+//
+// It returns the marshaled batch response, or nil if every request in the batch
+// is a notification.
+func (h *handler) callJSONRPCBatch(r *http.Request, body []byte) []byte {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		data, _ := json.Marshal(
+			newJSONRPCError(jsonRPCNullID, jsonRPCParseError, "the request body is not valid JSON"),
+		)
+		return data
+	}
+
+	if len(batch) == 0 {
+		data, _ := json.Marshal(
+			newJSONRPCError(jsonRPCNullID, jsonRPCInvalidRequest, "the batch request must contain at least one request"),
+		)
+		return data
+	}
+
+	responses := make([]*jsonRPCResponse, len(batch))
+
+	// Limit the number of concurrent calls, such that a single batch request
+	// can not start an unbounded number of goroutines.
+	sem := make(chan struct{}, maxJSONRPCBatchConcurrency)
+
+	var g sync.WaitGroup
+	for i, req := range batch {
+		sem <- struct{}{}
+
+		g.Add(1)
+		go func() {
+			defer g.Done()
+			defer func() { <-sem }()
+
+			if res, ok := h.callJSONRPC(r, req); ok {
+				responses[i] = &res
+			}
+		}()
+	}
+	g.Wait()
+
+	var results []*jsonRPCResponse
+	for _, res := range responses {
+		if res != nil {
+			results = append(results, res)
+		}
+	}
+
+	if len(results) == 0 {
+		return nil
+	}
+
+	data, _ := json.Marshal(results)
+	return data
+}
+
+// callJSONRPC calls the RPC method described by a single JSON-RPC request.
+//
+// ok is false if the request is a notification, in which case no response is
+// sent to the client.
+func (h *handler) callJSONRPC(r *http.Request, body []byte) (_ jsonRPCResponse, ok bool) {
+	if !json.Valid(body) {
+		return newJSONRPCError(jsonRPCNullID, jsonRPCParseError, "the request is not valid JSON"), true
+	}
+
+	var req jsonRPCRequest
+	if err := json.Unmarshal(body, &req); err != nil || req.Version != jsonRPCVersion || req.Method == "" {
+		return newJSONRPCError(jsonRPCNullID, jsonRPCInvalidRequest, "the request is not a valid JSON-RPC 2.0 request object"), true
+	}
+
+	res := h.callJSONRPCMethod(r, req)
+
+	if req.ID == nil {
+		return jsonRPCResponse{}, false
+	}
+
+	res.ID = req.ID
+	return res, true
+}
+
+// callJSONRPCMethod calls the RPC method named in a JSON-RPC request.
+func (h *handler) callJSONRPCMethod(r *http.Request, req jsonRPCRequest) jsonRPCResponse {
+	method, ok := h.resolveJSONRPCMethod(req.Method)
+	if !ok {
+		return newJSONRPCError(
+			req.ID,
+			jsonRPCMethodNotFound,
+			"the server does not provide a unary RPC method named '"+req.Method+"'",
+		)
+	}
+
+	params := bytes.TrimSpace(req.Params)
+	if len(params) == 0 || bytes.Equal(params, jsonRPCNullID) {
+		params = []byte("{}")
+	} else if params[0] != '{' {
+		return newJSONRPCError(
+			req.ID,
+			jsonRPCInvalidParams,
+			"the params must be a JSON object containing the RPC input message",
+		)
+	}
+
+	// The request body as a whole is subject to the largest limit of any
+	// method, so the params must also be checked against this method's limit.
+	if limit := h.maxInputSizeFor(method); len(params) > limit {
+		return newJSONRPCErrorFromRPCError(
+			req.ID,
+			newInputSizeLimitError(
+				limit,
+				"the RPC input message length exceeds the maximum allowable size",
+			),
+		)
+	}
+
+	out, rpcErr, ok := h.invokeUnaryMessage(
+		r.Context(),
+		method,
+		func(in proto.Message) error {
+			return protomime.JSONUnmarshaler.Unmarshal(params, in)
+		},
+	)
+	if !ok {
+		if errors.Is(rpcErr, errMalformedInput) {
+			return newJSONRPCError(
+				req.ID,
+				jsonRPCInvalidParams,
+				"the RPC input message could not be unmarshaled from the params",
+			)
+		}
+
+		return newJSONRPCErrorFromRPCError(req.ID, rpcErr)
+	}
+
+	result, err := protomime.JSONMarshaler.Marshal(out)
+	if err != nil {
+		return newJSONRPCErrorFromRPCError(
+			req.ID,
+			rpcerror.New(
+				rpcerror.Unknown,
+				"the RPC output message could not be marshaled to the result",
+			),
+		)
+	}
+
+	return jsonRPCResponse{
+		Version: jsonRPCVersion,
+		Result:  result,
+		ID:      req.ID,
+	}
+}
+
+// resolveJSONRPCMethod looks up the unary RPC method with the given
+// fully-qualified name.
+func (h *handler) resolveJSONRPCMethod(name string) (runtime.Method, bool) {
+	i := strings.LastIndexByte(name, '/')
+	if i == -1 {
+		return nil, false
+	}
+
+	service, ok := h.services[name[:i]]
+	if !ok {
+		return nil, false
+	}
+
+	method, ok := service.MethodByName(name[i+1:])
+	if !ok || method.InputIsStream() || method.OutputIsStream() {
+		return nil, false
+	}
+
+	return method, true
+}
+
+// newJSONRPCError returns a JSON-RPC response that describes one of the errors
+// defined by the JSON-RPC specification.
+func newJSONRPCError(id json.RawMessage, code int32, message string) jsonRPCResponse {
+	return newJSONRPCErrorFromProto(
+		id,
+		&proteanpb.Error{
+			Code:    code,
+			Message: message,
+		},
+	)
+}
+
+// newJSONRPCErrorFromRPCError returns a JSON-RPC response that describes an
+// RPC error.
+func newJSONRPCErrorFromRPCError(id json.RawMessage, rpcErr rpcerror.Error) jsonRPCResponse {
+	var protoErr proteanpb.Error
+	if err := rpcerror.ToProto(rpcErr, &protoErr); err != nil {
+		panic(err)
+	}
+
+	return newJSONRPCErrorFromProto(id, &protoErr)
+}
+
+// newJSONRPCErrorFromProto returns a JSON-RPC response containing an error.
+//
+// The error object is built explicitly, rather than by marshaling protoErr, as
+// the JSON-RPC specification requires the "code" and "message" members even
+// when they have zero values, such as for rpcerror.Unknown errors.
+func newJSONRPCErrorFromProto(id json.RawMessage, protoErr *proteanpb.Error) jsonRPCResponse {
+	e := jsonRPCError{
+		Code:    protoErr.GetCode(),
+		Message: protoErr.GetMessage(),
+	}
+
+	if protoErr.GetData() != nil {
+		data, err := protomime.JSONMarshaler.Marshal(protoErr.GetData())
+		if err != nil {
+			panic(err)
+		}
+		e.Data = data
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		panic(err)
+	}
+
+	if id == nil {
+		id = jsonRPCNullID
+	}
+
+	return jsonRPCResponse{
+		Version: jsonRPCVersion,
+		Error:   data,
+		ID:      id,
+	}
+}
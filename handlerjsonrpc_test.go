@@ -0,0 +1,323 @@
+package protean_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/proteanpb"
+	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/rpcerror"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+)
+
+var _ = Describe("type Handler (JSON-RPC)", func() {
+	var (
+		ctx      context.Context
+		cancel   context.CancelFunc
+		handler  Handler
+		calls    chan *testservice.Input
+		service  *testservice.Stub
+		response *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		format.TruncatedDiff = false
+
+		ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+
+		handler = NewHandler(
+			WithJSONRPCEndpoint("/rpc"),
+		)
+
+		calls = make(chan *testservice.Input, 10)
+		service = &testservice.Stub{
+			UnaryFunc: func(
+				_ context.Context,
+				in *testservice.Input,
+			) (*testservice.Output, error) {
+				calls <- in
+
+				if in.GetData() == "<fail>" {
+					return nil, rpcerror.New(rpcerror.NotFound, "<error>")
+				}
+
+				return &testservice.Output{Data: "<output:" + in.GetData() + ">"}, nil
+			},
+		}
+
+		testservice.RegisterProteanTestService(handler, service)
+
+		response = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		format.TruncatedDiff = true
+		cancel()
+	})
+
+	serve := func(body string) {
+		request := httptest.NewRequest(
+			http.MethodPost,
+			"/rpc",
+			strings.NewReader(body),
+		).WithContext(ctx)
+		request.Header.Set("Content-Type", "application/json")
+
+		handler.ServeHTTP(response, request)
+	}
+
+	Describe("func ServeHTTP()", func() {
+		It("responds with the RPC output message as the result", func() {
+			serve(`{"jsonrpc":"2.0","id":1,"method":"protean.test.TestService/Unary","params":{"data":"<input>"}}`)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response).To(HaveHTTPHeaderWithValue("Content-Type", "application/json"))
+			Expect(response.Body.String()).To(MatchJSON(
+				`{"jsonrpc":"2.0","id":1,"result":{"data":"<output:<input>>"}}`,
+			))
+			Expect((<-calls).GetData()).To(Equal("<input>"))
+		})
+
+		It("treats absent params as an empty RPC input message", func() {
+			serve(`{"jsonrpc":"2.0","id":"abc","method":"protean.test.TestService/Unary"}`)
+
+			// The test service's input message is not valid when it's empty,
+			// which also verifies that the input is validated before the call.
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response.Body.String()).To(MatchJSON(
+				`{"jsonrpc":"2.0","id":"abc","error":{"code":-3,"message":"the RPC input message is invalid: input data must not be empty"}}`,
+			))
+			Expect(calls).To(BeEmpty())
+		})
+
+		It("responds with the error returned by the RPC method", func() {
+			serve(`{"jsonrpc":"2.0","id":1,"method":"protean.test.TestService/Unary","params":{"data":"<fail>"}}`)
+
+			var protoErr proteanpb.Error
+			err := rpcerror.ToProto(rpcerror.New(rpcerror.NotFound, "<error>"), &protoErr)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response.Body.String()).To(MatchJSON(
+				`{"jsonrpc":"2.0","id":1,"error":` + marshalJSON(&protoErr) + `}`,
+			))
+		})
+
+		It("includes the error code when the RPC method returns an unrecognized error", func() {
+			service.UnaryFunc = func(
+				context.Context,
+				*testservice.Input,
+			) (*testservice.Output, error) {
+				return nil, errors.New("<error>")
+			}
+
+			serve(`{"jsonrpc":"2.0","id":1,"method":"protean.test.TestService/Unary","params":{"data":"<input>"}}`)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response.Body.String()).To(MatchJSON(
+				`{"jsonrpc":"2.0","id":1,"error":{"code":0,"message":"the RPC method returned an unrecognized error"}}`,
+			))
+		})
+
+		It("does not respond to notifications", func() {
+			serve(`{"jsonrpc":"2.0","method":"protean.test.TestService/Unary","params":{"data":"<input>"}}`)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusNoContent))
+			Expect(response.Body.Len()).To(BeZero())
+			Expect((<-calls).GetData()).To(Equal("<input>"))
+		})
+
+		It("responds to each request in a batch", func() {
+			serve(`[
+				{"jsonrpc":"2.0","id":1,"method":"protean.test.TestService/Unary","params":{"data":"a"}},
+				{"jsonrpc":"2.0","method":"protean.test.TestService/Unary","params":{"data":"b"}},
+				{"jsonrpc":"2.0","id":2,"method":"protean.test.TestService/Unknown"},
+				{"jsonrpc":"2.0","id":3,"method":"protean.test.TestService/Unary","params":{"data":"c"}}
+			]`)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+
+			var batch []map[string]json.RawMessage
+			err := json.Unmarshal(response.Body.Bytes(), &batch)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(batch).To(HaveLen(3))
+
+			Expect(batch[0]["id"]).To(MatchJSON(`1`))
+			Expect(batch[0]["result"]).To(MatchJSON(`{"data":"<output:a>"}`))
+			Expect(batch[1]["id"]).To(MatchJSON(`2`))
+			Expect(batch[1]["error"]).To(MatchJSON(`{"code":-32601,"message":"the server does not provide a unary RPC method named 'protean.test.TestService/Unknown'"}`))
+			Expect(batch[2]["id"]).To(MatchJSON(`3`))
+			Expect(batch[2]["result"]).To(MatchJSON(`{"data":"<output:c>"}`))
+
+			Expect(calls).To(HaveLen(3))
+		})
+
+		It("limits the number of concurrent calls made for a batch", func() {
+			var active, peak atomic.Int32
+
+			service.UnaryFunc = func(
+				context.Context,
+				*testservice.Input,
+			) (*testservice.Output, error) {
+				n := active.Add(1)
+				defer active.Add(-1)
+
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+
+				time.Sleep(time.Millisecond)
+
+				return &testservice.Output{Data: "<output>"}, nil
+			}
+
+			var requests []string
+			for i := 0; i < 100; i++ {
+				requests = append(
+					requests,
+					`{"jsonrpc":"2.0","id":`+strconv.Itoa(i)+`,"method":"protean.test.TestService/Unary","params":{"data":"<input>"}}`,
+				)
+			}
+
+			serve("[" + strings.Join(requests, ",") + "]")
+
+			var batch []json.RawMessage
+			err := json.Unmarshal(response.Body.Bytes(), &batch)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(batch).To(HaveLen(100))
+
+			Expect(peak.Load()).To(BeNumerically(">", 1))
+			Expect(peak.Load()).To(BeNumerically("<=", 8))
+		})
+
+		It("does not respond to a batch that contains only notifications", func() {
+			serve(`[
+				{"jsonrpc":"2.0","method":"protean.test.TestService/Unary","params":{"data":"a"}},
+				{"jsonrpc":"2.0","method":"protean.test.TestService/Unary","params":{"data":"b"}}
+			]`)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusNoContent))
+			Expect(calls).To(HaveLen(2))
+		})
+
+		DescribeTable(
+			"it responds with a JSON-RPC error if the request is invalid",
+			func(body, expect string) {
+				serve(body)
+
+				Expect(response).To(HaveHTTPStatus(http.StatusOK))
+				Expect(response.Body.String()).To(MatchJSON(expect))
+				Expect(calls).To(BeEmpty())
+			},
+			Entry(
+				"malformed JSON",
+				`{garbage`,
+				`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"the request is not valid JSON"}}`,
+			),
+			Entry(
+				"malformed batch",
+				`[{garbage`,
+				`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"the request body is not valid JSON"}}`,
+			),
+			Entry(
+				"empty batch",
+				`[]`,
+				`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"the batch request must contain at least one request"}}`,
+			),
+			Entry(
+				"invalid request in batch",
+				`[1]`,
+				`[{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"the request is not a valid JSON-RPC 2.0 request object"}}]`,
+			),
+			Entry(
+				"wrong version",
+				`{"jsonrpc":"1.0","id":1,"method":"protean.test.TestService/Unary"}`,
+				`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"the request is not a valid JSON-RPC 2.0 request object"}}`,
+			),
+			Entry(
+				"missing method",
+				`{"jsonrpc":"2.0","id":1}`,
+				`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"the request is not a valid JSON-RPC 2.0 request object"}}`,
+			),
+			Entry(
+				"unknown service",
+				`{"jsonrpc":"2.0","id":1,"method":"package.Service/Method"}`,
+				`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"the server does not provide a unary RPC method named 'package.Service/Method'"}}`,
+			),
+			Entry(
+				"streaming method",
+				`{"jsonrpc":"2.0","id":1,"method":"protean.test.TestService/ServerStream"}`,
+				`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"the server does not provide a unary RPC method named 'protean.test.TestService/ServerStream'"}}`,
+			),
+			Entry(
+				"positional params",
+				`{"jsonrpc":"2.0","id":1,"method":"protean.test.TestService/Unary","params":["<input>"]}`,
+				`{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"the params must be a JSON object containing the RPC input message"}}`,
+			),
+			Entry(
+				"params that do not match the RPC input message",
+				`{"jsonrpc":"2.0","id":1,"method":"protean.test.TestService/Unary","params":{"unknown":1}}`,
+				`{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"the RPC input message could not be unmarshaled from the params"}}`,
+			),
+		)
+
+		It("responds with an HTTP '405 Method Not Allowed' status if the HTTP method is not POST", func() {
+			request := httptest.NewRequest(http.MethodGet, "/rpc", nil).WithContext(ctx)
+
+			handler.ServeHTTP(response, request)
+
+			expectError(
+				response,
+				http.StatusMethodNotAllowed,
+				"text/plain; charset=utf-8; x-proto=protean.v1.Error",
+				rpcerror.New(
+					rpcerror.NotImplemented,
+					"the HTTP method must be POST",
+				),
+			)
+		})
+
+		It("responds with an HTTP '415 Unsupported Media Type' status if the request is not JSON", func() {
+			request := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{}`)).WithContext(ctx)
+			request.Header.Set("Content-Type", "text/plain")
+
+			handler.ServeHTTP(response, request)
+
+			expectError(
+				response,
+				http.StatusUnsupportedMediaType,
+				"text/plain; charset=utf-8; x-proto=protean.v1.Error",
+				rpcerror.New(
+					rpcerror.Unknown,
+					"JSON-RPC requests must use the 'application/json' media-type",
+				),
+			)
+		})
+
+		It("does not serve JSON-RPC requests unless the endpoint is enabled", func() {
+			handler = NewHandler()
+			testservice.RegisterProteanTestService(handler, service)
+
+			request := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{}`)).WithContext(ctx)
+			request.Header.Set("Content-Type", "application/json")
+
+			handler.ServeHTTP(response, request)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusNotFound))
+		})
+	})
+})
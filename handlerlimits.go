@@ -0,0 +1,223 @@
+package protean
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/dogmatiq/protean/rpcerror"
+	"github.com/dogmatiq/protean/runtime"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxInputSizeFor returns the maximum size of RPC input messages for m.
+//
+// Limits configured using handler options take precedence over limits
+// declared in the service definition, which in turn take precedence over the
+// handler's default limit.
+func (h *handler) maxInputSizeFor(m runtime.Method) int {
+	if n, ok := lookupSizeLimit(h.maxInputSizes, m); ok {
+		return n
+	}
+
+	if n := m.MaxInputSize(); n > 0 {
+		return n
+	}
+
+	return h.maxInputSize
+}
+
+// maxUnaryInputSize returns the largest maximum size of RPC input messages for
+// any of the unary RPC methods registered with the handler.
+//
+// It is used to limit the size of requests that may contain the input messages
+// for several different methods, such as JSON-RPC batches, before the methods
+// themselves are known.
+func (h *handler) maxUnaryInputSize() int {
+	limit := h.maxInputSize
+
+	for _, s := range h.services {
+		methods := s.Descriptor().Methods()
+
+		for i := 0; i < methods.Len(); i++ {
+			m, ok := s.MethodByName(string(methods.Get(i).Name()))
+			if !ok || m.InputIsStream() || m.OutputIsStream() {
+				continue
+			}
+
+			limit = max(limit, h.maxInputSizeFor(m))
+		}
+	}
+
+	return limit
+}
+
+// maxOutputSizeFor returns the maximum size of RPC output messages for m, or
+// zero if the size of output messages is not limited.
+//
+// The precedence of the limits is the same as for maxInputSizeFor().
+func (h *handler) maxOutputSizeFor(m runtime.Method) int {
+	if n, ok := lookupSizeLimit(h.maxOutputSizes, m); ok {
+		return n
+	}
+
+	if n := m.MaxOutputSize(); n > 0 {
+		return n
+	}
+
+	return h.maxOutputSize
+}
+
+// lookupSizeLimit returns the limit for m within limits, which is keyed by
+// either the fully-qualified method name or service name.
+func lookupSizeLimit(limits map[string]int, m runtime.Method) (int, bool) {
+	if len(limits) == 0 {
+		return 0, false
+	}
+
+	md := m.Descriptor()
+	service := string(md.Parent().FullName())
+
+	if n, ok := limits[service+"/"+string(md.Name())]; ok {
+		return n, true
+	}
+
+	n, ok := limits[service]
+	return n, ok
+}
+
+// errInputSizeLimitExceeded is the cause of the error returned by
+// newInputSizeLimitError().
+var errInputSizeLimitExceeded = errors.New("input size limit exceeded")
+
+// newInputSizeLimitError returns the error that is produced when an RPC input
+// message exceeds the given limit.
+//
+// The error uses the rpcerror.InvalidInput code, as retrying the same call can
+// never succeed. The rpcerror.ResourceExhausted code is not used, as it is
+// reported to the client as a reason to retry later.
+func newInputSizeLimitError(limit int, format string, args ...any) rpcerror.Error {
+	return rpcerror.New(
+		rpcerror.InvalidInput,
+		format,
+		args...,
+	).WithDetails(
+		&rpcerror.InputSizeLimitExceeded{
+			Limit: int64(limit),
+		},
+	).WithCause(errInputSizeLimitExceeded)
+}
+
+// newOutputSizeLimitError returns the error that is produced when an RPC output
+// message exceeds the given limit.
+//
+// The error uses the rpcerror.Unknown code, as the failure is caused by the
+// server and must not be reported to the client as a reason to retry later.
+func newOutputSizeLimitError(limit int) rpcerror.Error {
+	return rpcerror.New(
+		rpcerror.Unknown,
+		"the RPC output message length exceeds the maximum allowable size",
+	).WithDetails(
+		&rpcerror.OutputSizeLimitExceeded{
+			Limit: int64(limit),
+		},
+	)
+}
+
+// newCall starts a new call to m, enforcing the method's maximum output size.
+//
+// If ctx has a deadline, its expiry is reported as an rpcerror.DeadlineExceeded
+// error.
+func (h *handler) newCall(ctx context.Context, m runtime.Method) runtime.Call {
+	options := runtime.CallOptions{
+		Interceptor:       h.interceptors,
+		StreamInterceptor: h.streamInterceptors,
+	}
+
+	var call runtime.Call
+
+	if limit := h.maxOutputSizeFor(m); limit == 0 {
+		call = m.NewCall(ctx, options)
+	} else {
+		callCtx, cancel := context.WithCancel(ctx)
+
+		call = &outputLimitedCall{
+			Call:   m.NewCall(callCtx, options),
+			cancel: cancel,
+			limit:  limit,
+		}
+	}
+
+	if _, ok := ctx.Deadline(); ok {
+		call = &deadlineCall{
+			Call: call,
+			ctx:  ctx,
+		}
+	}
+
+	return call
+}
+
+// outputLimitedCall is a runtime.Call that fails if the RPC method produces an
+// output message that exceeds a size limit.
+//
+// The size of each output message is measured using the Protocol Buffers
+// binary format, regardless of the media-type used by the transport.
+type outputLimitedCall struct {
+	runtime.Call
+
+	cancel context.CancelFunc
+	limit  int
+
+	m   sync.Mutex
+	err *rpcerror.Error
+}
+
+// Recv returns the next output message produced by this call.
+//
+// If the message exceeds the size limit, the call's context is canceled and
+// Recv() returns false.
+func (c *outputLimitedCall) Recv() (proto.Message, bool) {
+	out, ok := c.Call.Recv()
+	if !ok {
+		return nil, false
+	}
+
+	if proto.Size(out) <= c.limit {
+		return out, true
+	}
+
+	err := newOutputSizeLimitError(c.limit)
+
+	c.m.Lock()
+	c.err = &err
+	c.m.Unlock()
+
+	c.cancel()
+
+	// Discard any output messages that the RPC method produced before it
+	// noticed that the context was canceled.
+	for {
+		if _, ok := c.Call.Recv(); !ok {
+			return nil, false
+		}
+	}
+}
+
+// Wait blocks until the RPC method returns.
+//
+// If an output message exceeded the size limit, it returns an error
+// describing the violation instead of the error returned by the RPC method.
+func (c *outputLimitedCall) Wait() error {
+	err := c.Call.Wait()
+	c.cancel()
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.err != nil {
+		return *c.err
+	}
+
+	return err
+}
@@ -0,0 +1,330 @@
+package protean_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/proteanpb"
+	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/rpcerror"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("type Handler (size limits)", func() {
+	var (
+		service *testservice.Stub
+	)
+
+	BeforeEach(func() {
+		echo := func(
+			_ context.Context,
+			in *testservice.Input,
+		) (*testservice.Output, error) {
+			return &testservice.Output{Data: in.GetData()}, nil
+		}
+
+		service = &testservice.Stub{
+			UnaryFunc:         echo,
+			NoSideEffectsFunc: echo,
+		}
+	})
+
+	// newHandler returns a new handler with the test service registered.
+	newHandler := func(options ...HandlerOption) Handler {
+		h := NewHandler(options...)
+		testservice.RegisterProteanTestService(h, service)
+		return h
+	}
+
+	// post makes a POST request to the given method of the test service.
+	post := func(h Handler, method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(
+			http.MethodPost,
+			"/protean.test/TestService/"+method,
+			strings.NewReader(body),
+		)
+		req.Header.Set("Content-Type", "application/json")
+
+		if method == "ServerStream" {
+			req.Header.Set("Accept", "text/event-stream")
+		}
+
+		res := httptest.NewRecorder()
+		h.ServeHTTP(res, req)
+
+		return res
+	}
+
+	inputTooLarge := func(limit int64) rpcerror.Error {
+		return rpcerror.New(
+			rpcerror.InvalidInput,
+			"the RPC input message length exceeds the maximum allowable size",
+		).WithDetails(
+			&rpcerror.InputSizeLimitExceeded{
+				Limit: limit,
+			},
+		)
+	}
+
+	outputTooLarge := func(limit int64) rpcerror.Error {
+		return rpcerror.New(
+			rpcerror.Unknown,
+			"the RPC output message length exceeds the maximum allowable size",
+		).WithDetails(
+			&rpcerror.OutputSizeLimitExceeded{
+				Limit: limit,
+			},
+		)
+	}
+
+	largeInput := `{"data":"` + strings.Repeat("x", 2048) + `"}`
+
+	Describe("func WithMaxRPCInputSize()", func() {
+		It("applies a method-specific limit to that method only", func() {
+			h := newHandler(
+				WithMaxRPCInputSize(16, "protean.test.TestService/Unary"),
+			)
+
+			res := post(h, "Unary", largeInput)
+			expectError(
+				res,
+				http.StatusRequestEntityTooLarge,
+				"text/plain; charset=utf-8; x-proto=protean.v1.Error",
+				inputTooLarge(16),
+			)
+
+			res = post(h, "NoSideEffects", largeInput)
+			Expect(res).To(HaveHTTPStatus(http.StatusOK))
+		})
+
+		It("applies a service-specific limit to all methods of that service", func() {
+			h := newHandler(
+				WithMaxRPCInputSize(16, "protean.test.TestService"),
+			)
+
+			res := post(h, "NoSideEffects", largeInput)
+			expectError(
+				res,
+				http.StatusRequestEntityTooLarge,
+				"text/plain; charset=utf-8; x-proto=protean.v1.Error",
+				inputTooLarge(16),
+			)
+		})
+
+		It("prefers a method-specific limit over a service-specific limit", func() {
+			h := newHandler(
+				WithMaxRPCInputSize(16, "protean.test.TestService"),
+				WithMaxRPCInputSize(4096, "protean.test.TestService/Unary"),
+			)
+
+			res := post(h, "Unary", largeInput)
+			Expect(res).To(HaveHTTPStatus(http.StatusOK))
+		})
+
+		It("applies the limit declared in the service definition", func() {
+			res := post(newHandler(), "ServerStream", largeInput)
+			expectError(
+				res,
+				http.StatusRequestEntityTooLarge,
+				"text/plain; charset=utf-8; x-proto=protean.v1.Error",
+				inputTooLarge(1024),
+			)
+		})
+
+		It("prefers a limit provided by a handler option over the service definition", func() {
+			h := newHandler(
+				WithMaxRPCInputSize(4096, "protean.test.TestService/ServerStream"),
+			)
+
+			res := post(h, "ServerStream", largeInput)
+			Expect(res).To(HaveHTTPStatus(http.StatusOK))
+		})
+
+		It("permits JSON-RPC requests up to the largest method-specific limit", func() {
+			h := newHandler(
+				WithJSONRPCEndpoint("/rpc"),
+				WithMaxRPCInputSize(16),
+				WithMaxRPCInputSize(4096, "protean.test.TestService/Unary"),
+			)
+
+			rpc := func(method string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(
+					http.MethodPost,
+					"/rpc",
+					strings.NewReader(
+						`{"jsonrpc":"2.0","id":1,"method":"protean.test.TestService/`+method+`","params":`+largeInput+`}`,
+					),
+				)
+				req.Header.Set("Content-Type", "application/json")
+
+				res := httptest.NewRecorder()
+				h.ServeHTTP(res, req)
+
+				return res
+			}
+
+			res := rpc("Unary")
+			Expect(res).To(HaveHTTPStatus(http.StatusOK))
+			Expect(res.Body.String()).To(ContainSubstring(`"result"`))
+
+			res = rpc("NoSideEffects")
+			Expect(res).To(HaveHTTPStatus(http.StatusOK))
+			Expect(res.Body.String()).To(ContainSubstring(`"error"`))
+		})
+
+		DescribeTable(
+			"it rejects oversized input messages with an error that is not retryable",
+			func(
+				path string,
+				header http.Header,
+				expectStatus int,
+				expectCode string,
+			) {
+				h := newHandler(
+					WithTwirpPrefix("/twirp"),
+					WithJSONRPCEndpoint("/rpc"),
+					WithMaxRPCInputSize(16, "protean.test.TestService/Unary"),
+				)
+
+				body := largeInput
+				if path == "/rpc" {
+					body = `{"jsonrpc":"2.0","id":1,"method":"protean.test.TestService/Unary","params":` + largeInput + `}`
+				}
+
+				req := httptest.NewRequest(
+					http.MethodPost,
+					path,
+					strings.NewReader(body),
+				)
+				req.Header.Set("Content-Type", "application/json")
+				for k, v := range header {
+					req.Header[k] = v
+				}
+
+				res := httptest.NewRecorder()
+				h.ServeHTTP(res, req)
+
+				Expect(res).To(HaveHTTPStatus(expectStatus))
+				Expect(res.Header().Get("Retry-After")).To(BeEmpty())
+				Expect(res.Body.String()).To(ContainSubstring(expectCode))
+				Expect(res.Body.String()).NotTo(ContainSubstring("resource_exhausted"))
+			},
+			Entry(
+				"native",
+				"/protean.test/TestService/Unary",
+				nil,
+				http.StatusRequestEntityTooLarge,
+				"the RPC input message length exceeds the maximum allowable size",
+			),
+			Entry(
+				"Connect",
+				"/protean.test.TestService/Unary",
+				http.Header{"Connect-Protocol-Version": {"1"}},
+				http.StatusBadRequest,
+				`"code":"invalid_argument"`,
+			),
+			Entry(
+				"Twirp",
+				"/twirp/protean.test.TestService/Unary",
+				nil,
+				http.StatusBadRequest,
+				`"code":"invalid_argument"`,
+			),
+			Entry(
+				"JSON-RPC",
+				"/rpc",
+				nil,
+				http.StatusOK,
+				`"code":-3`,
+			),
+		)
+
+		It("panics if the limit is not positive", func() {
+			Expect(func() {
+				WithMaxRPCInputSize(0, "protean.test.TestService")
+			}).To(PanicWith("maximum input size must be positive"))
+		})
+	})
+
+	Describe("func WithMaxRPCOutputSize()", func() {
+		It("does not limit the output size by default", func() {
+			res := post(newHandler(), "Unary", largeInput)
+			Expect(res).To(HaveHTTPStatus(http.StatusOK))
+		})
+
+		It("fails the call if the output message exceeds the limit", func() {
+			h := newHandler(
+				WithMaxRPCOutputSize(16),
+			)
+
+			res := post(h, "Unary", largeInput)
+			expectError(
+				res,
+				http.StatusInternalServerError,
+				"application/json; x-proto=protean.v1.Error",
+				outputTooLarge(16),
+			)
+		})
+
+		It("allows output messages within the limit", func() {
+			h := newHandler(
+				WithMaxRPCOutputSize(16),
+			)
+
+			res := post(h, "Unary", `{"data":"abc"}`)
+			Expect(res).To(HaveHTTPStatus(http.StatusOK))
+		})
+
+		It("applies a method-specific limit to that method only", func() {
+			h := newHandler(
+				WithMaxRPCOutputSize(16, "protean.test.TestService/NoSideEffects"),
+			)
+
+			res := post(h, "Unary", largeInput)
+			Expect(res).To(HaveHTTPStatus(http.StatusOK))
+		})
+
+		It("applies the limit declared in the service definition to each streamed message", func() {
+			service.ServerStreamFunc = func(
+				ctx context.Context,
+				_ *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				defer close(outputs)
+
+				for _, n := range []int{10, 2048, 10} {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case outputs <- &testservice.Output{Data: strings.Repeat("x", n)}:
+					}
+				}
+
+				return nil
+			}
+
+			res := post(newHandler(), "ServerStream", `{"data":"abc"}`)
+
+			var protoErr proteanpb.Error
+			err := rpcerror.ToProto(outputTooLarge(1024), &protoErr)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(res).To(HaveHTTPStatus(http.StatusOK))
+			Expect(res.Body.String()).To(Equal(
+				"data: " + marshalJSON(&testservice.Output{Data: strings.Repeat("x", 10)}) + "\n\n" +
+					"event: rpcerror\ndata: " + marshalJSON(&protoErr) + "\n\n",
+			))
+		})
+
+		It("panics if the limit is not positive", func() {
+			Expect(func() {
+				WithMaxRPCOutputSize(-1)
+			}).To(PanicWith("maximum output size must be positive"))
+		})
+	})
+})
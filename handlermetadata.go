@@ -0,0 +1,125 @@
+package protean
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+)
+
+// beginMetadata associates a new callMetadata with the request.
+//
+// It returns a response writer that sends the response headers and trailers
+// set by the RPC method, and a function that must be called once the request
+// has been served.
+func beginMetadata(
+	w http.ResponseWriter,
+	r *http.Request,
+) (http.ResponseWriter, *http.Request, func()) {
+	md := &callMetadata{
+		request: Metadata{
+			Header:     r.Header,
+			RemoteAddr: r.RemoteAddr,
+			TLS:        r.TLS,
+		},
+		url:     r.URL,
+		header:  http.Header{},
+		trailer: http.Header{},
+	}
+
+	mw := &metadataResponseWriter{
+		ResponseWriter: w,
+		md:             md,
+	}
+
+	ctx := context.WithValue(r.Context(), metadataKey{}, md)
+
+	return mw, r.WithContext(ctx), mw.writeTrailer
+}
+
+// metadataResponseWriter is an http.ResponseWriter that adds the response
+// headers set by the RPC method immediately before the headers are sent.
+type metadataResponseWriter struct {
+	http.ResponseWriter
+
+	md       *callMetadata
+	hijacked bool
+}
+
+// WriteHeader sends the response headers with the given status code.
+func (w *metadataResponseWriter) WriteHeader(code int) {
+	w.writeHeader()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes data to the response body, sending the response headers first
+// if they have not already been sent.
+func (w *metadataResponseWriter) Write(data []byte) (int, error) {
+	w.writeHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+// FlushError flushes buffered data to the client, sending the response headers
+// first if they have not already been sent.
+func (w *metadataResponseWriter) FlushError() error {
+	w.writeHeader()
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack lets the caller take over the connection.
+func (w *metadataResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.writeHeader()
+
+	w.md.m.Lock()
+	w.hijacked = true
+	w.md.m.Unlock()
+
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying response writer, for use by
+// http.ResponseController.
+func (w *metadataResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// writeHeader adds the headers set by the RPC method to the response headers,
+// if they have not already been sent.
+//
+// Headers that are already present, having been set by the handler itself,
+// are not overridden.
+func (w *metadataResponseWriter) writeHeader() {
+	w.md.m.Lock()
+	defer w.md.m.Unlock()
+
+	if w.md.sent {
+		return
+	}
+	w.md.sent = true
+
+	dst := w.ResponseWriter.Header()
+
+	for k, values := range w.md.header {
+		if _, ok := dst[k]; ok {
+			continue
+		}
+
+		dst[k] = values
+	}
+}
+
+// writeTrailer adds the trailers set by the RPC method to the response.
+func (w *metadataResponseWriter) writeTrailer() {
+	w.md.m.Lock()
+	defer w.md.m.Unlock()
+
+	if w.hijacked || !w.md.sent {
+		return
+	}
+
+	dst := w.ResponseWriter.Header()
+
+	for k, values := range w.md.trailer {
+		dst[http.TrailerPrefix+k] = values
+	}
+}
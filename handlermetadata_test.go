@@ -0,0 +1,198 @@
+package protean_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/rpcerror"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("type Handler (metadata)", func() {
+	var (
+		handler Handler
+		service *testservice.Stub
+		request *http.Request
+	)
+
+	BeforeEach(func() {
+		handler = NewHandler()
+		service = &testservice.Stub{}
+		testservice.RegisterProteanTestService(handler, service)
+
+		request = httptest.NewRequest(
+			http.MethodPost,
+			"/protean.test/TestService/Unary",
+			strings.NewReader(`{"data":"<input>"}`),
+		)
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-Custom", "<value>")
+		request.AddCookie(&http.Cookie{Name: "session", Value: "<session>"})
+		request.RemoteAddr = "192.0.2.1:1234"
+	})
+
+	Describe("func RequestMetadata()", func() {
+		It("provides information about the HTTP request", func() {
+			var (
+				md Metadata
+				ok bool
+			)
+
+			service.UnaryFunc = func(
+				ctx context.Context,
+				_ *testservice.Input,
+			) (*testservice.Output, error) {
+				md, ok = RequestMetadata(ctx)
+				return &testservice.Output{Data: "<output>"}, nil
+			}
+
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, request)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(ok).To(BeTrue())
+			Expect(md.Header.Get("X-Custom")).To(Equal("<value>"))
+			Expect(md.RemoteAddr).To(Equal("192.0.2.1:1234"))
+			Expect(md.ClientIP().String()).To(Equal("192.0.2.1"))
+			Expect(md.TLS).To(BeNil())
+
+			c, err := md.Cookie("session")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(c.Value).To(Equal("<session>"))
+		})
+
+		It("returns false if the context is not associated with an RPC call", func() {
+			_, ok := RequestMetadata(context.Background())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("func SetResponseHeader()", func() {
+		It("adds headers and cookies to the response", func() {
+			service.UnaryFunc = func(
+				ctx context.Context,
+				_ *testservice.Input,
+			) (*testservice.Output, error) {
+				Expect(SetResponseHeader(ctx, "X-Custom", "<value>")).To(Succeed())
+				Expect(AddResponseHeader(ctx, "X-Multi", "<one>")).To(Succeed())
+				Expect(AddResponseHeader(ctx, "X-Multi", "<two>")).To(Succeed())
+				Expect(SetResponseCookie(ctx, &http.Cookie{Name: "session", Value: "<session>"})).To(Succeed())
+				return &testservice.Output{Data: "<output>"}, nil
+			}
+
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, request)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response).To(HaveHTTPHeaderWithValue("X-Custom", "<value>"))
+			Expect(response.Header().Values("X-Multi")).To(Equal([]string{"<one>", "<two>"}))
+			Expect(response).To(HaveHTTPHeaderWithValue("Set-Cookie", "session=<session>"))
+		})
+
+		It("adds headers to error responses", func() {
+			service.UnaryFunc = func(
+				ctx context.Context,
+				_ *testservice.Input,
+			) (*testservice.Output, error) {
+				Expect(SetResponseHeader(ctx, "WWW-Authenticate", "Bearer")).To(Succeed())
+				return nil, rpcerror.New(rpcerror.Unauthenticated, "<error>")
+			}
+
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, request)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusUnauthorized))
+			Expect(response).To(HaveHTTPHeaderWithValue("WWW-Authenticate", "Bearer"))
+		})
+
+		It("does not override headers set by the handler", func() {
+			service.UnaryFunc = func(
+				ctx context.Context,
+				_ *testservice.Input,
+			) (*testservice.Output, error) {
+				Expect(SetResponseHeader(ctx, "Content-Type", "text/html")).To(Succeed())
+				return &testservice.Output{Data: "<output>"}, nil
+			}
+
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, request)
+
+			Expect(response).To(HaveHTTPHeaderWithValue("Content-Type", "application/json; x-proto=protean.test.Output"))
+		})
+
+		It("returns an error if the headers have already been sent", func() {
+			var err error
+
+			service.ServerStreamFunc = func(
+				ctx context.Context,
+				_ *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				err = SetResponseHeader(ctx, "X-Custom", "<value>")
+				close(outputs)
+				return nil
+			}
+
+			request.URL.Path = "/protean.test/TestService/ServerStream"
+			request.Header.Set("Accept", "text/event-stream")
+
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, request)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(err).To(Equal(ErrHeaderSent))
+			Expect(response.Header().Get("X-Custom")).To(BeEmpty())
+		})
+
+		It("returns an error if the context is not associated with an RPC call", func() {
+			err := SetResponseHeader(context.Background(), "X-Custom", "<value>")
+			Expect(err).To(Equal(ErrNoMetadata))
+		})
+	})
+
+	Describe("func SetResponseTrailer()", func() {
+		It("adds trailers to streaming responses", func() {
+			service.ServerStreamFunc = func(
+				ctx context.Context,
+				_ *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				Expect(SetResponseTrailer(ctx, "X-Custom", "<value>")).To(Succeed())
+				close(outputs)
+				return nil
+			}
+
+			server := httptest.NewServer(handler)
+			defer server.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(
+				ctx,
+				http.MethodPost,
+				server.URL+"/protean.test/TestService/ServerStream",
+				strings.NewReader(`{"data":"<input>"}`),
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "text/event-stream")
+
+			res, err := http.DefaultClient.Do(req)
+			Expect(err).ShouldNot(HaveOccurred())
+			defer res.Body.Close()
+
+			_, err = io.ReadAll(res.Body)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(res.Trailer.Get("X-Custom")).To(Equal("<value>"))
+		})
+	})
+})
@@ -0,0 +1,173 @@
+package protean
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/rpcerror"
+)
+
+// stripPathPrefix returns a copy of r with the handler's path prefix removed
+// from the URL path.
+//
+// It returns false if the URL path does not begin with the prefix, in which
+// case a 404 Not Found error has already been written to w.
+func (h *handler) stripPathPrefix(
+	w http.ResponseWriter,
+	r *http.Request,
+) (*http.Request, bool) {
+	if h.pathPrefix == "" {
+		return r, true
+	}
+
+	p := strings.TrimPrefix(r.URL.Path, h.pathPrefix)
+	rp := strings.TrimPrefix(r.URL.RawPath, h.pathPrefix)
+
+	if len(p) == len(r.URL.Path) ||
+		!strings.HasPrefix(p, "/") ||
+		(r.URL.RawPath != "" && len(rp) == len(r.URL.RawPath)) {
+		httpError(
+			w,
+			http.StatusNotFound,
+			protomime.TextMediaTypes[0],
+			protomime.TextMarshaler,
+			rpcerror.New(
+				rpcerror.NotImplemented,
+				"the request URI must begin with '%s'",
+				h.pathPrefix,
+			),
+		)
+		return nil, false
+	}
+
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = p
+	r2.URL.RawPath = rp
+
+	return r2, true
+}
+
+// RegisterRoutes registers the handler with mux, using a separate pattern for
+// each URL path and HTTP method that the handler serves.
+func (h *handler) RegisterRoutes(mux *http.ServeMux) {
+	for _, p := range h.routePatterns() {
+		mux.Handle(p, h)
+	}
+}
+
+// routePatterns returns the http.ServeMux patterns that match the requests
+// that the handler serves, in a deterministic order.
+func (h *handler) routePatterns() []string {
+	patterns := map[string]struct{}{}
+
+	add := func(method, path string) {
+		patterns[method+" "+h.pathPrefix+path] = struct{}{}
+	}
+
+	// addMethodPaths adds the patterns that may be used to reach a specific
+	// RPC method at the given path.
+	addMethodPaths := func(path string, get bool) {
+		add(http.MethodPost, path)
+
+		if get {
+			add(http.MethodGet, path)
+		}
+
+		if len(h.corsPolicies) != 0 {
+			add(http.MethodOptions, path)
+		}
+	}
+
+	for name, s := range h.services {
+		pkg := s.Package()
+
+		methods := s.Descriptor().Methods()
+
+		for i := 0; i < methods.Len(); i++ {
+			m, _ := s.MethodByName(string(methods.Get(i).Name()))
+
+			// The "/<package>/<service>/<method>" path is used by the POST,
+			// GET, websocket, server-sent events and framed transports.
+			// Websockets can be used to call any method, hence GET is always
+			// registered.
+			addMethodPaths("/"+pkg+"/"+s.Name()+"/"+m.Name(), true)
+
+			// The "/<package>.<service>/<method>" path is used by gRPC,
+			// gRPC-Web and Connect. Only Connect uses GET requests.
+			addMethodPaths("/"+name+"/"+m.Name(), !m.InputIsStream() && !m.OutputIsStream())
+
+			if h.twirpPrefix != "" && !m.InputIsStream() && !m.OutputIsStream() {
+				addMethodPaths(h.twirpPrefix+"/"+name+"/"+m.Name(), false)
+			}
+		}
+	}
+
+	for _, route := range h.restRoutes {
+		add(route.httpMethod, route.template.servePattern())
+
+		if len(h.corsPolicies) != 0 {
+			add(http.MethodOptions, route.template.servePattern())
+		}
+	}
+
+	if h.jsonRPCPath != "" {
+		addMethodPaths(h.jsonRPCPath, false)
+	}
+
+	if h.discovery {
+		add(http.MethodGet, DiscoveryPath)
+	}
+
+	if h.openAPIInfo != nil {
+		add(http.MethodGet, OpenAPIPath)
+	}
+
+	result := make([]string, 0, len(patterns))
+	for p := range patterns {
+		result = append(result, p)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// servePattern returns the path portion of an http.ServeMux pattern that
+// matches the same URL paths as t.
+//
+// Variables are replaced with wildcards. If part of the template can not be
+// represented exactly, such as a wildcard followed by a verb, the pattern
+// matches any path that begins with the preceding literal segments. The
+// handler performs the exact match itself.
+func (t pathTemplate) servePattern() string {
+	var b strings.Builder
+
+	for i, seg := range t.segments {
+		b.WriteByte('/')
+
+		last := i == len(t.segments)-1
+		wildcard := "{p" + strconv.Itoa(i)
+
+		switch {
+		case seg.multi || (last && seg.literal == "" && t.verb != ""):
+			b.WriteString(wildcard + "...}")
+			return b.String()
+		case seg.literal == "":
+			b.WriteString(wildcard + "}")
+		default:
+			b.WriteString(seg.literal)
+		}
+	}
+
+	if t.verb != "" {
+		b.WriteString(":" + t.verb)
+	}
+
+	return b.String()
+}
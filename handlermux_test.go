@@ -0,0 +1,156 @@
+package protean_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/rpcerror"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("type Handler (mounting)", func() {
+	var (
+		handler Handler
+		service *testservice.Stub
+	)
+
+	BeforeEach(func() {
+		service = &testservice.Stub{
+			UnaryFunc: func(
+				_ context.Context,
+				in *testservice.Input,
+			) (*testservice.Output, error) {
+				return &testservice.Output{Data: in.GetId() + in.GetData()}, nil
+			},
+			NoSideEffectsFunc: func(
+				_ context.Context,
+				in *testservice.Input,
+			) (*testservice.Output, error) {
+				return &testservice.Output{Data: in.GetId()}, nil
+			},
+		}
+
+		handler = NewHandler(WithPathPrefix("/api/rpc/"))
+		testservice.RegisterProteanTestService(handler, service)
+	})
+
+	// serve sends a request with a JSON body to h.
+	serve := func(h http.Handler, method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(
+			method,
+			path,
+			strings.NewReader(`{"data":"<input>"}`),
+		)
+		req.Header.Set("Content-Type", "application/json")
+
+		res := httptest.NewRecorder()
+		h.ServeHTTP(res, req)
+
+		return res
+	}
+
+	Describe("func WithPathPrefix()", func() {
+		It("serves RPC methods beneath the prefix", func() {
+			res := serve(handler, http.MethodPost, "/api/rpc/protean.test/TestService/Unary")
+			Expect(res).To(HaveHTTPStatus(http.StatusOK))
+			Expect(res.Body.String()).To(MatchJSON(`{"data":"<input>"}`))
+		})
+
+		It("serves REST-style requests beneath the prefix", func() {
+			res := serve(handler, http.MethodPost, "/api/rpc/v1/items/123")
+			Expect(res).To(HaveHTTPStatus(http.StatusOK))
+			Expect(res.Body.String()).To(MatchJSON(`{"data":"123<input>"}`))
+		})
+
+		DescribeTable(
+			"it responds with an HTTP '404 Not Found' status if the path does not begin with the prefix",
+			func(path string) {
+				res := serve(handler, http.MethodPost, path)
+				expectError(
+					res,
+					http.StatusNotFound,
+					"text/plain; charset=utf-8; x-proto=protean.v1.Error",
+					rpcerror.New(
+						rpcerror.NotImplemented,
+						"the request URI must begin with '/api/rpc'",
+					),
+				)
+			},
+			Entry("no prefix", "/protean.test/TestService/Unary"),
+			Entry("partial segment", "/api/rpcx/protean.test/TestService/Unary"),
+			Entry("prefix only", "/api/rpc"),
+		)
+
+		It("panics if the prefix does not begin with a slash", func() {
+			Expect(func() {
+				WithPathPrefix("api")
+			}).To(PanicWith("path prefix must begin with a slash"))
+		})
+	})
+
+	Describe("func RegisterRoutes()", func() {
+		var mux *http.ServeMux
+
+		BeforeEach(func() {
+			mux = http.NewServeMux()
+			mux.HandleFunc("GET /other", func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			})
+
+			handler.RegisterRoutes(mux)
+		})
+
+		It("routes RPC requests to the handler", func() {
+			res := serve(mux, http.MethodPost, "/api/rpc/protean.test/TestService/Unary")
+			Expect(res).To(HaveHTTPStatus(http.StatusOK))
+		})
+
+		It("routes REST-style requests to the handler", func() {
+			res := serve(mux, http.MethodGet, "/api/rpc/v1/folders/a/b:lookup?data=x")
+			Expect(res).To(HaveHTTPStatus(http.StatusOK))
+			Expect(res.Body.String()).To(MatchJSON(`{"data":"folders/a/b"}`))
+		})
+
+		It("routes other requests to other handlers", func() {
+			res := serve(mux, http.MethodGet, "/other")
+			Expect(res).To(HaveHTTPStatus(http.StatusTeapot))
+		})
+
+		It("allows the mux to reject unknown paths", func() {
+			res := serve(mux, http.MethodPost, "/api/rpc/protean.test/TestService/Unknown")
+			Expect(res).To(HaveHTTPStatus(http.StatusNotFound))
+			Expect(res.Body.String()).To(Equal("404 page not found\n"))
+		})
+
+		It("allows the mux to reject unsupported HTTP methods", func() {
+			res := serve(mux, http.MethodPut, "/api/rpc/protean.test/TestService/Unary")
+			Expect(res).To(HaveHTTPStatus(http.StatusMethodNotAllowed))
+			Expect(res.Header().Get("Allow")).To(Equal("GET, HEAD, POST"))
+		})
+
+		It("allows the generated client to call RPC methods via the mux", func() {
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			baseURL, err := url.Parse(server.URL + "/api/rpc/")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			client := testservice.NewProteanTestServiceClient(baseURL)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+
+			out, err := client.Unary(ctx, &testservice.Input{Data: "<input>"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(out.GetData()).To(Equal("<input>"))
+		})
+	})
+})
@@ -0,0 +1,70 @@
+package protean
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/dogmatiq/protean/internal/openapi"
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/rpcerror"
+	"github.com/dogmatiq/protean/runtime"
+)
+
+// OpenAPIPath is the URL path of the OpenAPI document, which is enabled by the
+// WithOpenAPIEndpoint() option.
+const OpenAPIPath = "/.well-known/protean/openapi.json"
+
+// serveOpenAPI serves a request for the OpenAPI document that describes the
+// registered services.
+func (h *handler) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		httpError(
+			w,
+			http.StatusMethodNotAllowed,
+			protomime.JSONMediaTypes[0],
+			protomime.JSONMarshaler,
+			rpcerror.New(
+				rpcerror.NotImplemented,
+				"the HTTP method must be GET",
+			),
+		)
+		return
+	}
+
+	var names []string
+	for n := range h.services {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var services []runtime.Service
+	for _, n := range names {
+		services = append(services, h.services[n])
+	}
+
+	doc := openapi.Build(*h.openAPIInfo, services)
+
+	// The paths in the document are relative to the handler's path prefix, if
+	// any.
+	if h.pathPrefix != "" {
+		doc.Servers = []openapi.Server{{URL: h.pathPrefix}}
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		// CODE COVERAGE: This condition can not be reproduced, as the document
+		// only contains values that can be marshaled to JSON.
+		panic(err)
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", "application/json")
+	data = h.compressResponseBody(w, r, data)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
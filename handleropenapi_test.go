@@ -0,0 +1,149 @@
+package protean_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/testservice"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("type Handler (OpenAPI)", func() {
+	var (
+		handler  Handler
+		response *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		handler = NewHandler(WithOpenAPIEndpoint("<title>", "1.2.3"))
+		testservice.RegisterProteanTestService(handler, &testservice.Stub{})
+
+		response = httptest.NewRecorder()
+	})
+
+	Describe("func ServeHTTP()", func() {
+		It("serves an OpenAPI document that describes the unary methods", func() {
+			req := httptest.NewRequest(http.MethodGet, OpenAPIPath, nil)
+			handler.ServeHTTP(response, req)
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header().Get("Content-Type")).To(Equal("application/json"))
+
+			var doc struct {
+				OpenAPI    string                     `json:"openapi"`
+				Info       json.RawMessage            `json:"info"`
+				Paths      map[string]json.RawMessage `json:"paths"`
+				Components struct {
+					Schemas   map[string]json.RawMessage `json:"schemas"`
+					Responses map[string]json.RawMessage `json:"responses"`
+				} `json:"components"`
+			}
+			err := json.Unmarshal(response.Body.Bytes(), &doc)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(doc.OpenAPI).To(Equal("3.1.0"))
+			Expect(doc.Info).To(MatchJSON(`{"title":"<title>","version":"1.2.3"}`))
+
+			Expect(doc.Paths).To(HaveLen(2))
+			Expect(doc.Paths).To(HaveKey("/protean.test/TestService/NoSideEffects"))
+
+			responseContent := `{
+				"application/vnd.google.protobuf": {"schema": {"$ref": "#/components/schemas/protean.test.Output"}},
+				"application/x-protobuf": {"schema": {"$ref": "#/components/schemas/protean.test.Output"}},
+				"application/json": {"schema": {"$ref": "#/components/schemas/protean.test.Output"}},
+				"text/plain": {"schema": {"$ref": "#/components/schemas/protean.test.Output"}}
+			}`
+
+			var path struct {
+				Post struct {
+					OperationID string                     `json:"operationId"`
+					RequestBody json.RawMessage            `json:"requestBody"`
+					Responses   map[string]json.RawMessage `json:"responses"`
+				} `json:"post"`
+			}
+			err = json.Unmarshal(doc.Paths["/protean.test/TestService/Unary"], &path)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(path.Post.OperationID).To(Equal("protean.test.TestService.Unary"))
+			Expect(path.Post.RequestBody).To(MatchJSON(`{
+				"required": true,
+				"content": {
+					"application/vnd.google.protobuf": {"schema": {"$ref": "#/components/schemas/protean.test.Input"}},
+					"application/x-protobuf": {"schema": {"$ref": "#/components/schemas/protean.test.Input"}},
+					"application/json": {"schema": {"$ref": "#/components/schemas/protean.test.Input"}},
+					"text/plain": {"schema": {"$ref": "#/components/schemas/protean.test.Input"}}
+				}
+			}`))
+			Expect(path.Post.Responses["200"]).To(MatchJSON(`{
+				"description": "The RPC method completed successfully.",
+				"content": ` + responseContent + `
+			}`))
+			Expect(path.Post.Responses["404"]).To(MatchJSON(`{"$ref": "#/components/responses/Error404"}`))
+			Expect(path.Post.Responses["504"]).To(MatchJSON(`{"$ref": "#/components/responses/Error504"}`))
+
+			Expect(doc.Components.Schemas["protean.test.Input"]).To(MatchJSON(`{
+				"type": "object",
+				"properties": {
+					"id": {"type": "string"},
+					"data": {"type": "string"}
+				}
+			}`))
+			Expect(doc.Components.Schemas["protean.v1.Error"]).To(MatchJSON(`{
+				"type": "object",
+				"properties": {
+					"code": {"type": "integer", "format": "int32"},
+					"message": {"type": "string"},
+					"data": {"type": "object", "properties": {"@type": {"type": "string"}}}
+				}
+			}`))
+			Expect(doc.Components.Responses).To(HaveKey("Error404"))
+			Expect(doc.Components.Responses["Error504"]).To(MatchJSON(`{
+				"description": "The RPC call did not complete before its deadline.",
+				"content": {
+					"application/vnd.google.protobuf": {"schema": {"$ref": "#/components/schemas/protean.v1.Error"}},
+					"application/x-protobuf": {"schema": {"$ref": "#/components/schemas/protean.v1.Error"}},
+					"application/json": {"schema": {"$ref": "#/components/schemas/protean.v1.Error"}},
+					"text/plain": {"schema": {"$ref": "#/components/schemas/protean.v1.Error"}}
+				}
+			}`))
+		})
+
+		It("lists the scopes required by each method", func() {
+			req := httptest.NewRequest(http.MethodGet, OpenAPIPath, nil)
+			handler.ServeHTTP(response, req)
+
+			var doc struct {
+				Paths map[string]struct {
+					Post map[string]json.RawMessage `json:"post"`
+				} `json:"paths"`
+			}
+			err := json.Unmarshal(response.Body.Bytes(), &doc)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(doc.Paths["/protean.test/TestService/NoSideEffects"].Post["x-protean-required-scopes"]).
+				To(MatchJSON(`["test:read", "test:list"]`))
+			Expect(doc.Paths["/protean.test/TestService/Unary"].Post).
+				NotTo(HaveKey("x-protean-required-scopes"))
+		})
+
+		It("responds with a '405 Method Not Allowed' status if the HTTP method is not GET", func() {
+			req := httptest.NewRequest(http.MethodPost, OpenAPIPath, nil)
+			handler.ServeHTTP(response, req)
+
+			Expect(response.Code).To(Equal(http.StatusMethodNotAllowed))
+			Expect(response.Header().Get("Allow")).To(Equal("GET"))
+		})
+
+		It("does not serve the OpenAPI document if WithOpenAPIEndpoint() is not used", func() {
+			handler = NewHandler()
+
+			req := httptest.NewRequest(http.MethodGet, OpenAPIPath, nil)
+			handler.ServeHTTP(response, req)
+
+			Expect(response.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+})
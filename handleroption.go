@@ -1,5 +1,14 @@
 package protean
 
+import (
+	"strings"
+	"time"
+
+	"github.com/dogmatiq/protean/compression"
+	"github.com/dogmatiq/protean/internal/openapi"
+	"github.com/dogmatiq/protean/middleware"
+)
+
 const (
 	// DefaultMaxRPCInputSize is the default maximum size for RPC input
 	// messages.
@@ -15,13 +24,354 @@ type HandlerOption func(*handler)
 // WithMaxRPCInputSize is a HandlerOption that sets the maximum size of RPC
 // input messages that the handler will accept, in bytes.
 //
-// If this option is not provided, DefaultMaxRPCInputSize is used.
-func WithMaxRPCInputSize(n int) HandlerOption {
+// targets restricts the limit to specific services or methods. Each target is
+// either a fully-qualified service name, in the form "<package>.<service>", or
+// a fully-qualified method name, in the form "<package>.<service>/<method>". A
+// method-specific limit takes precedence over a service-specific limit, which
+// in turn takes precedence over a limit declared in the service definition
+// using the "protean.max_input_size" or "protean.service_max_input_size"
+// options.
+//
+// If no targets are given the limit applies to all methods that do not have a
+// more specific limit. If this option is not provided, DefaultMaxRPCInputSize
+// is used.
+//
+// Input messages that exceed the limit are rejected with an
+// rpcerror.InvalidInput error that has an rpcerror.InputSizeLimitExceeded
+// details value.
+func WithMaxRPCInputSize(n int, targets ...string) HandlerOption {
 	if n <= 0 {
-		panic("maximum input size must be postive")
+		panic("maximum input size must be positive")
+	}
+
+	return func(h *handler) {
+		if len(targets) == 0 {
+			h.maxInputSize = n
+			return
+		}
+
+		if h.maxInputSizes == nil {
+			h.maxInputSizes = map[string]int{}
+		}
+
+		for _, t := range targets {
+			h.maxInputSizes[t] = n
+		}
+	}
+}
+
+// WithMaxRPCOutputSize is a HandlerOption that sets the maximum size of RPC
+// output messages that the handler will send, in bytes.
+//
+// The size of each output message is measured using the Protocol Buffers
+// binary format, regardless of the media-type used to send it. For streaming
+// methods the limit applies to each output message individually.
+//
+// targets restricts the limit to specific services or methods, with the same
+// semantics as WithMaxRPCInputSize(). Limits may also be declared in the
+// service definition using the "protean.max_output_size" or
+// "protean.service_max_output_size" options. By default, the size of output
+// messages is not limited.
+//
+// If the RPC method produces an output message that exceeds the limit, the
+// call is canceled and fails with an rpcerror.Unknown error that has an
+// rpcerror.OutputSizeLimitExceeded details value.
+func WithMaxRPCOutputSize(n int, targets ...string) HandlerOption {
+	if n <= 0 {
+		panic("maximum output size must be positive")
+	}
+
+	return func(h *handler) {
+		if len(targets) == 0 {
+			h.maxOutputSize = n
+			return
+		}
+
+		if h.maxOutputSizes == nil {
+			h.maxOutputSizes = map[string]int{}
+		}
+
+		for _, t := range targets {
+			h.maxOutputSizes[t] = n
+		}
+	}
+}
+
+// WithMaxTimeout is a HandlerOption that sets the maximum amount of time that
+// the handler allows for each RPC call.
+//
+// Clients may request a shorter timeout using the Protean-Timeout request
+// header, or the equivalent headers used by the gRPC and Connect protocols.
+// Timeouts requested by the client that are longer than d are reduced to d. The
+// limit also applies to calls for which the client does not request a timeout,
+// including streaming calls.
+//
+// Calls that do not complete in time fail with an rpcerror.DeadlineExceeded
+// error. By default, the handler does not limit the duration of RPC calls.
+func WithMaxTimeout(d time.Duration) HandlerOption {
+	if d <= 0 {
+		panic("maximum timeout must be positive")
+	}
+
+	return func(h *handler) {
+		h.maxTimeout = d
+	}
+}
+
+// WithServerInterceptor is a HandlerOption that adds an interceptor that is
+// invoked for each call to a unary RPC method.
+//
+// Interceptors are invoked in the order that they are added. The
+// middleware.Validator interceptor is always installed, and is invoked after
+// all other interceptors.
+func WithServerInterceptor(i middleware.ServerInterceptor) HandlerOption {
+	return func(h *handler) {
+		h.interceptors = append(h.interceptors, i)
+	}
+}
+
+// WithStreamServerInterceptor is a HandlerOption that adds an interceptor that
+// is invoked for each call to a client, server or bidirectional streaming RPC
+// method.
+//
+// Interceptors are invoked in the order that they are added. The
+// middleware.Validator interceptor is always installed, and is invoked after
+// all other interceptors.
+func WithStreamServerInterceptor(i middleware.StreamServerInterceptor) HandlerOption {
+	return func(h *handler) {
+		h.streamInterceptors = append(h.streamInterceptors, i)
+	}
+}
+
+// WithGETEnabled is a HandlerOption that allows the given unary and server
+// streaming RPC methods to be called using the HTTP GET method. Server
+// streaming methods may be called using GET via server-sent events.
+//
+// Each method is identified by its fully-qualified name, in the form
+// "<package>.<service>/<method>".
+//
+// By default, only those methods declared as having no side effects, by setting
+// the "idempotency_level" option to NO_SIDE_EFFECTS, may be called using GET.
+// This option should only be used for methods that are safe to call without
+// the user's intent, as browsers, crawlers and caches may make GET requests
+// freely.
+func WithGETEnabled(methods ...string) HandlerOption {
+	return func(h *handler) {
+		if h.getMethods == nil {
+			h.getMethods = map[string]bool{}
+		}
+
+		for _, m := range methods {
+			h.getMethods[m] = true
+		}
+	}
+}
+
+// WithJSONRPCEndpoint is a HandlerOption that enables a JSON-RPC 2.0 endpoint
+// at the given URL path, such as "/" or "/rpc".
+//
+// Clients may call any unary RPC method by making a POST request to the
+// endpoint. The JSON-RPC "method" is the fully-qualified name of the RPC
+// method, in the form "<package>.<service>/<method>", and the "params" are the
+// JSON representation of the RPC input message. Notifications and batch
+// requests are supported.
+//
+// The JSON-RPC endpoint is disabled by default.
+func WithJSONRPCEndpoint(path string) HandlerOption {
+	if !strings.HasPrefix(path, "/") {
+		panic("JSON-RPC endpoint path must begin with a slash")
+	}
+
+	return func(h *handler) {
+		h.jsonRPCPath = path
+	}
+}
+
+// WithPathPrefix is a HandlerOption that mounts the handler at the given URL
+// path prefix, such as "/api/rpc".
+//
+// The prefix is removed from the URL path before the request is routed, such
+// that RPC methods are served at "<prefix>/<package>/<service>/<method>". It
+// applies to every URL path served by the handler, including those used by
+// other transports, REST-style requests and the discovery endpoints. Requests
+// for URL paths that do not begin with the prefix are rejected with a "404 Not
+// Found" status.
+//
+// Clients must include the prefix in their base URL. Note that gRPC clients
+// typically do not support path prefixes.
+func WithPathPrefix(prefix string) HandlerOption {
+	if !strings.HasPrefix(prefix, "/") {
+		panic("path prefix must begin with a slash")
+	}
+
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	return func(h *handler) {
+		h.pathPrefix = prefix
+	}
+}
+
+// WithTwirpPrefix is a HandlerOption that allows unary RPC methods to be called
+// by Twirp clients, using URL paths that begin with the given prefix, such as
+// "/twirp".
+//
+// Twirp requests use the "<prefix>/<package>.<service>/<method>" URL path
+// pattern and the "application/protobuf" or "application/json" media-types.
+// Errors are reported using Twirp's JSON error format, allowing existing Twirp
+// clients to call services that have been migrated to Protean.
+//
+// Unlike Twirp itself, an empty prefix is not supported, as it would conflict
+// with the handler's own URL path pattern. Twirp routing is disabled by
+// default.
+func WithTwirpPrefix(prefix string) HandlerOption {
+	if !strings.HasPrefix(prefix, "/") {
+		panic("Twirp path prefix must begin with a slash")
+	}
+
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		panic("Twirp path prefix must not be empty")
+	}
+
+	return func(h *handler) {
+		h.twirpPrefix = prefix
+	}
+}
+
+// WithDiscoveryEndpoint is a HandlerOption that enables the discovery
+// endpoint, which is served at DiscoveryPath.
+//
+// The discovery endpoint lists the registered services and their RPC methods,
+// and optionally provides the Protocol Buffers descriptors that define them,
+// allowing tools to call the services without access to the .proto files.
+//
+// The discovery endpoint is disabled by default, as it reveals the handler's
+// entire API surface.
+func WithDiscoveryEndpoint() HandlerOption {
+	return func(h *handler) {
+		h.discovery = true
+	}
+}
+
+// WithOpenAPIEndpoint is a HandlerOption that enables an endpoint that serves
+// an OpenAPI 3.1 document at OpenAPIPath.
+//
+// The document describes the URL path of each unary RPC method of the
+// registered services, the schemas of their input and output messages, the
+// supported media-types, and the protean.v1.Error message returned for each
+// HTTP error status. The title and version are used as the document's "info"
+// metadata.
+//
+// The OpenAPI endpoint is disabled by default.
+func WithOpenAPIEndpoint(title, version string) HandlerOption {
+	return func(h *handler) {
+		h.openAPIInfo = &openapi.Info{
+			Title:   title,
+			Version: version,
+		}
+	}
+}
+
+// WithCORS is a HandlerOption that permits cross-origin requests from web
+// browsers, as per the given policy.
+//
+// targets restricts the policy to specific services or methods. Each target is
+// either a fully-qualified service name, in the form "<package>.<service>", or
+// a fully-qualified method name, in the form "<package>.<service>/<method>". If
+// no targets are given the policy applies to all methods that are not covered
+// by a more specific policy. A method-specific policy takes precedence over a
+// service-specific policy; policies are not merged.
+//
+// By default, cross-origin requests are not permitted.
+func WithCORS(p CORSPolicy, targets ...string) HandlerOption {
+	if len(targets) == 0 {
+		targets = []string{""}
+	}
+
+	return func(h *handler) {
+		if h.corsPolicies == nil {
+			h.corsPolicies = map[string]*CORSPolicy{}
+		}
+
+		for _, t := range targets {
+			h.corsPolicies[t] = &p
+		}
+	}
+}
+
+// WithAuthentication is a HandlerOption that requires RPC calls to be
+// authenticated, as per the given policy.
+//
+// Credentials are passed to the policy's Verify function. The principal that
+// it returns is made available to interceptors and RPC methods via
+// middleware.PrincipalFromContext(). Calls that are not authenticated fail with
+// an rpcerror.Unauthenticated error. Calls are authenticated before the
+// response is started, such that streaming calls and websocket connections are
+// rejected with a "401 Unauthorized" status.
+//
+// targets restricts the policy to specific services or methods, in the same
+// manner as WithCORS(). By default, calls do not require authentication.
+//
+// It panics if p.Verify is nil, or if p does not enable any credentials.
+func WithAuthentication(p AuthenticationPolicy, targets ...string) HandlerOption {
+	if p.Verify == nil {
+		panic("authentication policy must have a Verify function")
+	}
+
+	if !p.Bearer && !p.Basic && p.APIKeyHeader == "" && p.APIKeyParameter == "" {
+		panic("authentication policy must enable at least one type of credentials")
+	}
+
+	if len(targets) == 0 {
+		targets = []string{""}
+	}
+
+	return func(h *handler) {
+		if h.authPolicies == nil {
+			h.authPolicies = map[string]*AuthenticationPolicy{}
+		}
+
+		for _, t := range targets {
+			h.authPolicies[t] = &p
+		}
+	}
+}
+
+// WithCompressionAlgorithms is a HandlerOption that sets the compression
+// algorithms that the handler supports, in order of preference.
+//
+// The handler decompresses request bodies that use any of the algorithms, as
+// indicated by the Content-Encoding header, and compresses response bodies
+// using the most preferable algorithm that the client accepts, as indicated
+// by the Accept-Encoding header. Custom algorithms may be supported by
+// implementing the compression.Algorithm interface.
+//
+// If no algorithms are given, compression is disabled. If this option is not
+// provided, compression.Gzip and compression.Deflate are supported.
+func WithCompressionAlgorithms(algorithms ...compression.Algorithm) HandlerOption {
+	for _, a := range algorithms {
+		if isIdentityEncoding(a.Name()) {
+			panic("compression algorithm must have a non-identity name")
+		}
+	}
+
+	algorithms = append([]compression.Algorithm(nil), algorithms...)
+
+	return func(h *handler) {
+		h.compressionAlgorithms = algorithms
+	}
+}
+
+// WithCompressionThreshold is a HandlerOption that sets the minimum size of a
+// response body, in bytes, before it is compressed.
+//
+// If this option is not provided, compression.DefaultThreshold is used.
+func WithCompressionThreshold(n int) HandlerOption {
+	if n < 0 {
+		panic("compression threshold must not be negative")
 	}
 
 	return func(h *handler) {
-		h.maxInputSize = n
+		h.compressionThreshold = n
 	}
 }
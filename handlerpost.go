@@ -1,7 +1,9 @@
 package protean
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"mime"
 	"net/http"
@@ -22,7 +24,9 @@ func (h *handler) servePOST(
 	r *http.Request,
 	method runtime.Method,
 ) {
-	contentLength, ok := h.parseContentLength(w, r)
+	limit := h.maxInputSizeFor(method)
+
+	contentLength, ok := h.parseContentLength(w, r, limit)
 	if !ok {
 		return
 	}
@@ -32,148 +36,296 @@ func (h *handler) servePOST(
 		return
 	}
 
-	// Setup a read limit. If we don't know the content-length we allow reading
-	// up to the maximum input size.
-	readLimit := contentLength
-	if readLimit <= 0 {
-		readLimit = h.maxInputSize
+	body, ok := h.readRequestBody(w, r, contentLength, limit, outputMediaType, marshaler)
+	if !ok {
+		return
 	}
 
-	// Read the request body, up to the limit determined above, plus one byte.
-	// The extra byte lets us detect if the actual content length is longer than
-	// expected, or exceeds the maximum.
-	data, err := io.ReadAll(
-		io.LimitReader(
-			r.Body,
-			int64(readLimit)+1,
-		),
+	out, data, ok := h.callUnary(
+		w,
+		r,
+		method,
+		func(in proto.Message) error {
+			return unmarshaler.Unmarshal(body, in)
+		},
+		"request body",
+		outputMediaType,
+		marshaler,
 	)
-	if err != nil {
-		httpError(
-			w,
-			http.StatusInternalServerError,
-			outputMediaType,
-			marshaler,
-			rpcerror.New(
-				rpcerror.Unknown,
-				"the request body could not be read",
-			),
-		)
+	if !ok {
 		return
 	}
 
-	if contentLength != 0 && len(data) != contentLength {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Add("Content-Type", protomime.FormatMediaType(outputMediaType, out))
+	data = h.compressResponseBody(w, r, data)
+	w.Header().Add("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// callUnary calls a unary RPC method and marshals its output message.
+//
+// unmarshal produces the RPC input message. inputSource describes where the
+// input message is read from, for use in error messages.
+//
+// It returns false if the RPC method fails or the output message can not be
+// marshaled, in which case an error response has already been written to w.
+func (h *handler) callUnary(
+	w http.ResponseWriter,
+	r *http.Request,
+	method runtime.Method,
+	unmarshal runtime.Unmarshaler,
+	inputSource string,
+	outputMediaType string,
+	marshaler protomime.Marshaler,
+) (proto.Message, []byte, bool) {
+	out, rpcErr, ok := h.invokeUnaryMessage(r.Context(), method, unmarshal)
+	if !ok {
+		status := httpStatusFromErrorCode(rpcErr.Code())
+
+		// The native transport has always reported malformed input messages
+		// using the Unknown code, with a message that describes where the
+		// input was read from.
+		if errors.Is(rpcErr, errMalformedInput) {
+			rpcErr = rpcerror.New(
+				rpcerror.Unknown,
+				"the RPC input message could not be unmarshaled from the %s",
+				inputSource,
+			)
+		}
+
 		httpError(
 			w,
-			http.StatusBadRequest,
-			protomime.TextMediaTypes[0],
-			protomime.TextMarshaler,
-			rpcerror.New(
-				rpcerror.Unknown,
-				"the RPC input message length does not match the length specified by the Content-Length header",
-			),
+			status,
+			outputMediaType,
+			marshaler,
+			rpcErr,
 		)
-		return
+		return nil, nil, false
 	}
 
-	if len(data) > h.maxInputSize {
+	data, err := marshaler.Marshal(out)
+	if err != nil {
 		httpError(
 			w,
-			http.StatusRequestEntityTooLarge,
-			protomime.TextMediaTypes[0],
-			protomime.TextMarshaler,
+			http.StatusInternalServerError,
+			outputMediaType,
+			marshaler,
 			rpcerror.New(
 				rpcerror.Unknown,
-				"the RPC input message length exceeds the maximum allowable size",
+				"the RPC output message could not be marshaled to the response body",
 			),
 		)
-		return
+		return nil, nil, false
 	}
 
-	call := method.NewCall(
-		r.Context(),
-		runtime.CallOptions{
-			Interceptor: h.interceptor,
+	return out, data, true
+}
+
+// invokeUnary calls a unary RPC method with the RPC input message in data and
+// returns the marshaled RPC output message. Both messages are encoded using
+// the given media type.
+//
+// Unlike callUnary(), it does not write an error response. Instead, it returns
+// false and an error that describes the failure. It is used by transports that
+// have their own error representation.
+func (h *handler) invokeUnary(
+	ctx context.Context,
+	method runtime.Method,
+	mediaType string,
+	data []byte,
+) ([]byte, rpcerror.Error, bool) {
+	marshaler, _ := protomime.MarshalerForMediaType(mediaType)
+	unmarshaler, _ := protomime.UnmarshalerForMediaType(mediaType)
+
+	out, rpcErr, ok := h.invokeUnaryMessage(
+		ctx,
+		method,
+		func(in proto.Message) error {
+			return unmarshaler.Unmarshal(data, in)
 		},
 	)
+	if !ok {
+		return nil, rpcErr, false
+	}
+
+	data, err := marshaler.Marshal(out)
+	if err != nil {
+		return nil, rpcerror.New(
+			rpcerror.Unknown,
+			"the RPC output message could not be marshaled to the response body",
+		), false
+	}
+
+	return data, rpcerror.Error{}, true
+}
+
+// invokeUnaryMessage calls a unary RPC method with the RPC input message
+// produced by unmarshal and returns the RPC output message.
+//
+// Like invokeUnary(), it returns false and an error that describes the failure
+// instead of writing an error response.
+func (h *handler) invokeUnaryMessage(
+	ctx context.Context,
+	method runtime.Method,
+	unmarshal runtime.Unmarshaler,
+) (proto.Message, rpcerror.Error, bool) {
+	call := h.newCall(ctx, method)
 	defer call.Done()
 
 	// Send never blocks on unary RPC methods.
-	if _, err := call.Send(func(in proto.Message) error {
-		return unmarshaler.Unmarshal(data, in)
-	}); err != nil {
-		httpError(
-			w,
-			http.StatusBadRequest,
-			outputMediaType,
-			marshaler,
-			rpcerror.New(
-				rpcerror.Unknown,
-				"the RPC input message could not be unmarshaled from the request body",
-			),
-		)
-		return
+	if _, err := call.Send(unmarshal); err != nil {
+		return nil, rpcerror.New(
+			rpcerror.InvalidInput,
+			"the RPC input message could not be unmarshaled from the request",
+		).WithCause(
+			fmt.Errorf("%w: %w", errMalformedInput, err),
+		), false
 	}
 
 	out, _ := call.Recv()
 
 	if err := call.Wait(); err != nil {
 		if err, ok := err.(rpcerror.Error); ok {
-			httpError(
-				w,
-				httpStatusFromErrorCode(err.Code()),
-				outputMediaType,
-				marshaler,
-				err,
-			)
-		} else {
-			httpError(
-				w,
-				http.StatusInternalServerError,
-				outputMediaType,
-				marshaler,
-				rpcerror.New(
-					rpcerror.Unknown,
-					"the RPC method returned an unrecognized error",
-				),
-			)
+			return nil, err, false
 		}
 
-		return
+		return nil, rpcerror.New(
+			rpcerror.Unknown,
+			"the RPC method returned an unrecognized error",
+		), false
+	}
+
+	return out, rpcerror.Error{}, true
+}
+
+// errMalformedInput is the cause of the error returned by invokeUnary() and
+// invokeUnaryMessage() when the RPC input message can not be unmarshaled.
+var errMalformedInput = errors.New("malformed RPC input message")
+
+// readUnaryInput reads the RPC input message data from the request body. The
+// body is decompressed as per the Content-Encoding header.
+//
+// contentLength is the value returned by parseContentLength(), or zero if the
+// length of the body is not known in advance. limit is the maximum size of the
+// RPC input message, in bytes.
+//
+// Unlike readRequestBody(), it does not write an error response. Instead, it
+// returns false and an error that describes the failure.
+func (h *handler) readUnaryInput(
+	r *http.Request,
+	contentLength int,
+	limit int,
+) ([]byte, rpcerror.Error, bool) {
+	readLimit := contentLength
+	if readLimit <= 0 {
+		readLimit = limit
 	}
 
-	data, err = marshaler.Marshal(out)
+	data, err := io.ReadAll(
+		io.LimitReader(
+			r.Body,
+			int64(readLimit)+1,
+		),
+	)
 	if err != nil {
+		return nil, rpcerror.New(
+			rpcerror.Unknown,
+			"the request body could not be read",
+		), false
+	}
+
+	if contentLength != 0 && len(data) != contentLength {
+		return nil, rpcerror.New(
+			rpcerror.InvalidInput,
+			"the RPC input message length does not match the length specified by the Content-Length header",
+		), false
+	}
+
+	if len(data) > limit {
+		return nil, newInputSizeLimitError(
+			limit,
+			"the RPC input message length exceeds the maximum allowable size",
+		), false
+	}
+
+	return h.decompressRequestBody(r, data, limit)
+}
+
+// readRequestBody reads the RPC input message data from the request body. The
+// body is decompressed as per the Content-Encoding header.
+//
+// contentLength is the value returned by parseContentLength(). limit is the
+// maximum size of the RPC input message, in bytes. mediaType and marshaler are
+// used to write errors that occur while reading the request body.
+//
+// It returns false if the body can not be read or exceeds the maximum input
+// size, in which case an error response has already been written to w.
+func (h *handler) readRequestBody(
+	w http.ResponseWriter,
+	r *http.Request,
+	contentLength int,
+	limit int,
+	mediaType string,
+	marshaler protomime.Marshaler,
+) ([]byte, bool) {
+	data, rpcErr, ok := h.readUnaryInput(r, contentLength, limit)
+	if ok {
+		return data, true
+	}
+
+	switch {
+	case errors.Is(rpcErr, errUnsupportedContentEncoding):
+		// As per RFC 7694, advertise the content-codings that are supported.
+		w.Header().Set("Accept-Encoding", h.acceptEncodingHeader())
+		httpError(
+			w,
+			http.StatusUnsupportedMediaType,
+			protomime.TextMediaTypes[0],
+			protomime.TextMarshaler,
+			rpcerror.New(rpcerror.Unknown, "%s", rpcErr.Message()),
+		)
+	case errors.Is(rpcErr, errInputSizeLimitExceeded):
+		httpError(
+			w,
+			http.StatusRequestEntityTooLarge,
+			protomime.TextMediaTypes[0],
+			protomime.TextMarshaler,
+			rpcErr,
+		)
+	case rpcErr.Code() == rpcerror.InvalidInput:
+		httpError(
+			w,
+			http.StatusBadRequest,
+			protomime.TextMediaTypes[0],
+			protomime.TextMarshaler,
+			rpcerror.New(rpcerror.Unknown, "%s", rpcErr.Message()),
+		)
+	default:
 		httpError(
 			w,
 			http.StatusInternalServerError,
-			outputMediaType,
+			mediaType,
 			marshaler,
-			rpcerror.New(
-				rpcerror.Unknown,
-				"the RPC output message could not be marshaled to the response body",
-			),
+			rpcErr,
 		)
-		return
 	}
 
-	w.Header().Set("Cache-Control", "no-store")
-	w.Header().Set("X-Content-Type-Options", "nosniff")
-	w.Header().Add("Content-Type", protomime.FormatMediaType(outputMediaType, out))
-	w.Header().Add("Content-Length", strconv.Itoa(len(data)))
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write(data)
+	return nil, false
 }
 
 // parseContentLength parses the Content-Length header, if present.
 //
-// It returns false if the Content-Length header is invalid or too large, in
+// It returns false if the Content-Length header is invalid or exceeds limit, in
 // which case an error response has already been written to w.
 //
 // If no header is present it returns (0, true).
 func (h *handler) parseContentLength(
 	w http.ResponseWriter,
 	r *http.Request,
+	limit int,
 ) (int, bool) {
 	header := r.Header.Get("Content-Length")
 	if header == "" {
@@ -195,14 +347,14 @@ func (h *handler) parseContentLength(
 		return 0, false
 	}
 
-	if contentLength > h.maxInputSize {
+	if contentLength > limit {
 		httpError(
 			w,
 			http.StatusRequestEntityTooLarge,
 			protomime.TextMediaTypes[0],
 			protomime.TextMarshaler,
-			rpcerror.New(
-				rpcerror.Unknown,
+			newInputSizeLimitError(
+				limit,
 				"the length specified by the Content-Length header exceeds the maximum allowable size",
 			),
 		)
@@ -225,6 +377,31 @@ func negotiateMediaTypes(
 	unmarshaler protomime.Unmarshaler,
 	outputMediaType string,
 	ok bool,
+) {
+	unmarshaler, ok = negotiateInputMediaType(w, r)
+	if !ok {
+		return nil, nil, "", false
+	}
+
+	marshaler, outputMediaType, ok = negotiateOutputMediaType(w, r)
+	if !ok {
+		return nil, nil, "", false
+	}
+
+	return marshaler, unmarshaler, outputMediaType, true
+}
+
+// negotiateInputMediaType negotiates the media type used to unmarshal RPC input
+// messages from the request body.
+//
+// ok is false if the media-type is not supported, in which case an error
+// response has already been written to w.
+func negotiateInputMediaType(
+	w http.ResponseWriter,
+	r *http.Request,
+) (
+	unmarshaler protomime.Unmarshaler,
+	ok bool,
 ) {
 	unmarshaler, inputMediaType, ok, err := unmarshalerByNegotiation(r)
 	if err != nil {
@@ -238,7 +415,7 @@ func negotiateMediaTypes(
 				"the Content-Type header is missing or invalid",
 			),
 		)
-		return nil, nil, "", false
+		return nil, false
 	}
 
 	if !ok {
@@ -257,10 +434,26 @@ func negotiateMediaTypes(
 				},
 			),
 		)
-		return nil, nil, "", false
+		return nil, false
 	}
 
-	marshaler, outputMediaType, ok, err = marshalerByNegotiation(r)
+	return unmarshaler, true
+}
+
+// negotiateOutputMediaType negotiates the media type used to marshal RPC
+// output messages to the response body.
+//
+// ok is false if the media-type can not be negotiated, in which case an error
+// response has already been written to w.
+func negotiateOutputMediaType(
+	w http.ResponseWriter,
+	r *http.Request,
+) (
+	marshaler protomime.Marshaler,
+	outputMediaType string,
+	ok bool,
+) {
+	marshaler, outputMediaType, ok, err := marshalerByNegotiation(r)
 	if err != nil {
 		httpError(
 			w,
@@ -272,7 +465,7 @@ func negotiateMediaTypes(
 				"the Accept header is invalid",
 			),
 		)
-		return nil, nil, "", false
+		return nil, "", false
 	}
 
 	if !ok {
@@ -291,10 +484,10 @@ func negotiateMediaTypes(
 			),
 		)
 
-		return nil, nil, "", false
+		return nil, "", false
 	}
 
-	return marshaler, unmarshaler, outputMediaType, true
+	return marshaler, outputMediaType, true
 }
 
 // unmarshalerByNegotiation returns the unmarshaler to use for unmarshaling the
@@ -327,6 +520,12 @@ func marshalerByNegotiation(r *http.Request) (m protomime.Marshaler, mediaType s
 		// If no Accept header is provided, respond using the same content type
 		// that the client supplied for the RPC input method.
 		mediaType = r.Header.Get("Content-Type")
+
+		// If there is no Content-Type either, such as in a GET request, fall
+		// back to JSON, which is the most convenient encoding for browsers.
+		if mediaType == "" {
+			mediaType = protomime.JSONMediaTypes[0]
+		}
 	} else {
 		t, _, err := contenttype.GetAcceptableMediaType(r, protoAcceptMediaTypes)
 		if err != nil && err != contenttype.ErrNoAcceptableTypeFound {
@@ -386,6 +585,8 @@ func httpStatusFromErrorCode(c rpcerror.Code) int {
 		return http.StatusServiceUnavailable
 	case rpcerror.NotImplemented:
 		return http.StatusNotImplemented
+	case rpcerror.DeadlineExceeded:
+		return http.StatusGatewayTimeout
 	}
 
 	return http.StatusInternalServerError
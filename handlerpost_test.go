@@ -303,7 +303,7 @@ var _ = Describe("type Handler (HTTP POST)", func() {
 						http.StatusRequestEntityTooLarge,
 						"text/plain; charset=utf-8; x-proto=protean.v1.Error",
 						rpcerror.New(
-							rpcerror.Unknown,
+							rpcerror.InvalidInput,
 							"the length specified by the Content-Length header exceeds the maximum allowable size",
 						),
 					)
@@ -398,7 +398,7 @@ var _ = Describe("type Handler (HTTP POST)", func() {
 						http.StatusRequestEntityTooLarge,
 						"text/plain; charset=utf-8; x-proto=protean.v1.Error",
 						rpcerror.New(
-							rpcerror.Unknown,
+							rpcerror.InvalidInput,
 							"the RPC input message length exceeds the maximum allowable size",
 						),
 					)
@@ -833,17 +833,17 @@ var _ = Describe("type Handler (HTTP POST)", func() {
 				Entry(
 					"client streaming method",
 					"/protean.test/TestService/ClientStream",
-					"the 'protean.test.TestService' service does contain an RPC method named 'ClientStream', but is not supported by this server because it uses streaming inputs or outputs",
+					"the 'protean.test.TestService' service does contain an RPC method named 'ClientStream', but it uses streaming inputs or outputs and must be called via a websocket or a framed request body",
 				),
 				Entry(
 					"server streaming method",
 					"/protean.test/TestService/ServerStream",
-					"the 'protean.test.TestService' service does contain an RPC method named 'ServerStream', but is not supported by this server because it uses streaming inputs or outputs",
+					"the 'protean.test.TestService' service does contain an RPC method named 'ServerStream', but it uses streaming inputs or outputs and must be called via a websocket, server-sent events or a framed request body",
 				),
 				Entry(
 					"bidirectional streaming method",
 					"/protean.test/TestService/BidirectionalStream",
-					"the 'protean.test.TestService' service does contain an RPC method named 'BidirectionalStream', but is not supported by this server because it uses streaming inputs or outputs",
+					"the 'protean.test.TestService' service does contain an RPC method named 'BidirectionalStream', but it uses streaming inputs or outputs and must be called via a websocket or a framed request body",
 				),
 			)
 		})
@@ -0,0 +1,237 @@
+package protean
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dogmatiq/protean/internal/protomime"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// inputQueryParameter is the name of the URL query parameter that contains the
+// RPC input message for requests that can not carry it in the request body.
+const inputQueryParameter = "in"
+
+// unmarshalQueryInput unmarshals an RPC input message from the query
+// parameters in q.
+//
+// If the "in" parameter is present, it contains either the JSON representation
+// of the message, or the base64 encoding of its binary representation.
+//
+// Every other parameter sets the value of a single field. The parameter name is
+// the path to the field, using either the original or JSON field names,
+// separated by dots, such as "?customer.name=Jane". Repeated fields are
+// populated by repeating the parameter. Fields that are themselves messages
+// accept the JSON representation of that message, which allows well-known
+// types such as google.protobuf.Timestamp to be used. Map fields are not
+// supported.
+//
+// Field parameters are applied after the "in" parameter, and hence take
+// precedence over it.
+func unmarshalQueryInput(q url.Values, in proto.Message) error {
+	if q.Has(inputQueryParameter) {
+		v := q.Get(inputQueryParameter)
+
+		if strings.HasPrefix(strings.TrimSpace(v), "{") {
+			if err := protomime.JSONUnmarshaler.Unmarshal([]byte(v), in); err != nil {
+				return err
+			}
+		} else {
+			data, err := decodeBase64(v)
+			if err != nil {
+				return err
+			}
+
+			if err := protomime.BinaryUnmarshaler.Unmarshal(data, in); err != nil {
+				return err
+			}
+		}
+	}
+
+	var keys []string
+	for k := range q {
+		if k != inputQueryParameter {
+			keys = append(keys, k)
+		}
+	}
+
+	// Apply the fields in a deterministic order so that parameters referring
+	// to the same field by different names behave consistently.
+	sort.Strings(keys)
+
+	m := in.ProtoReflect()
+
+	for _, k := range keys {
+		if err := setQueryField(m, strings.Split(k, "."), q[k]); err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+	}
+
+	return proto.CheckInitialized(in)
+}
+
+// setQueryField sets the field of m at the given path to the values from a
+// query parameter.
+func setQueryField(
+	m protoreflect.Message,
+	path []string,
+	values []string,
+) error {
+	fd := findField(m.Descriptor(), path[0])
+	if fd == nil {
+		return errors.New("no such field")
+	}
+
+	if len(path) > 1 {
+		if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+			return fmt.Errorf("%s is not a singular message field", fd.Name())
+		}
+
+		return setQueryField(m.Mutable(fd).Message(), path[1:], values)
+	}
+
+	if fd.IsMap() {
+		return errors.New("map fields are not supported")
+	}
+
+	if fd.IsList() {
+		list := m.Mutable(fd).List()
+
+		for _, v := range values {
+			pv, err := parseQueryValue(fd, v, list.NewElement)
+			if err != nil {
+				return err
+			}
+
+			list.Append(pv)
+		}
+
+		return nil
+	}
+
+	if len(values) != 1 {
+		return errors.New("field is not repeated, but parameter has multiple values")
+	}
+
+	pv, err := parseQueryValue(
+		fd,
+		values[0],
+		func() protoreflect.Value {
+			return m.NewField(fd)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	m.Set(fd, pv)
+
+	return nil
+}
+
+// findField returns the field within the message described by md that has the
+// given name, which may be either its original or JSON name.
+func findField(md protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	fields := md.Fields()
+
+	if fd := fields.ByName(protoreflect.Name(name)); fd != nil {
+		return fd
+	}
+
+	return fields.ByJSONName(name)
+}
+
+// parseQueryValue parses a single (non-repeated) value for the field fd from
+// the text in a query parameter.
+//
+// newMessage returns a new, empty value for message fields.
+func parseQueryValue(
+	fd protoreflect.FieldDescriptor,
+	v string,
+	newMessage func() protoreflect.Value,
+) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		x, err := strconv.ParseBool(v)
+		return protoreflect.ValueOfBool(x), err
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		x, err := strconv.ParseInt(v, 10, 32)
+		return protoreflect.ValueOfInt32(int32(x)), err
+
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		x, err := strconv.ParseInt(v, 10, 64)
+		return protoreflect.ValueOfInt64(x), err
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		x, err := strconv.ParseUint(v, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(x)), err
+
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		x, err := strconv.ParseUint(v, 10, 64)
+		return protoreflect.ValueOfUint64(x), err
+
+	case protoreflect.FloatKind:
+		x, err := strconv.ParseFloat(v, 32)
+		return protoreflect.ValueOfFloat32(float32(x)), err
+
+	case protoreflect.DoubleKind:
+		x, err := strconv.ParseFloat(v, 64)
+		return protoreflect.ValueOfFloat64(x), err
+
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(v), nil
+
+	case protoreflect.BytesKind:
+		x, err := decodeBase64(v)
+		return protoreflect.ValueOfBytes(x), err
+
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(v)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+
+		x, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("%q is not a member of the %s enumeration", v, fd.Enum().FullName())
+		}
+
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(x)), nil
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		pv := newMessage()
+		m := pv.Message().Interface()
+
+		// First try to parse the value as JSON. If that fails, try again
+		// treating the value as a JSON string, which allows well-known types
+		// with a string representation (such as timestamps and durations) to
+		// be used without quotes.
+		if err := protomime.JSONUnmarshaler.Unmarshal([]byte(v), m); err != nil {
+			if err := protomime.JSONUnmarshaler.Unmarshal([]byte(strconv.Quote(v)), m); err != nil {
+				return protoreflect.Value{}, err
+			}
+		}
+
+		return pv, nil
+	}
+
+	return protoreflect.Value{}, fmt.Errorf("unsupported field kind (%s)", fd.Kind())
+}
+
+// decodeBase64 decodes base64 data that may use either the standard or the
+// URL-safe alphabet, with or without padding.
+//
+// Spaces are treated as "+" characters, as they are commonly produced when the
+// standard alphabet is used in a query string without being escaped.
+func decodeBase64(v string) ([]byte, error) {
+	v = strings.TrimRight(v, "=")
+	v = strings.NewReplacer("+", "-", " ", "-", "/", "_").Replace(v)
+
+	return base64.RawURLEncoding.DecodeString(v)
+}
@@ -0,0 +1,417 @@
+package protean
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/rpcerror"
+	"github.com/dogmatiq/protean/runtime"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// restRoute is an HTTP route that maps REST-style requests to an RPC method, as
+// described by a "google.api.http" rule.
+type restRoute struct {
+	service      runtime.Service
+	method       runtime.Method
+	httpMethod   string
+	template     pathTemplate
+	body         string
+	responseBody string
+}
+
+// addRESTRoutes adds routes for each of the "google.api.http" rules declared
+// on the methods of s, replacing any existing routes for the same service.
+//
+// It panics if any of the rules are invalid.
+func (h *handler) addRESTRoutes(s runtime.Service) {
+	name := s.Descriptor().FullName()
+
+	routes := h.restRoutes[:0]
+	for _, r := range h.restRoutes {
+		if r.service.Descriptor().FullName() != name {
+			routes = append(routes, r)
+		}
+	}
+	h.restRoutes = routes
+
+	methods := s.Descriptor().Methods()
+
+	for i := 0; i < methods.Len(); i++ {
+		md := methods.Get(i)
+
+		rule, _ := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if rule == nil {
+			continue
+		}
+
+		m, ok := s.MethodByName(string(md.Name()))
+		if !ok {
+			continue
+		}
+
+		rules := append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...)
+
+		for _, rule := range rules {
+			route, err := newRESTRoute(s, m, rule)
+			if err != nil {
+				panic(fmt.Sprintf(
+					"invalid google.api.http rule for the '%s' RPC method: %s",
+					md.FullName(),
+					err,
+				))
+			}
+
+			h.restRoutes = append(h.restRoutes, route)
+		}
+	}
+}
+
+// newRESTRoute returns the route described by a single "google.api.http" rule.
+func newRESTRoute(
+	s runtime.Service,
+	m runtime.Method,
+	rule *annotations.HttpRule,
+) (restRoute, error) {
+	route := restRoute{
+		service:      s,
+		method:       m,
+		body:         rule.GetBody(),
+		responseBody: rule.GetResponseBody(),
+	}
+
+	var path string
+
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		route.httpMethod, path = http.MethodGet, p.Get
+	case *annotations.HttpRule_Put:
+		route.httpMethod, path = http.MethodPut, p.Put
+	case *annotations.HttpRule_Post:
+		route.httpMethod, path = http.MethodPost, p.Post
+	case *annotations.HttpRule_Delete:
+		route.httpMethod, path = http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		route.httpMethod, path = http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		route.httpMethod, path = p.Custom.GetKind(), p.Custom.GetPath()
+	default:
+		return restRoute{}, errors.New("the rule does not specify an HTTP method and path")
+	}
+
+	var err error
+	route.template, err = parsePathTemplate(path)
+	if err != nil {
+		return restRoute{}, err
+	}
+
+	md := m.Descriptor()
+
+	for _, v := range route.template.variables {
+		if err := checkFieldPath(md.Input(), v.fieldPath); err != nil {
+			return restRoute{}, fmt.Errorf("path variable '%s': %w", strings.Join(v.fieldPath, "."), err)
+		}
+	}
+
+	if route.body != "" && route.body != "*" {
+		if md.Input().Fields().ByName(protoreflect.Name(route.body)) == nil {
+			return restRoute{}, fmt.Errorf("the body field '%s' does not exist", route.body)
+		}
+	}
+
+	if route.responseBody != "" {
+		if md.Output().Fields().ByName(protoreflect.Name(route.responseBody)) == nil {
+			return restRoute{}, fmt.Errorf("the response body field '%s' does not exist", route.responseBody)
+		}
+	}
+
+	return route, nil
+}
+
+// checkFieldPath returns an error if the given path does not refer to a
+// singular field within the message described by md.
+func checkFieldPath(md protoreflect.MessageDescriptor, path []string) error {
+	fd := md.Fields().ByName(protoreflect.Name(path[0]))
+	if fd == nil {
+		return fmt.Errorf("the field '%s' does not exist", path[0])
+	}
+
+	if fd.IsList() || fd.IsMap() {
+		return fmt.Errorf("the field '%s' is not a singular field", path[0])
+	}
+
+	if len(path) == 1 {
+		return nil
+	}
+
+	if fd.Kind() != protoreflect.MessageKind {
+		return fmt.Errorf("the field '%s' is not a message field", path[0])
+	}
+
+	return checkFieldPath(fd.Message(), path[1:])
+}
+
+// matchRESTRoute returns the route that matches r, if any.
+//
+// If no route matches, allowed contains the HTTP methods of any routes that
+// match the request's URL path.
+func (h *handler) matchRESTRoute(r *http.Request) (
+	route restRoute,
+	values []templateVariableValue,
+	allowed []string,
+	ok bool,
+) {
+	p := r.URL.EscapedPath()
+
+	for _, route := range h.restRoutes {
+		values, ok := route.template.match(p)
+		if !ok {
+			continue
+		}
+
+		if route.httpMethod == r.Method {
+			return route, values, nil, true
+		}
+
+		allowed = append(allowed, route.httpMethod)
+	}
+
+	return restRoute{}, nil, allowed, false
+}
+
+// serveREST serves a REST-style request that has been matched to an RPC method
+// by a "google.api.http" rule.
+//
+// The RPC input message is populated from the request body, the URL query
+// parameters and the variables in the URL path, in that order, as per the
+// rule. The RPC output message, or the field nominated by the rule's
+// "response_body", is written to the response body as JSON. Errors are
+// written as JSON protean.v1.Error messages.
+func (h *handler) serveREST(
+	w http.ResponseWriter,
+	r *http.Request,
+	route restRoute,
+	values []templateVariableValue,
+) {
+	if route.method.InputIsStream() || route.method.OutputIsStream() {
+		httpError(
+			w,
+			http.StatusNotImplemented,
+			protomime.JSONMediaTypes[0],
+			protomime.JSONMarshaler,
+			rpcerror.New(
+				rpcerror.NotImplemented,
+				"the '%s' RPC method uses streaming inputs or outputs and can not be called via a google.api.http rule",
+				route.method.Descriptor().FullName(),
+			),
+		)
+		return
+	}
+
+	var body []byte
+
+	if route.body != "" {
+		if ct := r.Header.Get("Content-Type"); ct != "" {
+			mediaType, _, _ := mime.ParseMediaType(ct)
+			if !protomime.IsJSON(mediaType) {
+				httpError(
+					w,
+					http.StatusUnsupportedMediaType,
+					protomime.JSONMediaTypes[0],
+					protomime.JSONMarshaler,
+					rpcerror.New(
+						rpcerror.Unknown,
+						"the server does not support the '%s' media-type supplied by the client",
+						mediaType,
+					),
+				)
+				return
+			}
+		}
+
+		limit := h.maxInputSizeFor(route.method)
+
+		contentLength, ok := h.parseContentLength(w, r, limit)
+		if !ok {
+			return
+		}
+
+		var rpcErr rpcerror.Error
+		body, rpcErr, ok = h.readUnaryInput(r, contentLength, limit)
+		if !ok {
+			status := httpStatusFromErrorCode(rpcErr.Code())
+			if errors.Is(rpcErr, errInputSizeLimitExceeded) {
+				status = http.StatusRequestEntityTooLarge
+			}
+
+			httpError(
+				w,
+				status,
+				protomime.JSONMediaTypes[0],
+				protomime.JSONMarshaler,
+				rpcErr,
+			)
+			return
+		}
+	}
+
+	out, rpcErr, ok := h.invokeUnaryMessage(
+		r.Context(),
+		route.method,
+		func(in proto.Message) error {
+			return unmarshalRESTInput(r, route, values, body, in)
+		},
+	)
+	if !ok {
+		httpError(
+			w,
+			httpStatusFromErrorCode(rpcErr.Code()),
+			protomime.JSONMediaTypes[0],
+			protomime.JSONMarshaler,
+			rpcErr,
+		)
+		return
+	}
+
+	data, err := marshalRESTOutput(out, route.responseBody)
+	if err != nil {
+		httpError(
+			w,
+			http.StatusInternalServerError,
+			protomime.JSONMediaTypes[0],
+			protomime.JSONMarshaler,
+			rpcerror.New(
+				rpcerror.Unknown,
+				"the RPC output message could not be marshaled to the response body",
+			),
+		)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", protomime.JSONMediaTypes[0])
+	data = h.compressResponseBody(w, r, data)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// unmarshalRESTInput populates the RPC input message from a REST-style
+// request.
+func unmarshalRESTInput(
+	r *http.Request,
+	route restRoute,
+	values []templateVariableValue,
+	body []byte,
+	in proto.Message,
+) error {
+	m := in.ProtoReflect()
+
+	switch route.body {
+	case "":
+	case "*":
+		if err := protomime.JSONUnmarshaler.Unmarshal(body, in); err != nil {
+			return err
+		}
+	default:
+		// Unmarshal the body as the value of a single field by wrapping it in a
+		// JSON object, so that fields of any type are handled consistently.
+		fd := m.Descriptor().Fields().ByName(protoreflect.Name(route.body))
+		name, _ := json.Marshal(string(fd.Name()))
+		wrapper := m.New()
+
+		if err := protomime.JSONUnmarshaler.Unmarshal(
+			[]byte(fmt.Sprintf(`{%s:%s}`, name, body)),
+			wrapper.Interface(),
+		); err != nil {
+			return err
+		}
+
+		m.Set(fd, wrapper.Get(fd))
+	}
+
+	// When the body is "*" every field not bound by the path is populated from
+	// the body, so the query parameters are ignored.
+	if route.body != "*" {
+		q := r.URL.Query()
+
+		var keys []string
+		for k := range q {
+			keys = append(keys, k)
+		}
+
+		// Apply the fields in a deterministic order, as per
+		// unmarshalQueryInput().
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			path := strings.Split(k, ".")
+
+			if route.body != "" {
+				if fd := findField(m.Descriptor(), path[0]); fd != nil && string(fd.Name()) == route.body {
+					continue
+				}
+			}
+
+			if err := setQueryField(m, path, q[k]); err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			}
+		}
+	}
+
+	// Path variables are applied last, and hence take precedence over both
+	// the body and the query parameters.
+	for _, v := range values {
+		if err := setQueryField(m, v.fieldPath, []string{v.value}); err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(v.fieldPath, "."), err)
+		}
+	}
+
+	return proto.CheckInitialized(in)
+}
+
+// marshalRESTOutput marshals the RPC output message, or the given field of it,
+// to JSON.
+func marshalRESTOutput(out proto.Message, field string) ([]byte, error) {
+	if field == "" {
+		return protomime.JSONMarshaler.Marshal(out)
+	}
+
+	m := out.ProtoReflect()
+	fd := m.Descriptor().Fields().ByName(protoreflect.Name(field))
+
+	if fd.Kind() == protoreflect.MessageKind && !fd.IsList() && !fd.IsMap() {
+		return protomime.JSONMarshaler.Marshal(m.Get(fd).Message().Interface())
+	}
+
+	// Marshal a message containing only the nominated field, then extract the
+	// field's value from the resulting JSON object.
+	wrapper := m.New()
+	wrapper.Set(fd, m.Get(fd))
+
+	data, err := protojson.MarshalOptions{
+		UseProtoNames:   true,
+		EmitUnpopulated: true,
+	}.Marshal(wrapper.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields[string(fd.Name())], nil
+}
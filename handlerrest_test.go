@@ -0,0 +1,202 @@
+package protean_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/rpcerror"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+)
+
+var _ = Describe("type Handler (REST)", func() {
+	var (
+		ctx      context.Context
+		cancel   context.CancelFunc
+		handler  Handler
+		service  *testservice.Stub
+		response *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		format.TruncatedDiff = false
+
+		ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+
+		handler = NewHandler()
+
+		echo := func(
+			_ context.Context,
+			in *testservice.Input,
+		) (*testservice.Output, error) {
+			return &testservice.Output{
+				Id:   in.GetId(),
+				Data: strings.ToUpper(in.GetData()),
+			}, nil
+		}
+
+		service = &testservice.Stub{
+			UnaryFunc:         echo,
+			NoSideEffectsFunc: echo,
+		}
+
+		testservice.RegisterProteanTestService(handler, service)
+
+		response = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		format.TruncatedDiff = true
+		cancel()
+	})
+
+	// serve makes a REST request to the handler.
+	serve := func(method, path, body string) {
+		var r io.Reader
+		if body != "" {
+			r = strings.NewReader(body)
+		}
+
+		req := httptest.NewRequest(method, path, r).WithContext(ctx)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		handler.ServeHTTP(response, req)
+	}
+
+	Describe("func ServeHTTP()", func() {
+		DescribeTable(
+			"it calls the RPC method that matches the google.api.http rule",
+			func(method, path, body, expect string) {
+				serve(method, path, body)
+
+				Expect(response.Code).To(Equal(http.StatusOK))
+				Expect(response.Header().Get("Content-Type")).To(Equal("application/json"))
+				Expect(response.Body.String()).To(MatchJSON(expect))
+			},
+			Entry(
+				"binds the entire body",
+				http.MethodPost,
+				"/v1/items/123",
+				`{"data":"hello"}`,
+				`{"id":"123","data":"HELLO"}`,
+			),
+			Entry(
+				"gives path variables precedence over the body",
+				http.MethodPost,
+				"/v1/items/123",
+				`{"id":"456","data":"hello"}`,
+				`{"id":"123","data":"HELLO"}`,
+			),
+			Entry(
+				"binds the body to a single field and responds with a single field",
+				http.MethodPut,
+				"/v1/items/123/data",
+				`"hello"`,
+				`"HELLO"`,
+			),
+			Entry(
+				"binds query parameters",
+				http.MethodGet,
+				"/v1/items/123?data=hello",
+				"",
+				`{"id":"123","data":"HELLO"}`,
+			),
+			Entry(
+				"unescapes path variables",
+				http.MethodGet,
+				"/v1/items/a%20b?data=hello",
+				"",
+				`{"id":"a b","data":"HELLO"}`,
+			),
+			Entry(
+				"binds multi-segment variables and matches verbs",
+				http.MethodGet,
+				"/v1/folders/a/b:lookup?data=hello",
+				"",
+				`{"id":"folders/a/b","data":"HELLO"}`,
+			),
+		)
+
+		It("responds with a '405 Method Not Allowed' status if the path matches a rule with a different HTTP method", func() {
+			serve(http.MethodDelete, "/v1/items/123", "")
+
+			Expect(response.Code).To(Equal(http.StatusMethodNotAllowed))
+			Expect(response.Header().Get("Allow")).To(Equal("POST, GET"))
+		})
+
+		It("responds with a '400 Bad Request' status if the body can not be unmarshaled", func() {
+			serve(http.MethodPost, "/v1/items/123", `}`)
+
+			Expect(response.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("responds with a '400 Bad Request' status if the body length does not match the Content-Length header", func() {
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/v1/items/123",
+				strings.NewReader(`{"data":"hello"}`),
+			).WithContext(ctx)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Content-Length", "100")
+
+			handler.ServeHTTP(response, req)
+
+			Expect(response.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("responds with a '413 Request Entity Too Large' status if the Content-Length header exceeds the maximum input size", func() {
+			handler = NewHandler(WithMaxRPCInputSize(8))
+			testservice.RegisterProteanTestService(handler, service)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/v1/items/123",
+				strings.NewReader(`{"data":"hello"}`),
+			).WithContext(ctx)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Content-Length", "16")
+
+			handler.ServeHTTP(response, req)
+
+			Expect(response.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		})
+
+		It("responds with a '400 Bad Request' status if a query parameter does not refer to a field", func() {
+			serve(http.MethodGet, "/v1/items/123?unknown=1", "")
+
+			Expect(response.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("maps the error returned by the RPC method to an HTTP status", func() {
+			service.UnaryFunc = func(
+				context.Context,
+				*testservice.Input,
+			) (*testservice.Output, error) {
+				return nil, rpcerror.New(rpcerror.NotFound, "<error>")
+			}
+
+			serve(http.MethodPost, "/v1/items/123", `{"data":"hello"}`)
+
+			Expect(response.Code).To(Equal(http.StatusNotFound))
+			Expect(response.Header().Get("Content-Type")).To(Equal("application/json; x-proto=protean.v1.Error"))
+			Expect(response.Body.String()).To(MatchJSON(`{"code":-6,"message":"<error>"}`))
+		})
+
+		It("does not affect requests that use the regular URL path pattern", func() {
+			serve(http.MethodPost, "/protean.test/TestService/Unary", `{"id":"123","data":"hello"}`)
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Body.String()).To(MatchJSON(`{"id":"123","data":"HELLO"}`))
+		})
+	})
+})
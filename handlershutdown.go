@@ -0,0 +1,191 @@
+package protean
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/rpcerror"
+)
+
+// shutdownRetryAfter is the value of the Retry-After header sent in responses
+// to requests that are rejected because the handler is shutting down.
+const shutdownRetryAfter = 5 * time.Second
+
+// shutdown tracks the in-flight requests of a handler so that it can be shut
+// down gracefully.
+type shutdown struct {
+	m        sync.Mutex
+	draining bool
+	active   map[*context.CancelFunc]struct{}
+
+	// requested is closed when the handler begins shutting down.
+	requested chan struct{}
+
+	// idle is closed when the handler is draining and there are no more active
+	// requests.
+	idle chan struct{}
+}
+
+// shutdownKey is the context key used to store the channel that is closed when
+// the handler begins shutting down.
+type shutdownKey struct{}
+
+// ShutdownRequested returns a channel that is closed when the handler that is
+// serving the RPC call associated with ctx begins shutting down.
+//
+// Implementations of long-lived streaming RPC methods should select on this
+// channel and end the call cleanly when it is closed, for example by returning
+// nil after sending any final output messages. It returns nil (a channel that
+// is never closed) if ctx is not associated with a call made via a Handler.
+func ShutdownRequested(ctx context.Context) <-chan struct{} {
+	ch, _ := ctx.Value(shutdownKey{}).(chan struct{})
+	return ch
+}
+
+// begin registers the start of a request.
+//
+// It returns false if the handler is shutting down, in which case the request
+// must be rejected. Otherwise, it returns a context for the request which is
+// canceled if the handler is forced to stop before the request completes, and
+// a function that must be called when the request completes.
+func (s *shutdown) begin(ctx context.Context) (context.Context, func(), bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.draining {
+		return nil, nil, false
+	}
+
+	if s.requested == nil {
+		s.requested = make(chan struct{})
+		s.idle = make(chan struct{})
+		s.active = map[*context.CancelFunc]struct{}{}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	ctx = context.WithValue(ctx, shutdownKey{}, s.requested)
+	s.active[&cancel] = struct{}{}
+
+	return ctx, func() {
+		cancel()
+
+		s.m.Lock()
+		defer s.m.Unlock()
+
+		delete(s.active, &cancel)
+
+		if s.draining && len(s.active) == 0 {
+			close(s.idle)
+		}
+	}, true
+}
+
+// Shutdown stops the handler gracefully.
+//
+// New requests are rejected with an rpcerror.Unavailable error and a
+// Retry-After header, in the error format of the transport used to make the
+// request. The channel returned by ShutdownRequested() is closed for all
+// in-flight calls, then Shutdown() waits for all in-flight calls to complete.
+//
+// Streaming calls are not ended by the handler itself; no websocket close frame
+// or equivalent signal is sent to the client until the call completes. Each
+// long-lived streaming RPC method must select on ShutdownRequested() in order
+// to end its call before ctx is canceled.
+//
+// If ctx is canceled before all calls complete, the contexts of the remaining
+// calls are canceled and ctx.Err() is returned.
+//
+// Shutdown() does not close any network listeners or idle connections; it is
+// intended to be called before http.Server.Shutdown(), which otherwise does
+// not know how to end long-lived streams.
+func (h *handler) Shutdown(ctx context.Context) error {
+	s := &h.shutdown
+
+	s.m.Lock()
+	if !s.draining {
+		if s.requested == nil {
+			s.requested = make(chan struct{})
+			s.idle = make(chan struct{})
+		}
+
+		s.draining = true
+		close(s.requested)
+
+		if len(s.active) == 0 {
+			close(s.idle)
+		}
+	}
+	idle := s.idle
+	s.m.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.m.Lock()
+	for cancel := range s.active {
+		(*cancel)()
+	}
+	s.m.Unlock()
+
+	return ctx.Err()
+}
+
+// writeShutdownError writes the response to a request that is rejected because
+// the handler is shutting down.
+//
+// The error is written in the format expected by the client, based on the
+// transport used to make the request.
+func (h *handler) writeShutdownError(w http.ResponseWriter, r *http.Request) {
+	rpcErr := rpcerror.New(
+		rpcerror.Unavailable,
+		"the server is shutting down",
+	)
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(shutdownRetryAfter/time.Second)))
+	w.Header().Set("Connection", "close")
+
+	switch {
+	case isGRPCRequest(r):
+		writeGRPCTrailersOnly(w, rpcErr)
+
+	case isConnectRequest(r):
+		writeConnectError(w, connectHTTPStatus(rpcErr.Code()), rpcErr)
+
+	case h.isTwirpRequest(r):
+		writeTwirpError(w, twirpErrorCodeFromErrorCode(rpcErr.Code()), rpcErr.Message())
+
+	case h.jsonRPCPath != "" && r.URL.Path == h.jsonRPCPath:
+		// The request is not parsed, so the error is reported using a single
+		// response with a null ID, even if the request is a batch.
+		data, _ := json.Marshal(newJSONRPCErrorFromRPCError(jsonRPCNullID, rpcErr))
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Content-Type", protomime.JSONMediaTypes[0])
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write(data)
+
+	default:
+		marshaler, mediaType, ok, _ := marshalerByNegotiation(r)
+		if !ok {
+			marshaler, mediaType = protomime.JSONMarshaler, protomime.JSONMediaTypes[0]
+		}
+
+		httpError(
+			w,
+			http.StatusServiceUnavailable,
+			mediaType,
+			marshaler,
+			rpcErr,
+		)
+	}
+}
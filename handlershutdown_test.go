@@ -0,0 +1,256 @@
+package protean_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/testservice"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("type Handler (shutdown)", func() {
+	var (
+		ctx     context.Context
+		cancel  context.CancelFunc
+		handler Handler
+		service *testservice.Stub
+		started chan struct{}
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+
+		handler = NewHandler()
+		started = make(chan struct{})
+		service = &testservice.Stub{}
+
+		testservice.RegisterProteanTestService(handler, service)
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	// call makes a unary RPC call in a separate goroutine and returns a channel
+	// that receives the response once the call completes.
+	call := func() <-chan *httptest.ResponseRecorder {
+		result := make(chan *httptest.ResponseRecorder, 1)
+
+		go func() {
+			defer GinkgoRecover()
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/protean.test/TestService/Unary",
+				strings.NewReader(`{"data":"<input>"}`),
+			)
+			req.Header.Set("Content-Type", "application/json")
+
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			result <- res
+		}()
+
+		return result
+	}
+
+	Describe("func Shutdown()", func() {
+		It("returns immediately if there are no in-flight calls", func() {
+			err := handler.Shutdown(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("rejects new calls with an 'unavailable' error", func() {
+			err := handler.Shutdown(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			res := <-call()
+
+			Expect(res.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(res.Header().Get("Retry-After")).To(Equal("5"))
+			Expect(res.Body.String()).To(MatchJSON(`{"code":-11,"message":"the server is shutting down"}`))
+		})
+
+		DescribeTable(
+			"it rejects new calls using the error format of the transport",
+			func(
+				contentType string,
+				path string,
+				header http.Header,
+				expectStatus int,
+				expectBody string,
+			) {
+				handler = NewHandler(
+					WithTwirpPrefix("/twirp"),
+					WithJSONRPCEndpoint("/rpc"),
+				)
+				testservice.RegisterProteanTestService(handler, service)
+
+				err := handler.Shutdown(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				req := httptest.NewRequest(
+					http.MethodPost,
+					path,
+					strings.NewReader(`{}`),
+				)
+				req.Header.Set("Content-Type", contentType)
+				for k, v := range header {
+					req.Header[k] = v
+				}
+
+				res := httptest.NewRecorder()
+				handler.ServeHTTP(res, req)
+
+				Expect(res.Code).To(Equal(expectStatus))
+				Expect(res.Header().Get("Retry-After")).To(Equal("5"))
+				Expect(res.Body.String()).To(MatchJSON(expectBody))
+			},
+			Entry(
+				"Connect",
+				"application/json",
+				"/protean.test.TestService/Unary",
+				http.Header{"Connect-Protocol-Version": {"1"}},
+				http.StatusServiceUnavailable,
+				`{"code":"unavailable","message":"the server is shutting down"}`,
+			),
+			Entry(
+				"Twirp",
+				"application/json",
+				"/twirp/protean.test.TestService/Unary",
+				nil,
+				http.StatusServiceUnavailable,
+				`{"code":"unavailable","msg":"the server is shutting down"}`,
+			),
+			Entry(
+				"JSON-RPC",
+				"application/json",
+				"/rpc",
+				nil,
+				http.StatusServiceUnavailable,
+				`{"jsonrpc":"2.0","error":{"code":-11,"message":"the server is shutting down"},"id":null}`,
+			),
+		)
+
+		It("rejects new gRPC calls with an UNAVAILABLE status", func() {
+			err := handler.Shutdown(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/protean.test.TestService/Unary",
+				nil,
+			)
+			req.Header.Set("Content-Type", "application/grpc")
+
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+
+			Expect(res.Code).To(Equal(http.StatusOK))
+			Expect(res.Header().Get("Retry-After")).To(Equal("5"))
+			Expect(res.Header().Get("Grpc-Status")).To(Equal("14"))
+			Expect(res.Header().Get("Grpc-Message")).To(Equal("the server is shutting down"))
+		})
+
+		It("allows browsers to read the 'unavailable' error of a cross-origin call", func() {
+			handler = NewHandler(
+				WithCORS(CORSPolicy{
+					AllowedOrigins: []string{"https://example.org"},
+				}),
+			)
+			testservice.RegisterProteanTestService(handler, service)
+
+			err := handler.Shutdown(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			preflight := httptest.NewRequest(
+				http.MethodOptions,
+				"/protean.test/TestService/Unary",
+				nil,
+			)
+			preflight.Header.Set("Origin", "https://example.org")
+			preflight.Header.Set("Access-Control-Request-Method", http.MethodPost)
+
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, preflight)
+
+			Expect(res.Code).To(Equal(http.StatusNoContent))
+			Expect(res.Header().Get("Access-Control-Allow-Origin")).To(Equal("https://example.org"))
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/protean.test/TestService/Unary",
+				strings.NewReader(`{"data":"<input>"}`),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Origin", "https://example.org")
+
+			res = httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+
+			Expect(res.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(res.Header().Get("Access-Control-Allow-Origin")).To(Equal("https://example.org"))
+			Expect(res.Header().Get("Retry-After")).To(Equal("5"))
+		})
+
+		It("signals in-flight calls and waits for them to complete", func() {
+			service.UnaryFunc = func(
+				ctx context.Context,
+				_ *testservice.Input,
+			) (*testservice.Output, error) {
+				close(started)
+				<-ShutdownRequested(ctx)
+				return &testservice.Output{Data: "<output>"}, nil
+			}
+
+			result := call()
+			<-started
+
+			err := handler.Shutdown(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			var res *httptest.ResponseRecorder
+			Expect(result).To(Receive(&res))
+			Expect(res.Code).To(Equal(http.StatusOK))
+			Expect(res.Body.String()).To(MatchJSON(`{"data":"<output>"}`))
+		})
+
+		It("cancels the in-flight calls if the context is canceled", func() {
+			canceled := make(chan struct{})
+
+			service.UnaryFunc = func(
+				ctx context.Context,
+				_ *testservice.Input,
+			) (*testservice.Output, error) {
+				close(started)
+				<-ctx.Done()
+				close(canceled)
+				return nil, ctx.Err()
+			}
+
+			result := call()
+			<-started
+
+			shutdownCtx, cancelShutdown := context.WithTimeout(ctx, 20*time.Millisecond)
+			defer cancelShutdown()
+
+			err := handler.Shutdown(shutdownCtx)
+			Expect(err).To(Equal(context.DeadlineExceeded))
+
+			Eventually(canceled).Should(BeClosed())
+			Eventually(result).Should(Receive())
+		})
+	})
+
+	Describe("func ShutdownRequested()", func() {
+		It("returns nil if the context is not associated with a handler", func() {
+			Expect(ShutdownRequested(context.Background())).To(BeNil())
+		})
+	})
+})
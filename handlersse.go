@@ -0,0 +1,275 @@
+package protean
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/dogmatiq/protean/internal/proteanpb"
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/rpcerror"
+	"github.com/dogmatiq/protean/runtime"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// eventStreamMediaType is the media type used for server-sent events.
+	eventStreamMediaType = "text/event-stream"
+
+	// eventStreamEncodingQueryParameter is the name of the URL query parameter
+	// that selects the encoding used for the data in each server-sent event.
+	eventStreamEncodingQueryParameter = "encoding"
+
+	// eventStreamDoneEvent is the name of the server-sent event that indicates
+	// that the RPC method completed successfully.
+	eventStreamDoneEvent = "done"
+
+	// eventStreamErrorEvent is the name of the server-sent event that carries
+	// the error returned by the RPC method.
+	//
+	// It is deliberately not named "error", which is the event that the
+	// browser's EventSource API dispatches when the connection fails.
+	eventStreamErrorEvent = "rpcerror"
+)
+
+// acceptsEventStream returns true if r explicitly accepts the server-sent
+// events media type.
+func acceptsEventStream(r *http.Request) bool {
+	for _, header := range r.Header.Values("Accept") {
+		for _, v := range strings.Split(header, ",") {
+			mediaType, _, err := mime.ParseMediaType(v)
+			if err == nil && mediaType == eventStreamMediaType {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// serveEventStream serves a call to a server streaming RPC method, sending each
+// RPC output message to the client as a server-sent event.
+//
+// The RPC input message is read from the query string of GET requests, or the
+// body of POST requests. As with unary RPC methods, GET requests are only
+// permitted if the method has no side effects or has been enabled using
+// WithGETEnabled(), as they can be made by third-party pages without a CORS
+// preflight request.
+func (h *handler) serveEventStream(
+	w http.ResponseWriter,
+	r *http.Request,
+	service runtime.Service,
+	method runtime.Method,
+) {
+	var unmarshal runtime.Unmarshaler
+
+	allowsGET := h.allowsGET(service, method)
+
+	switch {
+	case r.Method == http.MethodGet && allowsGET:
+		q := r.URL.Query()
+		q.Del(eventStreamEncodingQueryParameter)
+
+		unmarshal = func(in proto.Message) error {
+			return unmarshalQueryInput(q, in)
+		}
+
+	case r.Method == http.MethodPost:
+		limit := h.maxInputSizeFor(method)
+
+		contentLength, ok := h.parseContentLength(w, r, limit)
+		if !ok {
+			return
+		}
+
+		unmarshaler, ok := negotiateInputMediaType(w, r)
+		if !ok {
+			return
+		}
+
+		data, ok := h.readRequestBody(
+			w,
+			r,
+			contentLength,
+			limit,
+			protomime.TextMediaTypes[0],
+			protomime.TextMarshaler,
+		)
+		if !ok {
+			return
+		}
+
+		unmarshal = func(in proto.Message) error {
+			return unmarshaler.Unmarshal(data, in)
+		}
+
+	default:
+		message := "the HTTP method must be POST when using server-sent events"
+		if allowsGET {
+			message = "the HTTP method must be GET or POST when using server-sent events"
+		}
+
+		httpError(
+			w,
+			http.StatusMethodNotAllowed,
+			protomime.TextMediaTypes[0],
+			protomime.TextMarshaler,
+			rpcerror.New(
+				rpcerror.NotImplemented,
+				message,
+			),
+		)
+		return
+	}
+
+	encode, ok := eventStreamEncoder(r)
+	if !ok {
+		httpError(
+			w,
+			http.StatusBadRequest,
+			protomime.TextMediaTypes[0],
+			protomime.TextMarshaler,
+			rpcerror.New(
+				rpcerror.Unknown,
+				"the '%s' query parameter must be either 'json' or 'binary'",
+				eventStreamEncodingQueryParameter,
+			),
+		)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	call := h.newCall(ctx, method)
+	defer call.Done()
+
+	// Send never blocks on server streaming RPC methods.
+	if _, err := call.Send(unmarshal); err != nil {
+		httpError(
+			w,
+			http.StatusBadRequest,
+			protomime.TextMediaTypes[0],
+			protomime.TextMarshaler,
+			rpcerror.New(
+				rpcerror.Unknown,
+				"the RPC input message could not be unmarshaled from the request",
+			),
+		)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", eventStreamMediaType)
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	_ = rc.Flush()
+
+	var failure error
+
+	for {
+		out, ok := call.Recv()
+		if !ok {
+			break
+		}
+
+		if failure != nil {
+			// Continue to drain the output messages until the RPC method
+			// returns, but don't send them to the client.
+			continue
+		}
+
+		data, err := encode(out)
+		if err != nil {
+			failure = rpcerror.New(
+				rpcerror.Unknown,
+				"the RPC output message could not be marshaled to a server-sent event",
+			)
+			cancel()
+			continue
+		}
+
+		_ = writeEvent(w, "", data)
+		_ = rc.Flush()
+	}
+
+	err := call.Wait()
+
+	if failure != nil {
+		err = failure
+	}
+
+	if err == nil {
+		_ = writeEvent(w, eventStreamDoneEvent, "")
+	} else {
+		rpcErr, ok := err.(rpcerror.Error)
+		if !ok {
+			rpcErr = rpcerror.New(
+				rpcerror.Unknown,
+				"the RPC method returned an unrecognized error",
+			)
+		}
+
+		var protoErr proteanpb.Error
+		if err := rpcerror.ToProto(rpcErr, &protoErr); err != nil {
+			panic(err)
+		}
+
+		data, err := encode(&protoErr)
+		if err != nil {
+			panic(err)
+		}
+
+		_ = writeEvent(w, eventStreamErrorEvent, data)
+	}
+
+	_ = rc.Flush()
+}
+
+// eventStreamEncoder returns a function that encodes messages for use as the
+// data of a server-sent event, based on the encoding requested in the URL query
+// parameters.
+func eventStreamEncoder(r *http.Request) (func(proto.Message) (string, error), bool) {
+	switch r.URL.Query().Get(eventStreamEncodingQueryParameter) {
+	case "", "json":
+		return func(m proto.Message) (string, error) {
+			data, err := protomime.JSONMarshaler.Marshal(m)
+			return string(data), err
+		}, true
+
+	case "binary":
+		return func(m proto.Message) (string, error) {
+			data, err := protomime.BinaryMarshaler.Marshal(m)
+			return base64.StdEncoding.EncodeToString(data), err
+		}, true
+	}
+
+	return nil, false
+}
+
+// writeEvent writes a single server-sent event to w.
+//
+// If event is empty, the event has no name, and is dispatched by the browser as
+// a "message" event.
+func writeEvent(w io.Writer, event, data string) error {
+	var buf strings.Builder
+
+	if event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", event)
+	}
+
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(&buf, "data: %s\n", line)
+	}
+
+	buf.WriteString("\n")
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
@@ -0,0 +1,274 @@
+package protean_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/proteanpb"
+	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/rpcerror"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ = Describe("type Handler (server-sent events)", func() {
+	var (
+		ctx      context.Context
+		cancel   context.CancelFunc
+		handler  Handler
+		service  *testservice.Stub
+		request  *http.Request
+		response *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		format.TruncatedDiff = false
+
+		ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+
+		handler = NewHandler(
+			WithGETEnabled("protean.test.TestService/ServerStream"),
+		)
+
+		service = &testservice.Stub{
+			ServerStreamFunc: func(
+				ctx context.Context,
+				in *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				defer close(outputs)
+
+				for _, r := range in.GetData() {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case outputs <- &testservice.Output{Data: string(r)}:
+					}
+				}
+
+				return nil
+			},
+		}
+
+		testservice.RegisterProteanTestService(handler, service)
+
+		request = httptest.NewRequest(
+			http.MethodGet,
+			"/protean.test/TestService/ServerStream?in="+url.QueryEscape(`{"data":"abc"}`),
+			nil,
+		).WithContext(ctx)
+		request.Header.Set("Accept", "text/event-stream")
+
+		response = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		format.TruncatedDiff = true
+		cancel()
+	})
+
+	Describe("func ServeHTTP()", func() {
+		It("sends each RPC output message as an event", func() {
+			handler.ServeHTTP(response, request)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response).To(HaveHTTPHeaderWithValue("Content-Type", "text/event-stream"))
+			Expect(response).To(HaveHTTPHeaderWithValue("Cache-Control", "no-store"))
+			Expect(response.Body.String()).To(Equal(
+				"data: " + marshalJSON(&testservice.Output{Data: "a"}) + "\n\n" +
+					"data: " + marshalJSON(&testservice.Output{Data: "b"}) + "\n\n" +
+					"data: " + marshalJSON(&testservice.Output{Data: "c"}) + "\n\n" +
+					"event: done\ndata: \n\n",
+			))
+		})
+
+		It("accepts an RPC input message encoded as base64 binary in the query string", func() {
+			data, err := proto.Marshal(&testservice.Input{Data: "xy"})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			request.URL.RawQuery = "in=" + base64.RawURLEncoding.EncodeToString(data)
+
+			handler.ServeHTTP(response, request)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response.Body.String()).To(Equal(
+				"data: " + marshalJSON(&testservice.Output{Data: "x"}) + "\n\n" +
+					"data: " + marshalJSON(&testservice.Output{Data: "y"}) + "\n\n" +
+					"event: done\ndata: \n\n",
+			))
+		})
+
+		It("accepts an RPC input message in the body of a POST request", func() {
+			request.Method = http.MethodPost
+			request.URL.RawQuery = ""
+			request.Header.Set("Content-Type", "application/json")
+			request.Body = io.NopCloser(strings.NewReader(`{"data":"z"}`))
+
+			handler.ServeHTTP(response, request)
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response.Body.String()).To(Equal(
+				"data: " + marshalJSON(&testservice.Output{Data: "z"}) + "\n\n" +
+					"event: done\ndata: \n\n",
+			))
+		})
+
+		It("encodes events as base64 binary if requested", func() {
+			request.URL.RawQuery += "&encoding=binary"
+
+			handler.ServeHTTP(response, request)
+
+			data, err := proto.Marshal(&testservice.Output{Data: "a"})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response.Body.String()).To(HavePrefix(
+				"data: " + base64.StdEncoding.EncodeToString(data) + "\n\n",
+			))
+		})
+
+		It("sends the error returned by the RPC method as the final event", func() {
+			service.ServerStreamFunc = func(
+				_ context.Context,
+				_ *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				outputs <- &testservice.Output{Data: "a"}
+				close(outputs)
+				return rpcerror.New(rpcerror.NotFound, "<error>")
+			}
+
+			handler.ServeHTTP(response, request)
+
+			var protoErr proteanpb.Error
+			err := rpcerror.ToProto(rpcerror.New(rpcerror.NotFound, "<error>"), &protoErr)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(response).To(HaveHTTPStatus(http.StatusOK))
+			Expect(response.Body.String()).To(Equal(
+				"data: " + marshalJSON(&testservice.Output{Data: "a"}) + "\n\n" +
+					"event: rpcerror\ndata: " + marshalJSON(&protoErr) + "\n\n",
+			))
+		})
+
+		It("does not include the error message from arbitrary errors", func() {
+			service.ServerStreamFunc = func(
+				_ context.Context,
+				_ *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				close(outputs)
+				return io.EOF
+			}
+
+			handler.ServeHTTP(response, request)
+
+			var protoErr proteanpb.Error
+			err := rpcerror.ToProto(
+				rpcerror.New(
+					rpcerror.Unknown,
+					"the RPC method returned an unrecognized error",
+				),
+				&protoErr,
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(response.Body.String()).To(Equal(
+				"event: rpcerror\ndata: " + marshalJSON(&protoErr) + "\n\n",
+			))
+		})
+
+		It("responds with an HTTP '400 Bad Request' status if the RPC input message can not be unmarshaled", func() {
+			request.URL.RawQuery = "in=" + url.QueryEscape("{garbage")
+
+			handler.ServeHTTP(response, request)
+
+			expectError(
+				response,
+				http.StatusBadRequest,
+				"text/plain; charset=utf-8; x-proto=protean.v1.Error",
+				rpcerror.New(
+					rpcerror.Unknown,
+					"the RPC input message could not be unmarshaled from the request",
+				),
+			)
+		})
+
+		It("responds with an HTTP '400 Bad Request' status if the encoding is not supported", func() {
+			request.URL.RawQuery += "&encoding=xml"
+
+			handler.ServeHTTP(response, request)
+
+			expectError(
+				response,
+				http.StatusBadRequest,
+				"text/plain; charset=utf-8; x-proto=protean.v1.Error",
+				rpcerror.New(
+					rpcerror.Unknown,
+					"the 'encoding' query parameter must be either 'json' or 'binary'",
+				),
+			)
+		})
+
+		It("does not allow GET requests to RPC methods that have side effects", func() {
+			handler = NewHandler()
+			testservice.RegisterProteanTestService(handler, service)
+
+			service.ServerStreamFunc = func(
+				context.Context,
+				*testservice.Input,
+				chan<- *testservice.Output,
+			) error {
+				Fail("unexpected call")
+				return nil
+			}
+
+			handler.ServeHTTP(response, request)
+
+			expectError(
+				response,
+				http.StatusMethodNotAllowed,
+				"text/plain; charset=utf-8; x-proto=protean.v1.Error",
+				rpcerror.New(
+					rpcerror.NotImplemented,
+					"the HTTP method must be POST when using server-sent events",
+				),
+			)
+		})
+
+		It("responds with an HTTP '405 Method Not Allowed' status if the HTTP method is not GET or POST", func() {
+			request.Method = http.MethodPut
+			request.Body = io.NopCloser(&bytes.Buffer{})
+
+			handler.ServeHTTP(response, request)
+
+			expectError(
+				response,
+				http.StatusMethodNotAllowed,
+				"text/plain; charset=utf-8; x-proto=protean.v1.Error",
+				rpcerror.New(
+					rpcerror.NotImplemented,
+					"the HTTP method must be GET or POST when using server-sent events",
+				),
+			)
+		})
+	})
+})
+
+// marshalJSON returns the JSON representation of m, as produced by the handler.
+func marshalJSON(m proto.Message) string {
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(m)
+	Expect(err).ShouldNot(HaveOccurred())
+	return string(data)
+}
@@ -0,0 +1,232 @@
+package protean
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/rpcerror"
+	"github.com/dogmatiq/protean/runtime"
+)
+
+// streamMediaTypes maps each of the supported media types for framed request
+// and response bodies to the media type used to encode each frame.
+var streamMediaTypes = map[string]string{
+	"application/vnd.protean.stream+proto":  protomime.BinaryMediaTypes[0],
+	"application/vnd.protean.stream+ndjson": protomime.JSONMediaTypes[0],
+}
+
+// streamFrameHeaderSize is the size of the header that precedes each frame
+// when using the length-prefixed binary framing.
+const streamFrameHeaderSize = 4
+
+// isStreamRequest returns true if r has a body containing a stream of frames.
+func isStreamRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	_, ok := streamMediaTypes[mediaType]
+	return ok
+}
+
+// serveStream serves an RPC request made using an HTTP POST request with a
+// framed request body.
+//
+// Frames are read from the request body as they arrive and output frames are
+// flushed to the response body as they are produced, allowing any kind of RPC
+// method to be called without upgrading to a websocket. Full-duplex operation
+// requires HTTP/2, or an HTTP/1.1 client that reads the response while still
+// sending the request body.
+func (h *handler) serveStream(
+	w http.ResponseWriter,
+	r *http.Request,
+	method runtime.Method,
+) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	frameMediaType := streamMediaTypes[mediaType]
+	marshaler, _ := protomime.MarshalerForMediaType(frameMediaType)
+	unmarshaler, _ := protomime.UnmarshalerForMediaType(frameMediaType)
+
+	rc := http.NewResponseController(w)
+
+	// Allow the request body to be read after the response has been started.
+	// This is only necessary for HTTP/1.1, the error is ignored because full
+	// duplex is not supported (nor necessary) for HTTP/2.
+	_ = rc.EnableFullDuplex()
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(http.StatusOK)
+	_ = rc.Flush()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	c := &streamCall{
+		stream: &proteanFrameStream{
+			transport: &httpTransport{
+				r:            r,
+				w:            w,
+				rc:           rc,
+				body:         bufio.NewReader(r.Body),
+				delimited:    !protomime.IsBinary(frameMediaType),
+				maxFrameSize: h.maxInputSizeFor(method),
+			},
+			frameName:   "frame",
+			marshaler:   marshaler,
+			unmarshaler: unmarshaler,
+		},
+		frameName: "frame",
+		method:    method,
+		cancel:    cancel,
+		call:      h.newCall(ctx, method),
+	}
+
+	c.run()
+}
+
+// httpTransport is a frameTransport that reads frames from an HTTP request body
+// and writes frames to the HTTP response body.
+//
+// Frames are either length-prefixed, in which case each frame is preceded by
+// its length as a 32-bit big-endian unsigned integer, or delimited by newlines
+// (NDJSON).
+type httpTransport struct {
+	r            *http.Request
+	w            http.ResponseWriter
+	rc           *http.ResponseController
+	body         *bufio.Reader
+	delimited    bool
+	maxFrameSize int
+}
+
+// ReadFrame reads the next frame sent by the client.
+//
+// If the frame is malformed it returns an rpcerror.Error that describes the
+// problem.
+func (t *httpTransport) ReadFrame() ([]byte, error) {
+	if t.delimited {
+		return t.readLine()
+	}
+
+	return readLengthPrefixedFrame(t.body, t.maxFrameSize)
+}
+
+// readLine reads the next non-empty line from the request body.
+func (t *httpTransport) readLine() ([]byte, error) {
+	for {
+		var line []byte
+
+		for {
+			chunk, err := t.body.ReadSlice('\n')
+			line = append(line, chunk...)
+
+			if len(bytes.TrimSpace(line)) > t.maxFrameSize {
+				return nil, newFrameTooLargeError(t.maxFrameSize)
+			}
+
+			if err == bufio.ErrBufferFull {
+				continue
+			}
+
+			if err == io.EOF && len(line) != 0 {
+				// The last line is not required to have a trailing newline.
+				break
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			break
+		}
+
+		if line = bytes.TrimSpace(line); len(line) != 0 {
+			return line, nil
+		}
+	}
+}
+
+// readLengthPrefixedFrame reads a frame that is preceded by its length, as a
+// 32-bit big-endian unsigned integer, from r.
+//
+// It returns io.EOF if r ends before the frame begins.
+func readLengthPrefixedFrame(r io.Reader, maxSize int) ([]byte, error) {
+	var header [streamFrameHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, errTruncatedFrame
+		}
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if uint64(size) > uint64(maxSize) {
+		return nil, newFrameTooLargeError(maxSize)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, errTruncatedFrame
+		}
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// WriteFrame writes a frame to the response body and flushes it to the
+// client.
+func (t *httpTransport) WriteFrame(data []byte) error {
+	if t.delimited {
+		data = append(data, '\n')
+	} else {
+		var header [streamFrameHeaderSize]byte
+		binary.BigEndian.PutUint32(header[:], uint32(len(data)))
+
+		if _, err := t.w.Write(header[:]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := t.w.Write(data); err != nil {
+		return err
+	}
+
+	return t.rc.Flush()
+}
+
+// Close stops reading from the request body.
+func (t *httpTransport) Close() {
+	// Unblock any pending read. Not all response writers support read
+	// deadlines, so the request body is also closed.
+	_ = t.rc.SetReadDeadline(time.Now())
+	_ = t.r.Body.Close()
+}
+
+// errTruncatedFrame is returned when the request body ends part way through a
+// frame.
+var errTruncatedFrame = rpcerror.New(
+	rpcerror.Unknown,
+	"the request body ended part way through a frame",
+)
+
+// newFrameTooLargeError returns the error that is returned when a frame
+// exceeds the maximum RPC input message size.
+func newFrameTooLargeError(limit int) rpcerror.Error {
+	return newInputSizeLimitError(
+		limit,
+		"the frame length exceeds the maximum allowable size",
+	)
+}
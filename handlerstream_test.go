@@ -0,0 +1,379 @@
+package protean_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/proteanpb"
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/rpcerror"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+var _ = Describe("type Handler (framed request body)", func() {
+	var (
+		ctx     context.Context
+		cancel  context.CancelFunc
+		handler Handler
+		service *testservice.Stub
+		server  *httptest.Server
+		bodies  []io.Closer
+	)
+
+	BeforeEach(func() {
+		format.TruncatedDiff = false
+
+		ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+
+		handler = NewHandler()
+
+		service = &testservice.Stub{
+			UnaryFunc: func(
+				_ context.Context,
+				in *testservice.Input,
+			) (*testservice.Output, error) {
+				return &testservice.Output{
+					Data: strings.ToUpper(in.GetData()),
+				}, nil
+			},
+			ClientStreamFunc: func(
+				ctx context.Context,
+				inputs <-chan *testservice.Input,
+			) (*testservice.Output, error) {
+				var data []string
+
+				for {
+					select {
+					case <-ctx.Done():
+						return nil, ctx.Err()
+					case in, ok := <-inputs:
+						if !ok {
+							return &testservice.Output{
+								Data: strings.Join(data, ","),
+							}, nil
+						}
+
+						data = append(data, in.GetData())
+					}
+				}
+			},
+			ServerStreamFunc: func(
+				ctx context.Context,
+				in *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				defer close(outputs)
+
+				for _, r := range in.GetData() {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case outputs <- &testservice.Output{Data: string(r)}:
+					}
+				}
+
+				return nil
+			},
+			BidirectionalStreamFunc: func(
+				ctx context.Context,
+				inputs <-chan *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				defer close(outputs)
+
+				for {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case in, ok := <-inputs:
+						if !ok {
+							return nil
+						}
+
+						select {
+						case <-ctx.Done():
+							return ctx.Err()
+						case outputs <- &testservice.Output{Data: strings.ToUpper(in.GetData())}:
+						}
+					}
+				}
+			},
+		}
+
+		testservice.RegisterProteanTestService(handler, service)
+
+		server = httptest.NewServer(handler)
+	})
+
+	AfterEach(func() {
+		format.TruncatedDiff = true
+		cancel()
+
+		for _, b := range bodies {
+			b.Close()
+		}
+		bodies = nil
+
+		server.Close()
+	})
+
+	// post starts a call to the given RPC method with a request body that is
+	// read from body.
+	post := func(method, mediaType string, body io.Reader) *bufio.Reader {
+		req, err := http.NewRequestWithContext(
+			ctx,
+			http.MethodPost,
+			server.URL+"/protean.test/TestService/"+method,
+			body,
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Content-Type", mediaType)
+
+		res, err := http.DefaultClient.Do(req)
+		Expect(err).ShouldNot(HaveOccurred())
+		bodies = append(bodies, res.Body)
+
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(res.Header.Get("Content-Type")).To(Equal(mediaType))
+
+		return bufio.NewReader(res.Body)
+	}
+
+	// inputs returns a request body containing a frame for each of the given
+	// RPC input messages.
+	inputs := func(mediaType string, data ...string) io.Reader {
+		var buf bytes.Buffer
+		for _, d := range data {
+			writeStreamInput(&buf, mediaType, d)
+		}
+		return &buf
+	}
+
+	Describe("func ServeHTTP()", func() {
+		DescribeTable(
+			"it calls the RPC method",
+			func(mediaType string) {
+				By("unary")
+				r := post("Unary", mediaType, inputs(mediaType, "hello"))
+				expectStreamOutput(r, mediaType, "HELLO")
+				expectStreamDone(r, mediaType)
+
+				By("client streaming")
+				r = post("ClientStream", mediaType, inputs(mediaType, "a", "b", "c"))
+				expectStreamOutput(r, mediaType, "a,b,c")
+				expectStreamDone(r, mediaType)
+
+				By("server streaming")
+				r = post("ServerStream", mediaType, inputs(mediaType, "abc"))
+				expectStreamOutput(r, mediaType, "a")
+				expectStreamOutput(r, mediaType, "b")
+				expectStreamOutput(r, mediaType, "c")
+				expectStreamDone(r, mediaType)
+			},
+			Entry("length-prefixed binary", "application/vnd.protean.stream+proto"),
+			Entry("newline-delimited JSON", "application/vnd.protean.stream+ndjson"),
+		)
+
+		DescribeTable(
+			"it exchanges messages with a bidirectional streaming method while the request body is still being sent",
+			func(mediaType string) {
+				pr, pw := io.Pipe()
+				defer pw.Close()
+
+				r := post("BidirectionalStream", mediaType, pr)
+
+				writeStreamInput(pw, mediaType, "a")
+				expectStreamOutput(r, mediaType, "A")
+
+				writeStreamInput(pw, mediaType, "b")
+				expectStreamOutput(r, mediaType, "B")
+
+				pw.Close()
+				expectStreamDone(r, mediaType)
+			},
+			Entry("length-prefixed binary", "application/vnd.protean.stream+proto"),
+			Entry("newline-delimited JSON", "application/vnd.protean.stream+ndjson"),
+		)
+
+		It("sends the error returned by the RPC method as the final frame", func() {
+			service.UnaryFunc = func(
+				context.Context,
+				*testservice.Input,
+			) (*testservice.Output, error) {
+				return nil, rpcerror.New(rpcerror.NotFound, "<error>")
+			}
+
+			mediaType := "application/vnd.protean.stream+proto"
+			r := post("Unary", mediaType, inputs(mediaType, "hello"))
+
+			expectStreamError(
+				r,
+				mediaType,
+				rpcerror.New(rpcerror.NotFound, "<error>"),
+			)
+		})
+
+		It("sends an error if the request body ends without an input message for a unary method", func() {
+			mediaType := "application/vnd.protean.stream+ndjson"
+			r := post("Unary", mediaType, inputs(mediaType))
+
+			expectStreamError(
+				r,
+				mediaType,
+				rpcerror.New(
+					rpcerror.Unknown,
+					"the client ended the RPC input stream without sending an RPC input message",
+				),
+			)
+		})
+
+		It("sends an error if the request body ends part way through a frame", func() {
+			mediaType := "application/vnd.protean.stream+proto"
+			r := post("ClientStream", mediaType, bytes.NewReader([]byte{0, 0, 0, 10, 1, 2}))
+
+			expectStreamError(
+				r,
+				mediaType,
+				rpcerror.New(
+					rpcerror.Unknown,
+					"the request body ended part way through a frame",
+				),
+			)
+		})
+
+		It("sends an error if a frame exceeds the maximum input size", func() {
+			handler = NewHandler(WithMaxRPCInputSize(10))
+			testservice.RegisterProteanTestService(handler, service)
+			server.Config.Handler = handler
+
+			mediaType := "application/vnd.protean.stream+proto"
+			r := post("ClientStream", mediaType, bytes.NewReader([]byte{0, 0, 0, 11}))
+
+			expectStreamError(
+				r,
+				mediaType,
+				rpcerror.New(
+					rpcerror.InvalidInput,
+					"the frame length exceeds the maximum allowable size",
+				),
+			)
+		})
+
+		It("sends an error if a frame can not be unmarshaled", func() {
+			mediaType := "application/vnd.protean.stream+ndjson"
+			r := post("ClientStream", mediaType, strings.NewReader("{garbage\n"))
+
+			expectStreamError(
+				r,
+				mediaType,
+				rpcerror.New(
+					rpcerror.Unknown,
+					"the frame could not be unmarshaled",
+				),
+			)
+		})
+	})
+})
+
+// writeStreamInput writes a frame containing an RPC input message with the
+// given data to w.
+func writeStreamInput(w io.Writer, mediaType, data string) {
+	input, err := anypb.New(&testservice.Input{Data: data})
+	Expect(err).ShouldNot(HaveOccurred())
+
+	frame := &proteanpb.ClientFrame{
+		Frame: &proteanpb.ClientFrame_Input{
+			Input: input,
+		},
+	}
+
+	if mediaType == "application/vnd.protean.stream+proto" {
+		data, err := proto.Marshal(frame)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = binary.Write(w, binary.BigEndian, uint32(len(data)))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = w.Write(data)
+		Expect(err).ShouldNot(HaveOccurred())
+	} else {
+		data, err := protomime.JSONMarshaler.Marshal(frame)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = w.Write(append(data, '\n'))
+		Expect(err).ShouldNot(HaveOccurred())
+	}
+}
+
+// readStreamFrame reads the next frame from a framed response body.
+func readStreamFrame(r *bufio.Reader, mediaType string) *proteanpb.ServerFrame {
+	frame := &proteanpb.ServerFrame{}
+
+	if mediaType == "application/vnd.protean.stream+proto" {
+		var size uint32
+		err := binary.Read(r, binary.BigEndian, &size)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		data := make([]byte, size)
+		_, err = io.ReadFull(r, data)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = proto.Unmarshal(data, frame)
+		Expect(err).ShouldNot(HaveOccurred())
+	} else {
+		line, err := r.ReadBytes('\n')
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = protomime.JSONUnmarshaler.Unmarshal(line, frame)
+		Expect(err).ShouldNot(HaveOccurred())
+	}
+
+	return frame
+}
+
+// expectStreamOutput asserts that the next frame in a framed response body
+// contains an RPC output message with the given data.
+func expectStreamOutput(r *bufio.Reader, mediaType, data string) {
+	frame := readStreamFrame(r, mediaType)
+	Expect(frame.GetOutput()).NotTo(BeNil(), "expected an output frame, got %s", frame)
+
+	out := &testservice.Output{}
+	err := frame.GetOutput().UnmarshalTo(out)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	Expect(out.GetData()).To(Equal(data))
+}
+
+// expectStreamDone asserts that the next frame in a framed response body
+// indicates that the RPC method completed successfully.
+func expectStreamDone(r *bufio.Reader, mediaType string) {
+	frame := readStreamFrame(r, mediaType)
+	Expect(frame.GetDone()).NotTo(BeNil(), "expected a done frame, got %s", frame)
+}
+
+// expectStreamError asserts that the next frame in a framed response body
+// contains the expected error.
+func expectStreamError(r *bufio.Reader, mediaType string, expect rpcerror.Error) {
+	frame := readStreamFrame(r, mediaType)
+	Expect(frame.GetError()).NotTo(BeNil(), "expected an error frame, got %s", frame)
+
+	actual, err := rpcerror.FromProto(frame.GetError())
+	Expect(err).ShouldNot(HaveOccurred())
+
+	Expect(actual.Code()).To(Equal(expect.Code()))
+	Expect(actual.Message()).To(Equal(expect.Message()))
+}
@@ -0,0 +1,352 @@
+package protean
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/dogmatiq/protean/internal/proteanpb"
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/rpcerror"
+	"github.com/dogmatiq/protean/runtime"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// frameStream is a stream of frames exchanged with the client during a single
+// RPC call.
+type frameStream interface {
+	// ReadInput reads the next frame sent by the client.
+	//
+	// If the frame contains an RPC input message it returns a function that
+	// unmarshals that message. If the frame indicates that the client will not
+	// send any more input messages, done is true.
+	//
+	// It returns io.EOF if the client has finished sending frames. If the
+	// frame is malformed it returns an rpcerror.Error that describes the
+	// problem.
+	ReadInput() (unmarshal runtime.Unmarshaler, done bool, err error)
+
+	// WriteOutput writes a frame containing an RPC output message.
+	WriteOutput(out proto.Message) error
+
+	// WriteDone writes the final frame of a call that succeeded.
+	WriteDone() error
+
+	// WriteError writes the final frame of a call that failed.
+	WriteError(err rpcerror.Error) error
+
+	// Close closes the stream once the RPC method has returned. It causes any
+	// pending call to ReadInput() to return.
+	Close()
+}
+
+// frameTransport is a low-level transport that carries the encoded frames of
+// a frameStream.
+type frameTransport interface {
+	// ReadFrame reads the next frame sent by the client.
+	//
+	// It returns io.EOF if the client has finished sending frames. If the
+	// frame is malformed it returns an rpcerror.Error that describes the
+	// problem.
+	ReadFrame() ([]byte, error)
+
+	// WriteFrame writes a frame to the client.
+	WriteFrame(data []byte) error
+
+	// Close closes the transport once the RPC method has returned. It causes
+	// any pending call to ReadFrame() to return.
+	Close()
+}
+
+// streamCall manages the exchange of frames for a single RPC call made over a
+// frameStream.
+type streamCall struct {
+	stream    frameStream
+	frameName string
+	method    runtime.Method
+	call      runtime.Call
+	cancel    context.CancelFunc
+
+	m      sync.Mutex
+	failed bool
+	err    rpcerror.Error
+}
+
+// run exchanges frames with the client until the RPC method returns.
+func (c *streamCall) run() {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.readInputs()
+	}()
+
+	c.writeOutputs()
+
+	c.cancel()
+	c.stream.Close()
+	<-done
+}
+
+// readInputs reads frames from the client and sends the RPC input messages
+// they contain to the call.
+//
+// It continues reading frames until the stream is closed, even after the last
+// input message has been received, so that protocol violations are detected.
+func (c *streamCall) readInputs() {
+	more := true
+	sent := false
+
+	for {
+		unmarshal, done, err := c.stream.ReadInput()
+		if errors.Is(err, io.EOF) {
+			// The client has finished sending frames, which implicitly ends
+			// the RPC input stream if it has not already been ended.
+			if more {
+				c.endInputs(sent)
+			}
+			return
+		}
+		if rpcErr, ok := err.(rpcerror.Error); ok {
+			c.fail(rpcErr)
+			return
+		}
+		if err != nil {
+			// The stream has been closed, or is otherwise unusable. The call's
+			// context is canceled so that the RPC method is not left waiting
+			// for input messages that will never arrive.
+			c.cancel()
+			return
+		}
+
+		if !more {
+			c.fail(
+				rpcerror.New(
+					rpcerror.Unknown,
+					"the client sent a %s after the end of the RPC input stream",
+					c.frameName,
+				),
+			)
+			return
+		}
+
+		if done {
+			if !c.endInputs(sent) {
+				return
+			}
+			more = false
+			continue
+		}
+
+		more, err = c.call.Send(unmarshal)
+		if err != nil {
+			c.fail(
+				rpcerror.New(
+					rpcerror.Unknown,
+					"the RPC input message could not be unmarshaled from the %s",
+					c.frameName,
+				),
+			)
+			return
+		}
+
+		sent = true
+	}
+}
+
+// endInputs ends the RPC input stream.
+//
+// sent indicates whether the client has sent at least one RPC input message.
+// It returns false if the client was required to send an input message, in
+// which case the call has failed.
+func (c *streamCall) endInputs(sent bool) bool {
+	if !sent && !c.method.InputIsStream() {
+		c.fail(
+			rpcerror.New(
+				rpcerror.Unknown,
+				"the client ended the RPC input stream without sending an RPC input message",
+			),
+		)
+		return false
+	}
+
+	c.call.Done()
+
+	return true
+}
+
+// writeOutputs writes each RPC output message produced by the call to the
+// client, followed by a final frame that indicates whether the RPC method
+// succeeded.
+func (c *streamCall) writeOutputs() {
+	for {
+		out, ok := c.call.Recv()
+		if !ok {
+			break
+		}
+
+		if c.hasFailed() {
+			// Continue to drain the output messages until the RPC method
+			// returns, but don't send them to the client.
+			continue
+		}
+
+		err := c.stream.WriteOutput(out)
+		if err != nil {
+			c.fail(
+				rpcerror.New(
+					rpcerror.Unknown,
+					"the RPC output message could not be marshaled to a %s",
+					c.frameName,
+				),
+			)
+		}
+	}
+
+	err := c.call.Wait()
+
+	if rpcErr, ok := c.failure(); ok {
+		_ = c.stream.WriteError(rpcErr)
+	} else if err == nil {
+		_ = c.stream.WriteDone()
+	} else if rpcErr, ok := err.(rpcerror.Error); ok {
+		_ = c.stream.WriteError(rpcErr)
+	} else {
+		_ = c.stream.WriteError(
+			rpcerror.New(
+				rpcerror.Unknown,
+				"the RPC method returned an unrecognized error",
+			),
+		)
+	}
+}
+
+// fail records an error that prevents the call from continuing and cancels
+// the call's context.
+//
+// Only the first error is recorded. It is sent to the client in place of the
+// error returned by the RPC method.
+func (c *streamCall) fail(err rpcerror.Error) {
+	c.m.Lock()
+	if !c.failed {
+		c.failed = true
+		c.err = err
+	}
+	c.m.Unlock()
+
+	c.cancel()
+}
+
+// hasFailed returns true if fail() has been called.
+func (c *streamCall) hasFailed() bool {
+	_, ok := c.failure()
+	return ok
+}
+
+// failure returns the error passed to the first call to fail(), if any.
+func (c *streamCall) failure() (rpcerror.Error, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.err, c.failed
+}
+
+// proteanFrameStream is a frameStream that exchanges protean.v1.ClientFrame and
+// protean.v1.ServerFrame messages over a frameTransport.
+type proteanFrameStream struct {
+	transport   frameTransport
+	frameName   string
+	marshaler   protomime.Marshaler
+	unmarshaler protomime.Unmarshaler
+}
+
+// ReadInput reads the next frame sent by the client.
+func (s *proteanFrameStream) ReadInput() (runtime.Unmarshaler, bool, error) {
+	data, err := s.transport.ReadFrame()
+	if err != nil {
+		return nil, false, err
+	}
+
+	frame := &proteanpb.ClientFrame{}
+	if err := s.unmarshaler.Unmarshal(data, frame); err != nil {
+		return nil, false, rpcerror.New(
+			rpcerror.Unknown,
+			"the %s could not be unmarshaled",
+			s.frameName,
+		)
+	}
+
+	switch f := frame.GetFrame().(type) {
+	case *proteanpb.ClientFrame_Input:
+		return func(in proto.Message) error {
+			return f.Input.UnmarshalTo(in)
+		}, false, nil
+	case *proteanpb.ClientFrame_Done:
+		return nil, true, nil
+	default:
+		return nil, false, rpcerror.New(
+			rpcerror.Unknown,
+			"the %s does not contain an RPC input message",
+			s.frameName,
+		)
+	}
+}
+
+// WriteOutput writes a frame containing an RPC output message.
+func (s *proteanFrameStream) WriteOutput(out proto.Message) error {
+	output, err := anypb.New(out)
+	if err != nil {
+		return err
+	}
+
+	return s.writeFrame(
+		&proteanpb.ServerFrame{
+			Frame: &proteanpb.ServerFrame_Output{
+				Output: output,
+			},
+		},
+	)
+}
+
+// WriteDone writes a frame indicating that the RPC method succeeded.
+func (s *proteanFrameStream) WriteDone() error {
+	return s.writeFrame(
+		&proteanpb.ServerFrame{
+			Frame: &proteanpb.ServerFrame_Done{
+				Done: &proteanpb.Done{},
+			},
+		},
+	)
+}
+
+// WriteError writes a frame containing an RPC error.
+func (s *proteanFrameStream) WriteError(rpcErr rpcerror.Error) error {
+	var protoErr proteanpb.Error
+	if err := rpcerror.ToProto(rpcErr, &protoErr); err != nil {
+		panic(err)
+	}
+
+	return s.writeFrame(
+		&proteanpb.ServerFrame{
+			Frame: &proteanpb.ServerFrame_Error{
+				Error: &protoErr,
+			},
+		},
+	)
+}
+
+// Close closes the underlying transport.
+func (s *proteanFrameStream) Close() {
+	s.transport.Close()
+}
+
+// writeFrame marshals a frame and writes it to the client.
+func (s *proteanFrameStream) writeFrame(frame *proteanpb.ServerFrame) error {
+	data, err := s.marshaler.Marshal(frame)
+	if err != nil {
+		return err
+	}
+
+	return s.transport.WriteFrame(data)
+}
@@ -0,0 +1,89 @@
+package protean
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/rpcerror"
+	"github.com/dogmatiq/protean/runtime"
+)
+
+// applyTimeout returns a context for the request that has a deadline based
+// on the request's Protean-Timeout header and the handler's maximum timeout.
+//
+// It returns false if the header is invalid, in which case an error response
+// has already been written to w.
+func (h *handler) applyTimeout(
+	w http.ResponseWriter,
+	r *http.Request,
+) (context.Context, context.CancelFunc, bool) {
+	timeout := h.maxTimeout
+
+	if v := r.Header.Get(runtime.TimeoutHeader); v != "" {
+		t, err := runtime.ParseTimeout(v)
+		if err != nil {
+			httpError(
+				w,
+				http.StatusBadRequest,
+				protomime.TextMediaTypes[0],
+				protomime.TextMarshaler,
+				rpcerror.New(
+					rpcerror.Unknown,
+					"the %s header is invalid: %s",
+					runtime.TimeoutHeader,
+					err,
+				),
+			)
+			return nil, nil, false
+		}
+
+		if timeout == 0 || t < timeout {
+			timeout = t
+		}
+	} else if timeout == 0 {
+		return r.Context(), func() {}, true
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	return ctx, cancel, true
+}
+
+// newDeadlineExceededError returns the error that is produced when an RPC call
+// does not complete before its deadline.
+func newDeadlineExceededError() rpcerror.Error {
+	return rpcerror.New(
+		rpcerror.DeadlineExceeded,
+		"the RPC call did not complete before its deadline",
+	)
+}
+
+// deadlineCall is a runtime.Call that reports the expiry of the call's
+// deadline as an rpcerror.DeadlineExceeded error.
+type deadlineCall struct {
+	runtime.Call
+
+	ctx context.Context
+}
+
+// Wait blocks until the RPC method returns.
+//
+// If the RPC method fails with an error other than an rpcerror.Error after the
+// call's deadline has passed, it returns an rpcerror.DeadlineExceeded error
+// instead.
+func (c *deadlineCall) Wait() error {
+	err := c.Call.Wait()
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := err.(rpcerror.Error); ok {
+		return err
+	}
+
+	if c.ctx.Err() == context.DeadlineExceeded {
+		return newDeadlineExceededError()
+	}
+
+	return err
+}
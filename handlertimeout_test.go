@@ -0,0 +1,148 @@
+package protean_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/rpcerror"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("type Handler (timeouts)", func() {
+	var (
+		service     *testservice.Stub
+		deadline    time.Time
+		hasDeadline bool
+	)
+
+	BeforeEach(func() {
+		hasDeadline = false
+
+		service = &testservice.Stub{
+			UnaryFunc: func(
+				ctx context.Context,
+				in *testservice.Input,
+			) (*testservice.Output, error) {
+				deadline, hasDeadline = ctx.Deadline()
+				return &testservice.Output{Data: in.GetData()}, nil
+			},
+		}
+	})
+
+	// post makes a unary POST request with the given Protean-Timeout header.
+	post := func(h Handler, timeout string) *httptest.ResponseRecorder {
+		testservice.RegisterProteanTestService(h, service)
+
+		req := httptest.NewRequest(
+			http.MethodPost,
+			"/protean.test/TestService/Unary",
+			strings.NewReader(`{"data":"<input>"}`),
+		)
+		req.Header.Set("Content-Type", "application/json")
+
+		if timeout != "" {
+			req.Header.Set("Protean-Timeout", timeout)
+		}
+
+		res := httptest.NewRecorder()
+		h.ServeHTTP(res, req)
+
+		return res
+	}
+
+	It("does not apply a deadline by default", func() {
+		res := post(NewHandler(), "")
+		Expect(res).To(HaveHTTPStatus(http.StatusOK))
+		Expect(hasDeadline).To(BeFalse())
+	})
+
+	It("applies the timeout requested by the client", func() {
+		res := post(NewHandler(), "2000")
+		Expect(res).To(HaveHTTPStatus(http.StatusOK))
+		Expect(hasDeadline).To(BeTrue())
+		Expect(time.Until(deadline)).To(BeNumerically("~", 2*time.Second, 500*time.Millisecond))
+	})
+
+	It("caps the timeout requested by the client to the maximum timeout", func() {
+		res := post(NewHandler(WithMaxTimeout(time.Second)), "60000")
+		Expect(res).To(HaveHTTPStatus(http.StatusOK))
+		Expect(hasDeadline).To(BeTrue())
+		Expect(time.Until(deadline)).To(BeNumerically("~", time.Second, 500*time.Millisecond))
+	})
+
+	It("applies the maximum timeout if the client does not request a timeout", func() {
+		res := post(NewHandler(WithMaxTimeout(time.Second)), "")
+		Expect(res).To(HaveHTTPStatus(http.StatusOK))
+		Expect(hasDeadline).To(BeTrue())
+		Expect(time.Until(deadline)).To(BeNumerically("~", time.Second, 500*time.Millisecond))
+	})
+
+	It("responds with an HTTP '504 Gateway Timeout' status if the deadline is exceeded", func() {
+		service.UnaryFunc = func(
+			ctx context.Context,
+			_ *testservice.Input,
+		) (*testservice.Output, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+
+		res := post(NewHandler(), "10")
+
+		expectError(
+			res,
+			http.StatusGatewayTimeout,
+			"application/json; x-proto=protean.v1.Error",
+			rpcerror.New(
+				rpcerror.DeadlineExceeded,
+				"the RPC call did not complete before its deadline",
+			),
+		)
+	})
+
+	It("does not replace errors returned by the RPC method", func() {
+		service.UnaryFunc = func(
+			ctx context.Context,
+			_ *testservice.Input,
+		) (*testservice.Output, error) {
+			<-ctx.Done()
+			return nil, rpcerror.New(rpcerror.Unavailable, "<error>")
+		}
+
+		res := post(NewHandler(), "10")
+
+		expectError(
+			res,
+			http.StatusServiceUnavailable,
+			"application/json; x-proto=protean.v1.Error",
+			rpcerror.New(rpcerror.Unavailable, "<error>"),
+		)
+	})
+
+	It("responds with an HTTP '400 Bad Request' status if the header is invalid", func() {
+		res := post(NewHandler(), "1.5s")
+
+		expectError(
+			res,
+			http.StatusBadRequest,
+			"text/plain; charset=utf-8; x-proto=protean.v1.Error",
+			rpcerror.New(
+				rpcerror.Unknown,
+				"the Protean-Timeout header is invalid: timeout must be a non-negative integer",
+			),
+		)
+	})
+
+	Describe("func WithMaxTimeout()", func() {
+		It("panics if the timeout is not positive", func() {
+			Expect(func() {
+				WithMaxTimeout(0)
+			}).To(PanicWith("maximum timeout must be positive"))
+		})
+	})
+})
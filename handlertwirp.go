@@ -0,0 +1,202 @@
+package protean
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/rpcerror"
+)
+
+// twirpMediaTypes maps each of the media types supported for Twirp requests to
+// the media type used to encode the RPC messages.
+var twirpMediaTypes = map[string]string{
+	"application/protobuf": protomime.BinaryMediaTypes[0],
+	"application/json":     protomime.JSONMediaTypes[0],
+}
+
+// isTwirpRequest returns true if r is a request made to the Twirp path prefix.
+func (h *handler) isTwirpRequest(r *http.Request) bool {
+	return h.twirpPrefix != "" &&
+		strings.HasPrefix(r.URL.Path, h.twirpPrefix+"/")
+}
+
+// serveTwirp serves an RPC request made using the Twirp protocol.
+//
+// Twirp requests identify the RPC method using the
+// "<prefix>/<package>.<service>/<method>" pattern. Only unary RPC methods may
+// be called.
+//
+// See https://twitchtv.github.io/twirp/docs/spec_v7.html.
+func (h *handler) serveTwirp(w http.ResponseWriter, r *http.Request) {
+	_, method, rpcErr, ok := h.resolveQualifiedMethod(
+		strings.TrimPrefix(r.URL.Path, h.twirpPrefix),
+	)
+	if !ok {
+		writeTwirpError(w, twirpBadRoute, rpcErr.Message())
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeTwirpError(w, twirpBadRoute, "the HTTP method must be POST")
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	messageMediaType, ok := twirpMediaTypes[mediaType]
+	if !ok {
+		writeTwirpError(
+			w,
+			twirpBadRoute,
+			"the server does not support the '"+mediaType+"' media-type supplied by the client",
+		)
+		return
+	}
+
+	if method.InputIsStream() || method.OutputIsStream() {
+		writeTwirpError(
+			w,
+			twirpUnimplemented,
+			"the '"+method.Name()+"' RPC method uses streaming inputs or outputs, which are not supported by Twirp",
+		)
+		return
+	}
+
+	data, rpcErr, ok := h.readUnaryInput(r, 0, h.maxInputSizeFor(method))
+	if !ok {
+		writeTwirpError(w, twirpErrorCodeFromErrorCode(rpcErr.Code()), rpcErr.Message())
+		return
+	}
+
+	data, rpcErr, ok = h.invokeUnary(r.Context(), method, messageMediaType, data)
+	if !ok {
+		code := twirpErrorCodeFromErrorCode(rpcErr.Code())
+		if errors.Is(rpcErr, errMalformedInput) {
+			code = twirpMalformed
+		}
+
+		setRetryAfterHeader(w, rpcErr)
+		writeTwirpError(w, code, rpcErr.Message())
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", mediaType)
+	data = h.compressResponseBody(w, r, data)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// twirpErrorCode is a Twirp error code.
+type twirpErrorCode string
+
+const (
+	twirpCanceled           twirpErrorCode = "canceled"
+	twirpUnknown            twirpErrorCode = "unknown"
+	twirpInvalidArgument    twirpErrorCode = "invalid_argument"
+	twirpMalformed          twirpErrorCode = "malformed"
+	twirpDeadlineExceeded   twirpErrorCode = "deadline_exceeded"
+	twirpNotFound           twirpErrorCode = "not_found"
+	twirpBadRoute           twirpErrorCode = "bad_route"
+	twirpAlreadyExists      twirpErrorCode = "already_exists"
+	twirpPermissionDenied   twirpErrorCode = "permission_denied"
+	twirpUnauthenticated    twirpErrorCode = "unauthenticated"
+	twirpResourceExhausted  twirpErrorCode = "resource_exhausted"
+	twirpFailedPrecondition twirpErrorCode = "failed_precondition"
+	twirpAborted            twirpErrorCode = "aborted"
+	twirpUnimplemented      twirpErrorCode = "unimplemented"
+	twirpUnavailable        twirpErrorCode = "unavailable"
+)
+
+// twirpError is the JSON representation of an error in the Twirp protocol.
+type twirpError struct {
+	Code    twirpErrorCode `json:"code"`
+	Message string         `json:"msg"`
+}
+
+// writeTwirpError writes a Twirp error response to w.
+func writeTwirpError(
+	w http.ResponseWriter,
+	code twirpErrorCode,
+	message string,
+) {
+	data, err := json.Marshal(twirpError{code, message})
+	if err != nil {
+		panic(err)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", protomime.JSONMediaTypes[0])
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(twirpHTTPStatus(code))
+	_, _ = w.Write(data)
+}
+
+// twirpErrorCodeFromErrorCode returns the Twirp error code to send when an
+// error with the given code occurs.
+func twirpErrorCodeFromErrorCode(c rpcerror.Code) twirpErrorCode {
+	switch c {
+	case rpcerror.DeadlineExceeded:
+		return twirpDeadlineExceeded
+	case rpcerror.Canceled:
+		return twirpCanceled
+	case rpcerror.InvalidInput:
+		return twirpInvalidArgument
+	case rpcerror.Unauthenticated:
+		return twirpUnauthenticated
+	case rpcerror.PermissionDenied:
+		return twirpPermissionDenied
+	case rpcerror.NotFound:
+		return twirpNotFound
+	case rpcerror.AlreadyExists:
+		return twirpAlreadyExists
+	case rpcerror.ResourceExhausted:
+		return twirpResourceExhausted
+	case rpcerror.FailedPrecondition:
+		return twirpFailedPrecondition
+	case rpcerror.Aborted:
+		return twirpAborted
+	case rpcerror.Unavailable:
+		return twirpUnavailable
+	case rpcerror.NotImplemented:
+		return twirpUnimplemented
+	}
+
+	return twirpUnknown
+}
+
+// twirpHTTPStatus returns the HTTP status that Twirp associates with the given
+// error code.
+func twirpHTTPStatus(c twirpErrorCode) int {
+	switch c {
+	case twirpCanceled, twirpDeadlineExceeded:
+		return http.StatusRequestTimeout
+	case twirpInvalidArgument, twirpMalformed:
+		return http.StatusBadRequest
+	case twirpNotFound, twirpBadRoute:
+		return http.StatusNotFound
+	case twirpAlreadyExists, twirpAborted:
+		return http.StatusConflict
+	case twirpPermissionDenied:
+		return http.StatusForbidden
+	case twirpUnauthenticated:
+		return http.StatusUnauthorized
+	case twirpResourceExhausted:
+		return http.StatusTooManyRequests
+	case twirpFailedPrecondition:
+		return http.StatusPreconditionFailed
+	case twirpUnimplemented:
+		return http.StatusNotImplemented
+	case twirpUnavailable:
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusInternalServerError
+}
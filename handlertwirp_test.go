@@ -0,0 +1,200 @@
+package protean_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/rpcerror"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ = Describe("type Handler (Twirp)", func() {
+	var (
+		ctx      context.Context
+		cancel   context.CancelFunc
+		handler  Handler
+		service  *testservice.Stub
+		response *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		format.TruncatedDiff = false
+
+		ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+
+		handler = NewHandler(
+			WithTwirpPrefix("/twirp"),
+		)
+
+		service = &testservice.Stub{
+			UnaryFunc: func(
+				_ context.Context,
+				in *testservice.Input,
+			) (*testservice.Output, error) {
+				return &testservice.Output{
+					Data: strings.ToUpper(in.GetData()),
+				}, nil
+			},
+		}
+
+		testservice.RegisterProteanTestService(handler, service)
+
+		response = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		format.TruncatedDiff = true
+		cancel()
+	})
+
+	// post makes a Twirp request to the given URL path.
+	post := func(path, contentType, body string) {
+		req := httptest.NewRequest(
+			http.MethodPost,
+			path,
+			strings.NewReader(body),
+		).WithContext(ctx)
+		req.Header.Set("Content-Type", contentType)
+
+		handler.ServeHTTP(response, req)
+	}
+
+	Describe("func ServeHTTP()", func() {
+		It("calls unary methods using JSON", func() {
+			post(
+				"/twirp/protean.test.TestService/Unary",
+				"application/json",
+				`{"data":"hello"}`,
+			)
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header().Get("Content-Type")).To(Equal("application/json"))
+			Expect(response.Body.String()).To(MatchJSON(`{"data":"HELLO"}`))
+		})
+
+		It("calls unary methods using protocol buffers", func() {
+			data, err := proto.Marshal(&testservice.Input{Data: "hello"})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			post(
+				"/twirp/protean.test.TestService/Unary",
+				"application/protobuf",
+				string(data),
+			)
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Header().Get("Content-Type")).To(Equal("application/protobuf"))
+
+			out := &testservice.Output{}
+			err = proto.Unmarshal(response.Body.Bytes(), out)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(out.GetData()).To(Equal("HELLO"))
+		})
+
+		It("does not affect requests that use the regular URL path pattern", func() {
+			post(
+				"/protean.test/TestService/Unary",
+				"application/json",
+				`{"data":"hello"}`,
+			)
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Body.String()).To(MatchJSON(`{"data":"HELLO"}`))
+		})
+
+		DescribeTable(
+			"it responds with a Twirp error",
+			func(
+				setup func(),
+				path, contentType, body string,
+				status int,
+				expect string,
+			) {
+				if setup != nil {
+					setup()
+				}
+
+				post(path, contentType, body)
+
+				Expect(response.Code).To(Equal(status))
+				Expect(response.Header().Get("Content-Type")).To(Equal("application/json"))
+				Expect(response.Body.String()).To(MatchJSON(expect))
+			},
+			Entry(
+				"when the method does not exist",
+				nil,
+				"/twirp/protean.test.TestService/Unknown",
+				"application/json",
+				`{}`,
+				http.StatusNotFound,
+				`{"code":"bad_route","msg":"the 'protean.test.TestService' service does not contain an RPC method named 'Unknown'"}`,
+			),
+			Entry(
+				"when the media-type is not supported",
+				nil,
+				"/twirp/protean.test.TestService/Unary",
+				"text/plain",
+				`data: "hello"`,
+				http.StatusNotFound,
+				`{"code":"bad_route","msg":"the server does not support the 'text/plain' media-type supplied by the client"}`,
+			),
+			Entry(
+				"when the method uses streaming",
+				nil,
+				"/twirp/protean.test.TestService/ServerStream",
+				"application/json",
+				`{}`,
+				http.StatusNotImplemented,
+				`{"code":"unimplemented","msg":"the 'ServerStream' RPC method uses streaming inputs or outputs, which are not supported by Twirp"}`,
+			),
+			Entry(
+				"when the input message is malformed",
+				nil,
+				"/twirp/protean.test.TestService/Unary",
+				"application/json",
+				`}`,
+				http.StatusBadRequest,
+				`{"code":"malformed","msg":"the RPC input message could not be unmarshaled from the request"}`,
+			),
+			Entry(
+				"when the RPC method returns an error",
+				func() {
+					service.UnaryFunc = func(
+						context.Context,
+						*testservice.Input,
+					) (*testservice.Output, error) {
+						return nil, rpcerror.New(rpcerror.InvalidInput, "<error>")
+					}
+				},
+				"/twirp/protean.test.TestService/Unary",
+				"application/json",
+				`{"data":"hello"}`,
+				http.StatusBadRequest,
+				`{"code":"invalid_argument","msg":"<error>"}`,
+			),
+		)
+	})
+})
+
+var _ = Describe("func WithTwirpPrefix()", func() {
+	It("panics if the prefix does not begin with a slash", func() {
+		Expect(func() {
+			WithTwirpPrefix("twirp")
+		}).To(PanicWith("Twirp path prefix must begin with a slash"))
+	})
+
+	It("panics if the prefix is empty", func() {
+		Expect(func() {
+			WithTwirpPrefix("/")
+		}).To(PanicWith("Twirp path prefix must not be empty"))
+	})
+})
@@ -0,0 +1,161 @@
+package protean
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/rpcerror"
+	"github.com/dogmatiq/protean/runtime"
+	"github.com/gorilla/websocket"
+)
+
+// webSocketSubprotocols is the set of websocket sub-protocols supported by the
+// handler, in order of preference.
+//
+// The sub-protocol determines the encoding used for the messages in each
+// websocket frame.
+var webSocketSubprotocols = []string{
+	"protean.v1+proto",
+	"protean.v1+json",
+	"protean.v1+text",
+}
+
+// webSocketMediaTypes maps each of the supported websocket sub-protocols to
+// the media type used to encode its frames.
+var webSocketMediaTypes = map[string]string{
+	"protean.v1+proto": protomime.BinaryMediaTypes[0],
+	"protean.v1+json":  protomime.JSONMediaTypes[0],
+	"protean.v1+text":  protomime.TextMediaTypes[0],
+}
+
+// webSocketCloseTimeout is the amount of time to wait when sending a websocket
+// "close" frame.
+const webSocketCloseTimeout = 5 * time.Second
+
+// serveWebSocket serves an RPC request made by upgrading the HTTP connection
+// to a websocket.
+func (h *handler) serveWebSocket(
+	w http.ResponseWriter,
+	r *http.Request,
+	method runtime.Method,
+) {
+	if !hasWebSocketSubprotocol(r) {
+		httpError(
+			w,
+			http.StatusBadRequest,
+			protomime.TextMediaTypes[0],
+			protomime.TextMarshaler,
+			rpcerror.New(
+				rpcerror.Unknown,
+				"the client must request one of the following websocket sub-protocols: %s",
+				strings.Join(webSocketSubprotocols, ", "),
+			),
+		)
+		return
+	}
+
+	upgrader := websocket.Upgrader{
+		Subprotocols: webSocketSubprotocols,
+		CheckOrigin:  h.checkWebSocketOrigin,
+		Error: func(
+			w http.ResponseWriter,
+			_ *http.Request,
+			status int,
+			reason error,
+		) {
+			httpError(
+				w,
+				status,
+				protomime.TextMediaTypes[0],
+				protomime.TextMarshaler,
+				rpcerror.New(
+					rpcerror.Unknown,
+					"the websocket connection could not be established: %s",
+					reason,
+				),
+			)
+		},
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already written an error response.
+		return
+	}
+
+	conn.SetReadLimit(int64(h.maxInputSizeFor(method)))
+
+	mediaType := webSocketMediaTypes[conn.Subprotocol()]
+	marshaler, _ := protomime.MarshalerForMediaType(mediaType)
+	unmarshaler, _ := protomime.UnmarshalerForMediaType(mediaType)
+
+	messageType := websocket.TextMessage
+	if protomime.IsBinary(mediaType) {
+		messageType = websocket.BinaryMessage
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	c := &streamCall{
+		stream: &proteanFrameStream{
+			transport: &webSocketTransport{
+				conn:        conn,
+				messageType: messageType,
+			},
+			frameName:   "websocket frame",
+			marshaler:   marshaler,
+			unmarshaler: unmarshaler,
+		},
+		frameName: "websocket frame",
+		method:    method,
+		cancel:    cancel,
+		call:      h.newCall(ctx, method),
+	}
+
+	c.run()
+}
+
+// hasWebSocketSubprotocol returns true if r requests at least one of the
+// supported websocket sub-protocols.
+func hasWebSocketSubprotocol(r *http.Request) bool {
+	for _, p := range websocket.Subprotocols(r) {
+		if _, ok := webSocketMediaTypes[p]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// webSocketTransport is a frameTransport that exchanges frames over a websocket
+// connection.
+type webSocketTransport struct {
+	conn        *websocket.Conn
+	messageType int
+}
+
+// ReadFrame reads the next frame sent by the client.
+func (t *webSocketTransport) ReadFrame() ([]byte, error) {
+	_, data, err := t.conn.ReadMessage()
+	return data, err
+}
+
+// WriteFrame writes a frame to the client.
+func (t *webSocketTransport) WriteFrame(data []byte) error {
+	return t.conn.WriteMessage(t.messageType, data)
+}
+
+// Close sends a websocket "close" frame and closes the connection.
+func (t *webSocketTransport) Close() {
+	_ = t.conn.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(webSocketCloseTimeout),
+	)
+
+	t.conn.Close()
+}
@@ -0,0 +1,415 @@
+package protean_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/internal/proteanpb"
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/rpcerror"
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+var _ = Describe("type Handler (websocket)", func() {
+	var (
+		ctx     context.Context
+		cancel  context.CancelFunc
+		handler Handler
+		service *testservice.Stub
+		server  *httptest.Server
+	)
+
+	BeforeEach(func() {
+		format.TruncatedDiff = false
+
+		ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+
+		handler = NewHandler()
+
+		service = &testservice.Stub{
+			UnaryFunc: func(
+				_ context.Context,
+				in *testservice.Input,
+			) (*testservice.Output, error) {
+				return &testservice.Output{
+					Data: strings.ToUpper(in.GetData()),
+				}, nil
+			},
+			ClientStreamFunc: func(
+				ctx context.Context,
+				inputs <-chan *testservice.Input,
+			) (*testservice.Output, error) {
+				var data []string
+
+				for {
+					select {
+					case <-ctx.Done():
+						return nil, ctx.Err()
+					case in, ok := <-inputs:
+						if !ok {
+							return &testservice.Output{
+								Data: strings.Join(data, ","),
+							}, nil
+						}
+
+						data = append(data, in.GetData())
+					}
+				}
+			},
+			ServerStreamFunc: func(
+				ctx context.Context,
+				in *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				defer close(outputs)
+
+				for _, r := range in.GetData() {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case outputs <- &testservice.Output{Data: string(r)}:
+					}
+				}
+
+				return nil
+			},
+			BidirectionalStreamFunc: func(
+				ctx context.Context,
+				inputs <-chan *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				defer close(outputs)
+
+				for {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case in, ok := <-inputs:
+						if !ok {
+							return nil
+						}
+
+						select {
+						case <-ctx.Done():
+							return ctx.Err()
+						case outputs <- &testservice.Output{Data: strings.ToUpper(in.GetData())}:
+						}
+					}
+				}
+			},
+		}
+
+		testservice.RegisterProteanTestService(handler, service)
+
+		server = httptest.NewServer(handler)
+	})
+
+	AfterEach(func() {
+		format.TruncatedDiff = true
+		cancel()
+
+		server.Close()
+	})
+
+	// dial opens a websocket connection to the given RPC method.
+	dial := func(method, subprotocol string) *websocket.Conn {
+		dialer := websocket.Dialer{
+			Subprotocols: []string{subprotocol},
+		}
+
+		conn, res, err := dialer.DialContext(
+			ctx,
+			"ws"+strings.TrimPrefix(server.URL, "http")+"/protean.test/TestService/"+method,
+			nil,
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+		res.Body.Close()
+
+		Expect(conn.Subprotocol()).To(Equal(subprotocol))
+
+		return conn
+	}
+
+	Describe("func ServeHTTP()", func() {
+		DescribeTable(
+			"it supports unary RPC methods",
+			func(subprotocol, mediaType string) {
+				conn := dial("Unary", subprotocol)
+				defer conn.Close()
+
+				sendInput(conn, mediaType, &testservice.Input{Data: "<input>"})
+
+				expectOutput(conn, mediaType, "<INPUT>")
+				expectDone(conn, mediaType)
+			},
+			Entry("binary", "protean.v1+proto", "application/vnd.google.protobuf"),
+			Entry("JSON", "protean.v1+json", "application/json"),
+			Entry("text", "protean.v1+text", "text/plain"),
+		)
+
+		It("supports client streaming RPC methods", func() {
+			conn := dial("ClientStream", "protean.v1+json")
+			defer conn.Close()
+
+			sendInput(conn, "application/json", &testservice.Input{Data: "<one>"})
+			sendInput(conn, "application/json", &testservice.Input{Data: "<two>"})
+			sendDone(conn, "application/json")
+
+			expectOutput(conn, "application/json", "<one>,<two>")
+			expectDone(conn, "application/json")
+		})
+
+		It("supports server streaming RPC methods", func() {
+			conn := dial("ServerStream", "protean.v1+json")
+			defer conn.Close()
+
+			sendInput(conn, "application/json", &testservice.Input{Data: "abc"})
+
+			expectOutput(conn, "application/json", "a")
+			expectOutput(conn, "application/json", "b")
+			expectOutput(conn, "application/json", "c")
+			expectDone(conn, "application/json")
+		})
+
+		It("supports bidirectional streaming RPC methods", func() {
+			conn := dial("BidirectionalStream", "protean.v1+proto")
+			defer conn.Close()
+
+			sendInput(conn, "application/vnd.google.protobuf", &testservice.Input{Data: "<one>"})
+			expectOutput(conn, "application/vnd.google.protobuf", "<ONE>")
+
+			sendInput(conn, "application/vnd.google.protobuf", &testservice.Input{Data: "<two>"})
+			expectOutput(conn, "application/vnd.google.protobuf", "<TWO>")
+
+			sendDone(conn, "application/vnd.google.protobuf")
+			expectDone(conn, "application/vnd.google.protobuf")
+		})
+
+		It("sends the error returned by the RPC method in the final frame", func() {
+			service.ServerStreamFunc = func(
+				_ context.Context,
+				_ *testservice.Input,
+				outputs chan<- *testservice.Output,
+			) error {
+				close(outputs)
+				return rpcerror.New(rpcerror.NotFound, "<error>")
+			}
+
+			conn := dial("ServerStream", "protean.v1+json")
+			defer conn.Close()
+
+			sendInput(conn, "application/json", &testservice.Input{Data: "<input>"})
+
+			expectFrameError(
+				conn,
+				"application/json",
+				rpcerror.New(rpcerror.NotFound, "<error>"),
+			)
+		})
+
+		It("applies the interceptor to unary RPC methods", func() {
+			conn := dial("Unary", "protean.v1+json")
+			defer conn.Close()
+
+			sendInput(conn, "application/json", &testservice.Input{})
+
+			expectFrameError(
+				conn,
+				"application/json",
+				rpcerror.New(
+					rpcerror.InvalidInput,
+					"the RPC input message is invalid: input data must not be empty",
+				),
+			)
+		})
+
+		It("sends an error if the client sends a frame after the end of the input stream", func() {
+			service.UnaryFunc = func(
+				ctx context.Context,
+				_ *testservice.Input,
+			) (*testservice.Output, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+
+			conn := dial("Unary", "protean.v1+json")
+			defer conn.Close()
+
+			sendInput(conn, "application/json", &testservice.Input{Data: "<input>"})
+			sendInput(conn, "application/json", &testservice.Input{Data: "<input>"})
+
+			expectFrameError(
+				conn,
+				"application/json",
+				rpcerror.New(
+					rpcerror.Unknown,
+					"the client sent a websocket frame after the end of the RPC input stream",
+				),
+			)
+		})
+
+		It("sends an error if the input stream of a unary method is ended without an input message", func() {
+			conn := dial("Unary", "protean.v1+json")
+			defer conn.Close()
+
+			sendDone(conn, "application/json")
+
+			expectFrameError(
+				conn,
+				"application/json",
+				rpcerror.New(
+					rpcerror.Unknown,
+					"the client ended the RPC input stream without sending an RPC input message",
+				),
+			)
+		})
+
+		It("cancels the call's context when the client closes the connection", func() {
+			canceled := make(chan struct{})
+
+			service.ClientStreamFunc = func(
+				ctx context.Context,
+				_ <-chan *testservice.Input,
+			) (*testservice.Output, error) {
+				<-ctx.Done()
+				close(canceled)
+				return nil, ctx.Err()
+			}
+
+			conn := dial("ClientStream", "protean.v1+json")
+			conn.Close()
+
+			Eventually(canceled).Should(BeClosed())
+		})
+
+		It("responds with an HTTP '400 Bad Request' status if the client does not request a supported sub-protocol", func() {
+			dialer := websocket.Dialer{
+				Subprotocols: []string{"unsupported"},
+			}
+
+			_, res, err := dialer.DialContext(
+				ctx,
+				"ws"+strings.TrimPrefix(server.URL, "http")+"/protean.test/TestService/Unary",
+				nil,
+			)
+			Expect(err).To(MatchError(websocket.ErrBadHandshake))
+			defer res.Body.Close()
+
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
+
+// sendInput sends a websocket frame containing an RPC input message.
+func sendInput(conn *websocket.Conn, mediaType string, in proto.Message) {
+	input, err := anypb.New(in)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	sendFrame(
+		conn,
+		mediaType,
+		&proteanpb.ClientFrame{
+			Frame: &proteanpb.ClientFrame_Input{
+				Input: input,
+			},
+		},
+	)
+}
+
+// sendDone sends a websocket frame that ends the RPC input stream.
+func sendDone(conn *websocket.Conn, mediaType string) {
+	sendFrame(
+		conn,
+		mediaType,
+		&proteanpb.ClientFrame{
+			Frame: &proteanpb.ClientFrame_Done{
+				Done: &proteanpb.Done{},
+			},
+		},
+	)
+}
+
+// sendFrame sends a websocket frame to the server.
+func sendFrame(conn *websocket.Conn, mediaType string, frame *proteanpb.ClientFrame) {
+	marshaler, ok := protomime.MarshalerForMediaType(mediaType)
+	Expect(ok).To(BeTrue())
+
+	data, err := marshaler.Marshal(frame)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	messageType := websocket.TextMessage
+	if protomime.IsBinary(mediaType) {
+		messageType = websocket.BinaryMessage
+	}
+
+	err = conn.WriteMessage(messageType, data)
+	Expect(err).ShouldNot(HaveOccurred())
+}
+
+// readFrame reads the next websocket frame sent by the server.
+func readFrame(conn *websocket.Conn, mediaType string) *proteanpb.ServerFrame {
+	err := conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	Expect(err).ShouldNot(HaveOccurred())
+
+	messageType, data, err := conn.ReadMessage()
+	Expect(err).ShouldNot(HaveOccurred())
+
+	if protomime.IsBinary(mediaType) {
+		Expect(messageType).To(Equal(websocket.BinaryMessage))
+	} else {
+		Expect(messageType).To(Equal(websocket.TextMessage))
+	}
+
+	unmarshaler, ok := protomime.UnmarshalerForMediaType(mediaType)
+	Expect(ok).To(BeTrue())
+
+	frame := &proteanpb.ServerFrame{}
+	err = unmarshaler.Unmarshal(data, frame)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	return frame
+}
+
+// expectOutput asserts that the next websocket frame contains an RPC output
+// message with the given data.
+func expectOutput(conn *websocket.Conn, mediaType, data string) {
+	frame := readFrame(conn, mediaType)
+	Expect(frame.GetOutput()).NotTo(BeNil(), "expected an output frame, got %s", frame)
+
+	out := &testservice.Output{}
+	err := frame.GetOutput().UnmarshalTo(out)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	Expect(out.GetData()).To(Equal(data))
+}
+
+// expectDone asserts that the next websocket frame indicates that the RPC
+// method completed successfully.
+func expectDone(conn *websocket.Conn, mediaType string) {
+	frame := readFrame(conn, mediaType)
+	Expect(frame.GetDone()).NotTo(BeNil(), "expected a done frame, got %s", frame)
+}
+
+// expectFrameError asserts that the next websocket frame contains the expected
+// error.
+func expectFrameError(conn *websocket.Conn, mediaType string, expect rpcerror.Error) {
+	frame := readFrame(conn, mediaType)
+	Expect(frame.GetError()).NotTo(BeNil(), "expected an error frame, got %s", frame)
+
+	actual, err := rpcerror.FromProto(frame.GetError())
+	Expect(err).ShouldNot(HaveOccurred())
+
+	Expect(actual.Code()).To(Equal(expect.Code()))
+	Expect(actual.Message()).To(Equal(expect.Message()))
+}
@@ -0,0 +1,9 @@
+// Package health provides a service that reports whether a server, and the
+// individual services that it hosts, are able to handle requests.
+//
+// The Health service is modelled on gRPC's grpc.health.v1.Health service. It
+// can be registered with a Protean handler like any other service, and
+// additionally provides plain HTTP GET endpoints for liveness and readiness
+// probes that can not use Protocol Buffers, such as those used by load
+// balancers and Kubernetes.
+package health
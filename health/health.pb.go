@@ -0,0 +1,243 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.32.0
+// source: github.com/dogmatiq/protean/health/health.proto
+
+package health
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ServingStatus is the serving status of a service.
+type HealthCheckResponse_ServingStatus int32
+
+const (
+	HealthCheckResponse_UNKNOWN     HealthCheckResponse_ServingStatus = 0
+	HealthCheckResponse_SERVING     HealthCheckResponse_ServingStatus = 1
+	HealthCheckResponse_NOT_SERVING HealthCheckResponse_ServingStatus = 2
+	// SERVICE_UNKNOWN is used only by the Watch method.
+	HealthCheckResponse_SERVICE_UNKNOWN HealthCheckResponse_ServingStatus = 3
+)
+
+// Enum value maps for HealthCheckResponse_ServingStatus.
+var (
+	HealthCheckResponse_ServingStatus_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "SERVING",
+		2: "NOT_SERVING",
+		3: "SERVICE_UNKNOWN",
+	}
+	HealthCheckResponse_ServingStatus_value = map[string]int32{
+		"UNKNOWN":         0,
+		"SERVING":         1,
+		"NOT_SERVING":     2,
+		"SERVICE_UNKNOWN": 3,
+	}
+)
+
+func (x HealthCheckResponse_ServingStatus) Enum() *HealthCheckResponse_ServingStatus {
+	p := new(HealthCheckResponse_ServingStatus)
+	*p = x
+	return p
+}
+
+func (x HealthCheckResponse_ServingStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (HealthCheckResponse_ServingStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_github_com_dogmatiq_protean_health_health_proto_enumTypes[0].Descriptor()
+}
+
+func (HealthCheckResponse_ServingStatus) Type() protoreflect.EnumType {
+	return &file_github_com_dogmatiq_protean_health_health_proto_enumTypes[0]
+}
+
+func (x HealthCheckResponse_ServingStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use HealthCheckResponse_ServingStatus.Descriptor instead.
+func (HealthCheckResponse_ServingStatus) EnumDescriptor() ([]byte, []int) {
+	return file_github_com_dogmatiq_protean_health_health_proto_rawDescGZIP(), []int{1, 0}
+}
+
+// HealthCheckRequest is the input message for the Check and Watch methods.
+type HealthCheckRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Service is the fully-qualified name of the service to check, or an empty
+	// string to check the server as a whole.
+	Service       string `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthCheckRequest) Reset() {
+	*x = HealthCheckRequest{}
+	mi := &file_github_com_dogmatiq_protean_health_health_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthCheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthCheckRequest) ProtoMessage() {}
+
+func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_dogmatiq_protean_health_health_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthCheckRequest.ProtoReflect.Descriptor instead.
+func (*HealthCheckRequest) Descriptor() ([]byte, []int) {
+	return file_github_com_dogmatiq_protean_health_health_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *HealthCheckRequest) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+// HealthCheckResponse is the output message for the Check and Watch methods.
+type HealthCheckResponse struct {
+	state         protoimpl.MessageState            `protogen:"open.v1"`
+	Status        HealthCheckResponse_ServingStatus `protobuf:"varint,1,opt,name=status,proto3,enum=protean.health.v1.HealthCheckResponse_ServingStatus" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthCheckResponse) Reset() {
+	*x = HealthCheckResponse{}
+	mi := &file_github_com_dogmatiq_protean_health_health_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthCheckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthCheckResponse) ProtoMessage() {}
+
+func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_dogmatiq_protean_health_health_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthCheckResponse.ProtoReflect.Descriptor instead.
+func (*HealthCheckResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_dogmatiq_protean_health_health_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HealthCheckResponse) GetStatus() HealthCheckResponse_ServingStatus {
+	if x != nil {
+		return x.Status
+	}
+	return HealthCheckResponse_UNKNOWN
+}
+
+var File_github_com_dogmatiq_protean_health_health_proto protoreflect.FileDescriptor
+
+const file_github_com_dogmatiq_protean_health_health_proto_rawDesc = "" +
+	"\n" +
+	"/github.com/dogmatiq/protean/health/health.proto\x12\x11protean.health.v1\".\n" +
+	"\x12HealthCheckRequest\x12\x18\n" +
+	"\aservice\x18\x01 \x01(\tR\aservice\"\xb4\x01\n" +
+	"\x13HealthCheckResponse\x12L\n" +
+	"\x06status\x18\x01 \x01(\x0e24.protean.health.v1.HealthCheckResponse.ServingStatusR\x06status\"O\n" +
+	"\rServingStatus\x12\v\n" +
+	"\aUNKNOWN\x10\x00\x12\v\n" +
+	"\aSERVING\x10\x01\x12\x0f\n" +
+	"\vNOT_SERVING\x10\x02\x12\x13\n" +
+	"\x0fSERVICE_UNKNOWN\x10\x032\xbf\x01\n" +
+	"\x06Health\x12[\n" +
+	"\x05Check\x12%.protean.health.v1.HealthCheckRequest\x1a&.protean.health.v1.HealthCheckResponse\"\x03\x90\x02\x01\x12X\n" +
+	"\x05Watch\x12%.protean.health.v1.HealthCheckRequest\x1a&.protean.health.v1.HealthCheckResponse0\x01B$Z\"github.com/dogmatiq/protean/healthb\x06proto3"
+
+var (
+	file_github_com_dogmatiq_protean_health_health_proto_rawDescOnce sync.Once
+	file_github_com_dogmatiq_protean_health_health_proto_rawDescData []byte
+)
+
+func file_github_com_dogmatiq_protean_health_health_proto_rawDescGZIP() []byte {
+	file_github_com_dogmatiq_protean_health_health_proto_rawDescOnce.Do(func() {
+		file_github_com_dogmatiq_protean_health_health_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_github_com_dogmatiq_protean_health_health_proto_rawDesc), len(file_github_com_dogmatiq_protean_health_health_proto_rawDesc)))
+	})
+	return file_github_com_dogmatiq_protean_health_health_proto_rawDescData
+}
+
+var file_github_com_dogmatiq_protean_health_health_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_github_com_dogmatiq_protean_health_health_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_github_com_dogmatiq_protean_health_health_proto_goTypes = []any{
+	(HealthCheckResponse_ServingStatus)(0), // 0: protean.health.v1.HealthCheckResponse.ServingStatus
+	(*HealthCheckRequest)(nil),             // 1: protean.health.v1.HealthCheckRequest
+	(*HealthCheckResponse)(nil),            // 2: protean.health.v1.HealthCheckResponse
+}
+var file_github_com_dogmatiq_protean_health_health_proto_depIdxs = []int32{
+	0, // 0: protean.health.v1.HealthCheckResponse.status:type_name -> protean.health.v1.HealthCheckResponse.ServingStatus
+	1, // 1: protean.health.v1.Health.Check:input_type -> protean.health.v1.HealthCheckRequest
+	1, // 2: protean.health.v1.Health.Watch:input_type -> protean.health.v1.HealthCheckRequest
+	2, // 3: protean.health.v1.Health.Check:output_type -> protean.health.v1.HealthCheckResponse
+	2, // 4: protean.health.v1.Health.Watch:output_type -> protean.health.v1.HealthCheckResponse
+	3, // [3:5] is the sub-list for method output_type
+	1, // [1:3] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_github_com_dogmatiq_protean_health_health_proto_init() }
+func file_github_com_dogmatiq_protean_health_health_proto_init() {
+	if File_github_com_dogmatiq_protean_health_health_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_github_com_dogmatiq_protean_health_health_proto_rawDesc), len(file_github_com_dogmatiq_protean_health_health_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_github_com_dogmatiq_protean_health_health_proto_goTypes,
+		DependencyIndexes: file_github_com_dogmatiq_protean_health_health_proto_depIdxs,
+		EnumInfos:         file_github_com_dogmatiq_protean_health_health_proto_enumTypes,
+		MessageInfos:      file_github_com_dogmatiq_protean_health_health_proto_msgTypes,
+	}.Build()
+	File_github_com_dogmatiq_protean_health_health_proto = out.File
+	file_github_com_dogmatiq_protean_health_health_proto_goTypes = nil
+	file_github_com_dogmatiq_protean_health_health_proto_depIdxs = nil
+}
@@ -0,0 +1,318 @@
+// Code generated by protoc-gen-go-protean. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go-protean v
+// 	protoc                v6.32.0
+// source: github.com/dogmatiq/protean/health/health.proto
+
+package health
+
+import (
+	"context"
+	"errors"
+	protean "github.com/dogmatiq/protean"
+	middleware "github.com/dogmatiq/protean/middleware"
+	runtime "github.com/dogmatiq/protean/runtime"
+	proto "google.golang.org/protobuf/proto"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	"net/url"
+)
+
+// ProteanHealth is an interface for the protean.health.v1.Health service.
+//
+// Health is a service that reports whether a server, or the individual services
+// that it hosts, are able to handle requests.
+//
+// It is modelled on the grpc.health.v1.Health service. A different package
+// name is used so that it does not conflict with the gRPC definitions when
+// both are linked into the same binary.
+type ProteanHealth interface {
+	// Check returns the current serving status of a service.
+	//
+	// An empty service name refers to the server as a whole. It fails with a
+	// "not found" error if the service is unknown.
+	Check(ctx context.Context, in *HealthCheckRequest) (*HealthCheckResponse, error)
+
+	// Watch streams the serving status of a service.
+	//
+	// The current status is sent immediately, followed by a new message each
+	// time the status changes. If the service is unknown, the status is
+	// SERVICE_UNKNOWN until the service's status is set.
+	//
+	// The caller MUST NOT close the outputs channel. The implementation MUST
+	// close the outputs channel before returning.
+	Watch(ctx context.Context, in *HealthCheckRequest, outputs chan<- *HealthCheckResponse) error
+}
+
+// RegisterProteanHealth registers a ProteanHealth service with a Protean registry.
+func RegisterProteanHealth(r runtime.Registry, s ProteanHealth) {
+	r.RegisterService(&proteanService_Health{
+		proteanMethod_Health_Check{s},
+		proteanMethod_Health_Watch{s},
+	})
+}
+
+// NewProteanHealthClient returns a new client for the protean.health.v1.Health service.
+func NewProteanHealthClient(baseURL *url.URL, options ...protean.ClientOption) ProteanHealth {
+	var opts runtime.ClientOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	return &proteanClient_Health{runtime.NewClient(baseURL, opts)}
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// proteanService_Health is a runtime.Service implementation for the protean.health.v1.Health service.
+type proteanService_Health struct {
+	methodCheck proteanMethod_Health_Check
+	methodWatch proteanMethod_Health_Watch
+}
+
+func (s *proteanService_Health) Name() string {
+	return "Health"
+}
+
+func (s *proteanService_Health) Package() string {
+	return "protean.health.v1"
+}
+
+func (s *proteanService_Health) Descriptor() protoreflect.ServiceDescriptor {
+	return File_github_com_dogmatiq_protean_health_health_proto.Services().ByName("Health")
+}
+
+func (s *proteanService_Health) MethodByName(name string) (runtime.Method, bool) {
+	switch name {
+	case "Check":
+		return &s.methodCheck, true
+	case "Watch":
+		return &s.methodWatch, true
+	}
+	return nil, false
+}
+
+// proteanMethod_Health_Check is a runtime.Method implementation for the protean.health.v1.Health.Check() method.
+type proteanMethod_Health_Check struct {
+	service ProteanHealth
+}
+
+func (m *proteanMethod_Health_Check) Name() string {
+	return "Check"
+}
+
+func (m *proteanMethod_Health_Check) InputIsStream() bool {
+	return false
+}
+
+func (m *proteanMethod_Health_Check) OutputIsStream() bool {
+	return false
+}
+
+func (m *proteanMethod_Health_Check) HasSideEffects() bool {
+	return false
+}
+
+func (m *proteanMethod_Health_Check) Descriptor() protoreflect.MethodDescriptor {
+	return File_github_com_dogmatiq_protean_health_health_proto.Services().ByName("Health").Methods().ByName("Check")
+}
+
+func (m *proteanMethod_Health_Check) MaxInputSize() int {
+	return 0
+}
+
+func (m *proteanMethod_Health_Check) MaxOutputSize() int {
+	return 0
+}
+
+func (m *proteanMethod_Health_Check) RequiredScopes() []string {
+	return nil
+}
+
+func (m *proteanMethod_Health_Check) NewCall(ctx context.Context, options runtime.CallOptions) runtime.Call {
+	return newProteanCall_Health_Check(ctx, m.service, options)
+}
+
+// newProteanCall_Health_Check returns a new runtime.Call for the protean.health.v1.Health.Check() method.
+func newProteanCall_Health_Check(ctx context.Context, service ProteanHealth, options runtime.CallOptions) runtime.Call {
+	return &proteanCall_Health_Check{ctx, service, options.Interceptor, make(chan *HealthCheckRequest, 1), nil}
+}
+
+// proteanMethod_Health_Check is a runtime.Call implementation for the protean.health.v1.Health.Check() method.
+type proteanCall_Health_Check struct {
+	ctx         context.Context
+	service     ProteanHealth
+	interceptor middleware.ServerInterceptor
+	in          chan *HealthCheckRequest
+	err         error
+}
+
+func (c *proteanCall_Health_Check) Send(unmarshal runtime.Unmarshaler) (bool, error) {
+	in := &HealthCheckRequest{}
+	if err := unmarshal(in); err != nil {
+		return false, err
+	}
+
+	c.in <- in
+	close(c.in)
+
+	return false, nil
+}
+
+func (c *proteanCall_Health_Check) Done() {}
+
+func (c *proteanCall_Health_Check) Recv() (proto.Message, bool) {
+	select {
+	case <-c.ctx.Done():
+		c.service = nil
+		c.err = c.ctx.Err()
+		return nil, false
+	case in, ok := <-c.in:
+		if !ok {
+			return nil, false
+		}
+
+		out, err := c.interceptor.InterceptUnaryRPC(
+			c.ctx,
+			middleware.UnaryServerInfo{
+				Method:  "Check",
+				Package: "protean.health.v1",
+				Service: "Health",
+			},
+			in,
+			func(ctx context.Context) (proto.Message, error) {
+				return c.service.Check(ctx, in)
+			},
+		)
+
+		c.service = nil
+		c.err = err
+
+		return out, err == nil
+	}
+}
+
+func (c *proteanCall_Health_Check) Wait() error {
+	if c.service == nil {
+		return c.err
+	}
+
+	panic("Wait() called before Recv() returned false")
+}
+
+// proteanMethod_Health_Watch is a runtime.Method implementation for the protean.health.v1.Health.Watch() method.
+type proteanMethod_Health_Watch struct {
+	service ProteanHealth
+}
+
+func (m *proteanMethod_Health_Watch) Name() string {
+	return "Watch"
+}
+
+func (m *proteanMethod_Health_Watch) InputIsStream() bool {
+	return false
+}
+
+func (m *proteanMethod_Health_Watch) OutputIsStream() bool {
+	return true
+}
+
+func (m *proteanMethod_Health_Watch) HasSideEffects() bool {
+	return true
+}
+
+func (m *proteanMethod_Health_Watch) Descriptor() protoreflect.MethodDescriptor {
+	return File_github_com_dogmatiq_protean_health_health_proto.Services().ByName("Health").Methods().ByName("Watch")
+}
+
+func (m *proteanMethod_Health_Watch) MaxInputSize() int {
+	return 0
+}
+
+func (m *proteanMethod_Health_Watch) MaxOutputSize() int {
+	return 0
+}
+
+func (m *proteanMethod_Health_Watch) RequiredScopes() []string {
+	return nil
+}
+
+func (m *proteanMethod_Health_Watch) NewCall(ctx context.Context, options runtime.CallOptions) runtime.Call {
+	return newProteanCall_Health_Watch(ctx, m.service, options)
+}
+
+// newProteanCall_Health_Watch returns a new runtime.Call for the protean.health.v1.Health.Watch() method.
+func newProteanCall_Health_Watch(ctx context.Context, service ProteanHealth, options runtime.CallOptions) runtime.Call {
+	c := &proteanCall_Health_Watch{ctx, service, options.StreamInterceptor, make(chan *HealthCheckRequest, 1), make(chan *HealthCheckResponse, options.OutputChannelCapacity), make(chan error, 1)}
+	go c.run()
+	return c
+}
+
+// proteanMethod_Health_Watch is a runtime.Call implementation for the protean.health.v1.Health.Watch() method.
+type proteanCall_Health_Watch struct {
+	ctx         context.Context
+	service     ProteanHealth
+	interceptor middleware.StreamServerInterceptor
+	in          chan *HealthCheckRequest
+	out         chan *HealthCheckResponse
+	err         chan error
+}
+
+func (c *proteanCall_Health_Watch) Send(unmarshal runtime.Unmarshaler) (bool, error) {
+	in := &HealthCheckRequest{}
+	if err := unmarshal(in); err != nil {
+		return false, err
+	}
+
+	c.in <- in
+	close(c.in)
+
+	return false, nil
+}
+
+func (c *proteanCall_Health_Watch) Done() {}
+
+func (c *proteanCall_Health_Watch) Recv() (proto.Message, bool) {
+	out, ok := <-c.out
+	return out, ok
+}
+
+func (c *proteanCall_Health_Watch) Wait() error {
+	return <-c.err
+}
+
+func (c *proteanCall_Health_Watch) run() {
+	select {
+	case <-c.ctx.Done():
+		close(c.out)
+		c.err <- c.ctx.Err()
+	case in := <-c.in:
+		c.err <- runtime.InterceptServerStream(
+			c.ctx,
+			c.interceptor,
+			middleware.StreamServerInfo{
+				InputIsStream:  false,
+				Method:         "Watch",
+				OutputIsStream: true,
+				Package:        "protean.health.v1",
+				Service:        "Health",
+			},
+			in,
+			c.out,
+			c.service.Watch,
+		)
+	}
+}
+
+// proteanClient_Health is an implementation of the ProteanHealth interface that is an RPC client.
+type proteanClient_Health struct {
+	client *runtime.Client
+}
+
+func (c *proteanClient_Health) Check(ctx context.Context, in *HealthCheckRequest) (*HealthCheckResponse, error) {
+	out := &HealthCheckResponse{}
+	return out, c.client.CallUnary(ctx, "/protean.health.v1/Health/Check", in, out)
+}
+
+func (c *proteanClient_Health) Watch(ctx context.Context, in *HealthCheckRequest, outputs chan<- *HealthCheckResponse) error {
+	return errors.New("This client does not support streaming RPC methods.")
+}
@@ -0,0 +1,238 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/dogmatiq/protean/rpcerror"
+)
+
+// Server is an implementation of the Health service.
+//
+// The server as a whole, identified by an empty service name, is initially
+// SERVING. The statuses of individual services are set by the application
+// using SetServingStatus().
+type Server struct {
+	m        sync.Mutex
+	statuses map[string]HealthCheckResponse_ServingStatus
+	watchers map[string]map[chan struct{}]struct{}
+	shutdown bool
+}
+
+var _ ProteanHealth = (*Server)(nil)
+
+// NewServer returns a new health server.
+func NewServer() *Server {
+	return &Server{
+		statuses: map[string]HealthCheckResponse_ServingStatus{
+			"": HealthCheckResponse_SERVING,
+		},
+	}
+}
+
+// SetServingStatus sets the serving status of a service.
+//
+// service is the fully-qualified name of the service, such as
+// "example.v1.CustomerService", or an empty string to set the status of the
+// server as a whole. It has no effect after Shutdown() has been called.
+func (s *Server) SetServingStatus(
+	service string,
+	status HealthCheckResponse_ServingStatus,
+) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if !s.shutdown {
+		s.setServingStatus(service, status)
+	}
+}
+
+// Shutdown sets the status of the server and all services to NOT_SERVING.
+//
+// Subsequent calls to SetServingStatus() are ignored. It is intended to be
+// called when the server begins shutting down, so that load balancers stop
+// sending new requests.
+func (s *Server) Shutdown() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.shutdown = true
+
+	for service := range s.statuses {
+		s.setServingStatus(service, HealthCheckResponse_NOT_SERVING)
+	}
+}
+
+// Resume sets the status of the server and all services to SERVING.
+//
+// It reverses the effect of Shutdown().
+func (s *Server) Resume() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.shutdown = false
+
+	for service := range s.statuses {
+		s.setServingStatus(service, HealthCheckResponse_SERVING)
+	}
+}
+
+// setServingStatus sets the status of a service and notifies any watchers.
+//
+// It assumes s.m is already locked.
+func (s *Server) setServingStatus(
+	service string,
+	status HealthCheckResponse_ServingStatus,
+) {
+	if s.statuses == nil {
+		s.statuses = map[string]HealthCheckResponse_ServingStatus{}
+	}
+
+	s.statuses[service] = status
+
+	for w := range s.watchers[service] {
+		select {
+		case w <- struct{}{}:
+		default:
+			// The watcher has not yet handled the previous notification, it
+			// will see the latest status when it does.
+		}
+	}
+}
+
+// status returns the status of the given service.
+//
+// ok is false if the service is unknown.
+func (s *Server) status(service string) (HealthCheckResponse_ServingStatus, bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	status, ok := s.statuses[service]
+	return status, ok
+}
+
+// Check returns the current serving status of a service.
+func (s *Server) Check(
+	_ context.Context,
+	in *HealthCheckRequest,
+) (*HealthCheckResponse, error) {
+	status, ok := s.status(in.GetService())
+	if !ok {
+		return nil, rpcerror.New(
+			rpcerror.NotFound,
+			"the '%s' service is unknown",
+			in.GetService(),
+		)
+	}
+
+	return &HealthCheckResponse{
+		Status: status,
+	}, nil
+}
+
+// Watch streams the serving status of a service.
+func (s *Server) Watch(
+	ctx context.Context,
+	in *HealthCheckRequest,
+	outputs chan<- *HealthCheckResponse,
+) error {
+	defer close(outputs)
+
+	service := in.GetService()
+	notify := make(chan struct{}, 1)
+
+	s.m.Lock()
+	if s.watchers == nil {
+		s.watchers = map[string]map[chan struct{}]struct{}{}
+	}
+	if s.watchers[service] == nil {
+		s.watchers[service] = map[chan struct{}]struct{}{}
+	}
+	s.watchers[service][notify] = struct{}{}
+	s.m.Unlock()
+
+	defer func() {
+		s.m.Lock()
+		defer s.m.Unlock()
+
+		delete(s.watchers[service], notify)
+		if len(s.watchers[service]) == 0 {
+			delete(s.watchers, service)
+		}
+	}()
+
+	prev := HealthCheckResponse_UNKNOWN
+
+	for {
+		status, ok := s.status(service)
+		if !ok {
+			status = HealthCheckResponse_SERVICE_UNKNOWN
+		}
+
+		if status != prev {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case outputs <- &HealthCheckResponse{Status: status}:
+				prev = status
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+		}
+	}
+}
+
+// ServeHTTP serves a plain HTTP readiness probe.
+//
+// It responds with a "200 OK" status if the service named by the "service"
+// query parameter is SERVING, or "503 Service Unavailable" if it is not. If
+// the parameter is omitted the status of the server as a whole is reported.
+// Unknown services result in a "404 Not Found" status. The response body is
+// the name of the status, such as "SERVING".
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeProbeResponse(w, http.StatusMethodNotAllowed, "the HTTP method must be GET or HEAD")
+		return
+	}
+
+	service := r.URL.Query().Get("service")
+
+	status, ok := s.status(service)
+	if !ok {
+		writeProbeResponse(w, http.StatusNotFound, HealthCheckResponse_SERVICE_UNKNOWN.String())
+		return
+	}
+
+	if status == HealthCheckResponse_SERVING {
+		writeProbeResponse(w, http.StatusOK, status.String())
+	} else {
+		writeProbeResponse(w, http.StatusServiceUnavailable, status.String())
+	}
+}
+
+// LivenessHandler returns an HTTP handler that serves a plain HTTP liveness
+// probe.
+//
+// It always responds with a "200 OK" status, regardless of the serving status
+// of the server, as a server that is shutting down or not yet ready is still
+// alive. Use the Server itself as a readiness probe.
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeProbeResponse(w, http.StatusOK, "OK")
+	})
+}
+
+// writeProbeResponse writes a plain-text response to an HTTP probe.
+func writeProbeResponse(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(body + "\n"))
+}
@@ -0,0 +1,173 @@
+package health_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/dogmatiq/protean"
+	. "github.com/dogmatiq/protean/health"
+	"github.com/dogmatiq/protean/rpcerror"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("type Server", func() {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+		server *Server
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+		server = NewServer()
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	Describe("func Check()", func() {
+		It("reports that the server is serving by default", func() {
+			out, err := server.Check(ctx, &HealthCheckRequest{})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(out.GetStatus()).To(Equal(HealthCheckResponse_SERVING))
+		})
+
+		It("returns the status set by SetServingStatus()", func() {
+			server.SetServingStatus("example.Service", HealthCheckResponse_NOT_SERVING)
+
+			out, err := server.Check(ctx, &HealthCheckRequest{Service: "example.Service"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(out.GetStatus()).To(Equal(HealthCheckResponse_NOT_SERVING))
+		})
+
+		It("returns a 'not found' error if the service is unknown", func() {
+			_, err := server.Check(ctx, &HealthCheckRequest{Service: "example.Unknown"})
+			Expect(err).To(BeAssignableToTypeOf(rpcerror.Error{}))
+			Expect(err.(rpcerror.Error).Code()).To(Equal(rpcerror.NotFound))
+		})
+
+		It("can be called via a Protean handler", func() {
+			handler := protean.NewHandler()
+			RegisterProteanHealth(handler, server)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/protean.health.v1/Health/Check",
+				strings.NewReader(`{}`),
+			)
+			req.Header.Set("Content-Type", "application/json")
+
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+
+			Expect(res.Code).To(Equal(http.StatusOK))
+			Expect(res.Body.String()).To(MatchJSON(`{"status":"SERVING"}`))
+		})
+	})
+
+	Describe("func Watch()", func() {
+		It("sends the current status and each subsequent change", func() {
+			outputs := make(chan *HealthCheckResponse)
+			result := make(chan error, 1)
+
+			go func() {
+				result <- server.Watch(
+					ctx,
+					&HealthCheckRequest{Service: "example.Service"},
+					outputs,
+				)
+			}()
+
+			Expect((<-outputs).GetStatus()).To(Equal(HealthCheckResponse_SERVICE_UNKNOWN))
+
+			server.SetServingStatus("example.Service", HealthCheckResponse_SERVING)
+			Expect((<-outputs).GetStatus()).To(Equal(HealthCheckResponse_SERVING))
+
+			server.Shutdown()
+			Expect((<-outputs).GetStatus()).To(Equal(HealthCheckResponse_NOT_SERVING))
+
+			cancel()
+			Expect(<-result).To(Equal(context.Canceled))
+
+			_, ok := <-outputs
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("func Shutdown()", func() {
+		It("sets all statuses to NOT_SERVING and ignores subsequent changes", func() {
+			server.SetServingStatus("example.Service", HealthCheckResponse_SERVING)
+			server.Shutdown()
+			server.SetServingStatus("example.Service", HealthCheckResponse_SERVING)
+
+			out, err := server.Check(ctx, &HealthCheckRequest{Service: "example.Service"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(out.GetStatus()).To(Equal(HealthCheckResponse_NOT_SERVING))
+
+			out, err = server.Check(ctx, &HealthCheckRequest{})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(out.GetStatus()).To(Equal(HealthCheckResponse_NOT_SERVING))
+		})
+	})
+
+	Describe("func Resume()", func() {
+		It("sets all statuses to SERVING", func() {
+			server.Shutdown()
+			server.Resume()
+
+			out, err := server.Check(ctx, &HealthCheckRequest{})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(out.GetStatus()).To(Equal(HealthCheckResponse_SERVING))
+		})
+	})
+
+	Describe("func ServeHTTP()", func() {
+		var response *httptest.ResponseRecorder
+
+		BeforeEach(func() {
+			response = httptest.NewRecorder()
+		})
+
+		It("responds with a '200 OK' status if the server is serving", func() {
+			server.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Body.String()).To(Equal("SERVING\n"))
+		})
+
+		It("responds with a '503 Service Unavailable' status if the service is not serving", func() {
+			server.SetServingStatus("example.Service", HealthCheckResponse_NOT_SERVING)
+			server.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/readyz?service=example.Service", nil))
+
+			Expect(response.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(response.Body.String()).To(Equal("NOT_SERVING\n"))
+		})
+
+		It("responds with a '404 Not Found' status if the service is unknown", func() {
+			server.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/readyz?service=example.Unknown", nil))
+
+			Expect(response.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("responds with a '405 Method Not Allowed' status if the HTTP method is not GET or HEAD", func() {
+			server.ServeHTTP(response, httptest.NewRequest(http.MethodPost, "/readyz", nil))
+
+			Expect(response.Code).To(Equal(http.StatusMethodNotAllowed))
+			Expect(response.Header().Get("Allow")).To(Equal("GET, HEAD"))
+		})
+	})
+})
+
+var _ = Describe("func LivenessHandler()", func() {
+	It("always responds with a '200 OK' status", func() {
+		response := httptest.NewRecorder()
+		LivenessHandler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+		Expect(response.Code).To(Equal(http.StatusOK))
+	})
+})
@@ -102,3 +102,37 @@ func appendRuntimeCallImpl(
 			Block(runMethod...)
 	}
 }
+
+// streamServerInfo returns a middleware.StreamServerInfo literal that
+// describes an RPC method.
+func streamServerInfo(s *scope.Method) jen.Code {
+	fields := jen.Dict{
+		jen.Id("Package"):        jen.Lit(s.FileDesc.GetPackage()),
+		jen.Id("Service"):        jen.Lit(s.ServiceDesc.GetName()),
+		jen.Id("Method"):         jen.Lit(s.MethodDesc.GetName()),
+		jen.Id("InputIsStream"):  jen.Lit(s.MethodDesc.GetClientStreaming()),
+		jen.Id("OutputIsStream"): jen.Lit(s.MethodDesc.GetServerStreaming()),
+	}
+
+	if len(requiredScopes(s)) != 0 {
+		fields[jen.Id("RequiredScopes")] = requiredScopesExpr(s)
+	}
+
+	return jen.Qual(middlewarePackage, "StreamServerInfo").Values(fields)
+}
+
+// unaryServerInfo returns a middleware.UnaryServerInfo literal that describes
+// an RPC method.
+func unaryServerInfo(s *scope.Method) jen.Code {
+	fields := jen.Dict{
+		jen.Id("Package"): jen.Lit(s.FileDesc.GetPackage()),
+		jen.Id("Service"): jen.Lit(s.ServiceDesc.GetName()),
+		jen.Id("Method"):  jen.Lit(s.MethodDesc.GetName()),
+	}
+
+	if len(requiredScopes(s)) != 0 {
+		fields[jen.Id("RequiredScopes")] = requiredScopesExpr(s)
+	}
+
+	return jen.Qual(middlewarePackage, "UnaryServerInfo").Values(fields)
+}
@@ -20,6 +20,7 @@ func appendBidirectionalStreamingRuntimeCallConstructor(code *jen.File, s *scope
 				Values(
 					jen.Id("ctx"),
 					jen.Id("service"),
+					jen.Id("options").Dot("StreamInterceptor"),
 					jen.Make(
 						jen.Chan().Op("*").Qual(inputPkg, inputType),
 						jen.Id("options").Dot("InputChannelCapacity"),
@@ -55,6 +56,7 @@ func appendBidirectionalStreamingRuntimeCallImpl(code *jen.File, s *scope.Method
 		[]jen.Code{
 			jen.Id("ctx").Qual("context", "Context"),
 			jen.Id("service").Id(s.ServiceInterface()),
+			jen.Id("interceptor").Qual(middlewarePackage, "StreamServerInterceptor"),
 			jen.Id("in").Chan().Op("*").Qual(inputPkg, inputType),
 			jen.Id("out").Chan().Op("*").Qual(outputPkg, outputType),
 			jen.Id("err").Chan().Error(),
@@ -118,11 +120,15 @@ func appendBidirectionalStreamingRuntimeCallImpl(code *jen.File, s *scope.Method
 		// run method
 		[]jen.Code{
 			jen.Id("c").Dot("err").Op("<-").
-				Id("c").Dot("service").Dot(s.MethodDesc.GetName()).
+				Qual(runtimePackage, "InterceptBidirectionalStream").
 				Call(
-					jen.Id("c").Dot("ctx"),
-					jen.Id("c").Dot("in"),
-					jen.Id("c").Dot("out"),
+					jen.Line().Id("c").Dot("ctx"),
+					jen.Line().Id("c").Dot("interceptor"),
+					jen.Line().Add(streamServerInfo(s)),
+					jen.Line().Id("c").Dot("in"),
+					jen.Line().Id("c").Dot("out"),
+					jen.Line().Id("c").Dot("service").Dot(s.MethodDesc.GetName()),
+					jen.Line(),
 				),
 		},
 	)
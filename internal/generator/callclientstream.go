@@ -19,6 +19,7 @@ func appendClientStreamingRuntimeCallConstructor(code *jen.File, s *scope.Method
 					Values(
 						jen.Id("ctx"),
 						jen.Id("service"),
+						jen.Id("options").Dot("StreamInterceptor"),
 						jen.Make(
 							jen.Chan().Op("*").Qual(inputPkg, inputType),
 							jen.Id("options").Dot("InputChannelCapacity"),
@@ -44,6 +45,7 @@ func appendClientStreamingRuntimeCallImpl(code *jen.File, s *scope.Method) {
 		[]jen.Code{
 			jen.Id("ctx").Qual("context", "Context"),
 			jen.Id("service").Id(s.ServiceInterface()),
+			jen.Id("interceptor").Qual(middlewarePackage, "StreamServerInterceptor"),
 			jen.Id("in").Chan().Op("*").Qual(inputPkg, inputType),
 			jen.Id("err").Error(),
 		},
@@ -100,10 +102,14 @@ func appendClientStreamingRuntimeCallImpl(code *jen.File, s *scope.Method) {
 			),
 			jen.Line(),
 			jen.Id("out").Op(",").Id("err").Op(":=").
-				Id("c").Dot("service").Dot(s.MethodDesc.GetName()).
+				Qual(runtimePackage, "InterceptClientStream").
 				Call(
-					jen.Id("c").Dot("ctx"),
-					jen.Id("c").Dot("in"),
+					jen.Line().Id("c").Dot("ctx"),
+					jen.Line().Id("c").Dot("interceptor"),
+					jen.Line().Add(streamServerInfo(s)),
+					jen.Line().Id("c").Dot("in"),
+					jen.Line().Id("c").Dot("service").Dot(s.MethodDesc.GetName()),
+					jen.Line(),
 				),
 			jen.Line(),
 			jen.Id("c").Dot("service").Op("=").Nil(),
@@ -20,6 +20,7 @@ func appendServerStreamingRuntimeCallConstructor(code *jen.File, s *scope.Method
 				Values(
 					jen.Id("ctx"),
 					jen.Id("service"),
+					jen.Id("options").Dot("StreamInterceptor"),
 					jen.Make(
 						jen.Chan().Op("*").Qual(inputPkg, inputType),
 						jen.Lit(1),
@@ -55,6 +56,7 @@ func appendServerStreamingRuntimeCallImpl(code *jen.File, s *scope.Method) {
 		[]jen.Code{
 			jen.Id("ctx").Qual("context", "Context"),
 			jen.Id("service").Id(s.ServiceInterface()),
+			jen.Id("interceptor").Qual(middlewarePackage, "StreamServerInterceptor"),
 			jen.Id("in").Chan().Op("*").Qual(inputPkg, inputType),
 			jen.Id("out").Chan().Op("*").Qual(outputPkg, outputType),
 			jen.Id("err").Chan().Id("error"),
@@ -107,17 +109,22 @@ func appendServerStreamingRuntimeCallImpl(code *jen.File, s *scope.Method) {
 				jen.Case(
 					jen.Op("<-").Id("c").Dot("ctx").Dot("Done").Call(),
 				).Block(
+					jen.Close(jen.Id("c").Dot("out")),
 					jen.Id("c").Dot("err").Op("<-").Id("c").Dot("ctx").Dot("Err").Call(),
 				),
 				jen.Case(
 					jen.Id("in").Op(":=").Op("<-").Id("c").Dot("in"),
 				).Block(
 					jen.Id("c").Dot("err").Op("<-").
-						Id("c").Dot("service").Dot(s.MethodDesc.GetName()).
+						Qual(runtimePackage, "InterceptServerStream").
 						Call(
-							jen.Id("c").Dot("ctx"),
-							jen.Id("in"),
-							jen.Id("c").Dot("out"),
+							jen.Line().Id("c").Dot("ctx"),
+							jen.Line().Id("c").Dot("interceptor"),
+							jen.Line().Add(streamServerInfo(s)),
+							jen.Line().Id("in"),
+							jen.Line().Id("c").Dot("out"),
+							jen.Line().Id("c").Dot("service").Dot(s.MethodDesc.GetName()),
+							jen.Line(),
 						),
 				),
 			),
@@ -79,6 +79,7 @@ func appendUnaryRuntimeCallImpl(code *jen.File, s *scope.Method) {
 				jen.Case(
 					jen.Op("<-").Id("c").Dot("ctx").Dot("Done").Call(),
 				).Block(
+					jen.Id("c").Dot("service").Op("=").Nil(),
 					jen.Id("c").Dot("err").Op("=").Id("c").Dot("ctx").Dot("Err").Call(),
 					jen.Return(
 						jen.Nil(),
@@ -98,13 +99,7 @@ func appendUnaryRuntimeCallImpl(code *jen.File, s *scope.Method) {
 					jen.Id("out").Op(",").Id("err").Op(":=").
 						Id("c").Dot("interceptor").Dot("InterceptUnaryRPC").Call(
 						jen.Line().Id("c").Dot("ctx"),
-						jen.Line().Qual(middlewarePackage, "UnaryServerInfo").Values(
-							jen.Dict{
-								jen.Id("Package"): jen.Lit(s.FileDesc.GetPackage()),
-								jen.Id("Service"): jen.Lit(s.ServiceDesc.GetName()),
-								jen.Id("Method"):  jen.Lit(s.MethodDesc.GetName()),
-							},
-						),
+						jen.Line().Add(unaryServerInfo(s)),
 						jen.Line().Id("in"),
 						jen.Line().Func().
 							Params(
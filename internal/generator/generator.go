@@ -8,10 +8,11 @@ import (
 )
 
 const (
-	protoPackage      = "google.golang.org/protobuf/proto"
-	rootPackage       = "github.com/dogmatiq/protean"
-	runtimePackage    = rootPackage + "/runtime"
-	middlewarePackage = rootPackage + "/middleware"
+	protoPackage        = "google.golang.org/protobuf/proto"
+	protoreflectPackage = "google.golang.org/protobuf/reflect/protoreflect"
+	rootPackage         = "github.com/dogmatiq/protean"
+	runtimePackage      = rootPackage + "/runtime"
+	middlewarePackage   = rootPackage + "/middleware"
 )
 
 // Generator produces a code generation response from a request.
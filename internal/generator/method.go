@@ -3,6 +3,9 @@ package generator
 import (
 	"github.com/dave/jennifer/jen"
 	"github.com/dogmatiq/protean/internal/generator/scope"
+	"github.com/dogmatiq/protean/options"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 // appendMethod appends all generated code for an RPC method to the output.
@@ -66,6 +69,54 @@ func appendRuntimeMethodImpl(code *jen.File, s *scope.Method) {
 		Params(jen.Bool()).
 		Block(jen.Return(jen.Lit(s.MethodDesc.GetServerStreaming())))
 
+	code.Line()
+	code.Func().
+		Params(recv).
+		Id("HasSideEffects").
+		Params().
+		Params(jen.Bool()).
+		Block(jen.Return(jen.Lit(
+			s.MethodDesc.GetOptions().GetIdempotencyLevel() != descriptorpb.MethodOptions_NO_SIDE_EFFECTS,
+		)))
+
+	code.Line()
+	code.Func().
+		Params(recv).
+		Id("Descriptor").
+		Params().
+		Params(jen.Qual(protoreflectPackage, "MethodDescriptor")).
+		Block(jen.Return(
+			serviceDescriptorExpr(s.Service).
+				Dot("Methods").Call().
+				Dot("ByName").Call(jen.Lit(s.MethodDesc.GetName())),
+		))
+
+	maxInputSize, maxOutputSize := sizeLimits(s)
+
+	code.Line()
+	code.Func().
+		Params(recv).
+		Id("MaxInputSize").
+		Params().
+		Params(jen.Int()).
+		Block(jen.Return(jen.Lit(maxInputSize)))
+
+	code.Line()
+	code.Func().
+		Params(recv).
+		Id("MaxOutputSize").
+		Params().
+		Params(jen.Int()).
+		Block(jen.Return(jen.Lit(maxOutputSize)))
+
+	code.Line()
+	code.Func().
+		Params(recv).
+		Id("RequiredScopes").
+		Params().
+		Params(jen.Index().String()).
+		Block(jen.Return(requiredScopesExpr(s)))
+
 	code.Line()
 	code.Func().
 		Params(recv).
@@ -85,3 +136,58 @@ func appendRuntimeMethodImpl(code *jen.File, s *scope.Method) {
 			),
 		))
 }
+
+// sizeLimits returns the maximum input and output message sizes declared for
+// a method by the "protean.max_input_size" and "protean.max_output_size"
+// options, falling back to the equivalent service options.
+func sizeLimits(s *scope.Method) (maxInputSize, maxOutputSize int) {
+	mo := s.MethodDesc.GetOptions()
+	so := s.ServiceDesc.GetOptions()
+
+	maxInputSize = int(proto.GetExtension(mo, options.E_MaxInputSize).(int64))
+	if maxInputSize == 0 {
+		maxInputSize = int(proto.GetExtension(so, options.E_ServiceMaxInputSize).(int64))
+	}
+
+	maxOutputSize = int(proto.GetExtension(mo, options.E_MaxOutputSize).(int64))
+	if maxOutputSize == 0 {
+		maxOutputSize = int(proto.GetExtension(so, options.E_ServiceMaxOutputSize).(int64))
+	}
+
+	return maxInputSize, maxOutputSize
+}
+
+// requiredScopes returns the scopes declared for a method by the
+// "protean.required_scopes" option, falling back to the equivalent service
+// option.
+func requiredScopes(s *scope.Method) []string {
+	scopes := proto.GetExtension(
+		s.MethodDesc.GetOptions(),
+		options.E_RequiredScopes,
+	).([]string)
+
+	if len(scopes) == 0 {
+		scopes = proto.GetExtension(
+			s.ServiceDesc.GetOptions(),
+			options.E_ServiceRequiredScopes,
+		).([]string)
+	}
+
+	return scopes
+}
+
+// requiredScopesExpr returns an expression that evaluates to the scopes
+// declared for a method, or nil if there are none.
+func requiredScopesExpr(s *scope.Method) jen.Code {
+	scopes := requiredScopes(s)
+	if len(scopes) == 0 {
+		return jen.Nil()
+	}
+
+	var values []jen.Code
+	for _, v := range scopes {
+		values = append(values, jen.Lit(v))
+	}
+
+	return jen.Index().String().Values(values...)
+}
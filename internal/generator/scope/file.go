@@ -1,8 +1,11 @@
 package scope
 
 import (
+	"go/token"
 	"path"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/dogmatiq/protean/internal/generator/descriptorutil"
 	"google.golang.org/protobuf/types/descriptorpb"
@@ -34,6 +37,28 @@ func (s *File) OutputFileName() string {
 	return n + "_protean.pb.go"
 }
 
+// GoDescriptorVar returns the name of the variable that protoc-gen-go generates
+// to hold the protoreflect.FileDescriptor for this file.
+func (s *File) GoDescriptorVar() string {
+	// This mirrors the sanitization performed by protoc-gen-go, which is not
+	// exported.
+	n := strings.Map(
+		func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return '_'
+		},
+		s.FileDesc.GetName(),
+	)
+
+	if r, _ := utf8.DecodeRuneInString(n); token.Lookup(n).IsKeyword() || !unicode.IsLetter(r) {
+		n = "_" + n
+	}
+
+	return "File_" + n
+}
+
 // EnterService returns a scope for a service within this file.
 func (s *File) EnterService(d *descriptorpb.ServiceDescriptorProto) *Service {
 	return &Service{s, d}
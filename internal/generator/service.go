@@ -127,6 +127,14 @@ func appendRuntimeServiceImpl(code *jen.File, s *scope.Service) {
 		Params(jen.String()).
 		Block(jen.Return(jen.Lit(s.FileDesc.GetPackage())))
 
+	code.Line()
+	code.Func().
+		Params(recv).
+		Id("Descriptor").
+		Params().
+		Params(jen.Qual(protoreflectPackage, "ServiceDescriptor")).
+		Block(jen.Return(serviceDescriptorExpr(s)))
+
 	// TODO: avoid constructing new method instances each time
 	code.Line()
 	code.Func().
@@ -147,3 +155,11 @@ func appendRuntimeServiceImpl(code *jen.File, s *scope.Service) {
 			),
 		)
 }
+
+// serviceDescriptorExpr returns an expression that evaluates to the
+// protoreflect.ServiceDescriptor for the service.
+func serviceDescriptorExpr(s *scope.Service) *jen.Statement {
+	return jen.Id(s.GoDescriptorVar()).
+		Dot("Services").Call().
+		Dot("ByName").Call(jen.Lit(s.ServiceDesc.GetName()))
+}
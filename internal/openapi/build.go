@@ -0,0 +1,276 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/dogmatiq/protean/internal/proteanpb"
+	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/runtime"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// errorStatuses is the set of HTTP statuses that the HTTP POST transport may
+// use for error responses, and their descriptions.
+var errorStatuses = map[int]string{
+	http.StatusBadRequest:            "The request is invalid or the RPC input message is malformed.",
+	http.StatusUnauthorized:          "The client is not authenticated.",
+	http.StatusForbidden:             "The client does not have permission to call the RPC method.",
+	http.StatusNotFound:              "The RPC method or a requested resource does not exist.",
+	http.StatusNotAcceptable:         "The client does not accept any of the supported media-types.",
+	http.StatusConflict:              "The request conflicts with the current state of a resource.",
+	http.StatusRequestEntityTooLarge: "The RPC input message exceeds the maximum allowable size.",
+	http.StatusUnsupportedMediaType:  "The request body uses an unsupported media-type or content-coding.",
+	http.StatusTooManyRequests:       "A resource has been exhausted, such as a rate limit or quota.",
+	http.StatusInternalServerError:   "An unexpected error occurred.",
+	http.StatusNotImplemented:        "The RPC method is not implemented.",
+	http.StatusServiceUnavailable:    "The service is temporarily unavailable.",
+	http.StatusGatewayTimeout:        "The RPC call did not complete before its deadline.",
+}
+
+// Build returns an OpenAPI document that describes the unary RPC methods of
+// the given services, as exposed by the HTTP POST transport.
+//
+// Schemas follow the canonical Protocol Buffers JSON mapping, using the
+// original field names, which is how the handler marshals JSON messages.
+// Methods that use streaming inputs or outputs are omitted, as they can not be
+// described by OpenAPI.
+func Build(info Info, services []runtime.Service) *Document {
+	b := &builder{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   map[string]PathItem{},
+			Components: Components{
+				Schemas:   map[string]*Schema{},
+				Responses: map[string]Response{},
+			},
+		},
+	}
+
+	errorSchema := b.messageRef((&proteanpb.Error{}).ProtoReflect().Descriptor())
+
+	for status, desc := range errorStatuses {
+		b.doc.Components.Responses[errorResponseName(status)] = Response{
+			Description: desc,
+			Content:     content(errorSchema),
+		}
+	}
+
+	for _, s := range services {
+		b.addService(s)
+	}
+
+	return b.doc
+}
+
+// builder builds an OpenAPI document.
+type builder struct {
+	doc *Document
+}
+
+// addService adds the operations for each unary method of s to the document.
+func (b *builder) addService(s runtime.Service) {
+	sd := s.Descriptor()
+	methods := sd.Methods()
+
+	for i := 0; i < methods.Len(); i++ {
+		md := methods.Get(i)
+
+		m, ok := s.MethodByName(string(md.Name()))
+		if !ok || m.InputIsStream() || m.OutputIsStream() {
+			continue
+		}
+
+		path := fmt.Sprintf("/%s/%s/%s", s.Package(), s.Name(), m.Name())
+
+		op := &Operation{
+			OperationID: string(md.FullName()),
+			Summary:     string(sd.FullName()) + "/" + m.Name(),
+			Tags:        []string{string(sd.FullName())},
+			RequestBody: &RequestBody{
+				Required: true,
+				Content:  content(b.messageRef(md.Input())),
+			},
+			Responses: map[string]Response{
+				strconv.Itoa(http.StatusOK): {
+					Description: "The RPC method completed successfully.",
+					Content:     content(b.messageRef(md.Output())),
+				},
+			},
+			RequiredScopes: m.RequiredScopes(),
+		}
+
+		for status := range errorStatuses {
+			op.Responses[strconv.Itoa(status)] = Response{
+				Ref: "#/components/responses/" + errorResponseName(status),
+			}
+		}
+
+		b.doc.Paths[path] = PathItem{Post: op}
+	}
+}
+
+// messageRef returns a schema that refers to the schema for the message
+// described by md, adding it to the document's components if necessary.
+func (b *builder) messageRef(md protoreflect.MessageDescriptor) *Schema {
+	if s, ok := wellKnownSchema(md); ok {
+		return s
+	}
+
+	name := string(md.FullName())
+
+	if _, ok := b.doc.Components.Schemas[name]; !ok {
+		s := &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{},
+		}
+
+		// Add the schema before populating it so that recursive messages
+		// refer to the existing schema.
+		b.doc.Components.Schemas[name] = s
+
+		fields := md.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			s.Properties[string(fd.Name())] = b.fieldSchema(fd)
+		}
+	}
+
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// enumRef returns a schema that refers to the schema for the enum described by
+// ed, adding it to the document's components if necessary.
+func (b *builder) enumRef(ed protoreflect.EnumDescriptor) *Schema {
+	if ed.FullName() == "google.protobuf.NullValue" {
+		return &Schema{Type: "null"}
+	}
+
+	name := string(ed.FullName())
+
+	if _, ok := b.doc.Components.Schemas[name]; !ok {
+		s := &Schema{Type: "string"}
+
+		values := ed.Values()
+		for i := 0; i < values.Len(); i++ {
+			s.Enum = append(s.Enum, string(values.Get(i).Name()))
+		}
+
+		b.doc.Components.Schemas[name] = s
+	}
+
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// fieldSchema returns the schema for the field described by fd.
+func (b *builder) fieldSchema(fd protoreflect.FieldDescriptor) *Schema {
+	if fd.IsMap() {
+		return &Schema{
+			Type:                 "object",
+			AdditionalProperties: b.singularSchema(fd.MapValue()),
+		}
+	}
+
+	if fd.IsList() {
+		return &Schema{
+			Type:  "array",
+			Items: b.singularSchema(fd),
+		}
+	}
+
+	return b.singularSchema(fd)
+}
+
+// singularSchema returns the schema for a single value of the field described
+// by fd.
+func (b *builder) singularSchema(fd protoreflect.FieldDescriptor) *Schema {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return &Schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &Schema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &Schema{Type: "integer", Format: "uint32", Minimum: new(int)}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// 64-bit integers are represented as strings, as they can not be
+		// represented precisely by JSON numbers.
+		return &Schema{Type: "string", Format: "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &Schema{Type: "string", Format: "uint64"}
+	case protoreflect.FloatKind:
+		return &Schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &Schema{Type: "number", Format: "double"}
+	case protoreflect.StringKind:
+		return &Schema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &Schema{Type: "string", ContentEncoding: "base64"}
+	case protoreflect.EnumKind:
+		return b.enumRef(fd.Enum())
+	default: // message or group
+		return b.messageRef(fd.Message())
+	}
+}
+
+// wellKnownSchema returns the schema for the well-known message type described
+// by md, which have special representations in the JSON mapping.
+func wellKnownSchema(md protoreflect.MessageDescriptor) (*Schema, bool) {
+	switch md.FullName() {
+	case "google.protobuf.Any":
+		return &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"@type": {Type: "string"},
+			},
+		}, true
+	case "google.protobuf.Timestamp":
+		return &Schema{Type: "string", Format: "date-time"}, true
+	case "google.protobuf.Duration":
+		return &Schema{Type: "string", Format: "duration"}, true
+	case "google.protobuf.FieldMask":
+		return &Schema{Type: "string"}, true
+	case "google.protobuf.Struct":
+		return &Schema{Type: "object"}, true
+	case "google.protobuf.Value":
+		return &Schema{}, true
+	case "google.protobuf.ListValue":
+		return &Schema{Type: "array"}, true
+	case "google.protobuf.Empty":
+		return &Schema{Type: "object"}, true
+	case "google.protobuf.BoolValue":
+		return &Schema{Type: []string{"boolean", "null"}}, true
+	case "google.protobuf.Int32Value", "google.protobuf.UInt32Value":
+		return &Schema{Type: []string{"integer", "null"}}, true
+	case "google.protobuf.Int64Value", "google.protobuf.UInt64Value",
+		"google.protobuf.StringValue":
+		return &Schema{Type: []string{"string", "null"}}, true
+	case "google.protobuf.FloatValue", "google.protobuf.DoubleValue":
+		return &Schema{Type: []string{"number", "null"}}, true
+	case "google.protobuf.BytesValue":
+		return &Schema{Type: []string{"string", "null"}, ContentEncoding: "base64"}, true
+	}
+
+	return nil, false
+}
+
+// content returns the content of a request or response body that contains a
+// message described by the given schema, in each of the supported media-types.
+//
+// The schema describes the structure of the message regardless of the
+// media-type, although only the JSON media-types use the JSON representation
+// literally.
+func content(s *Schema) map[string]MediaType {
+	c := map[string]MediaType{}
+	for _, mediaType := range protomime.MediaTypes {
+		c[mediaType] = MediaType{Schema: s}
+	}
+	return c
+}
+
+// errorResponseName returns the name of the component that describes an error
+// response with the given HTTP status.
+func errorResponseName(status int) string {
+	return "Error" + strconv.Itoa(status)
+}
@@ -0,0 +1,3 @@
+// Package openapi builds OpenAPI 3.1 documents that describe the HTTP POST
+// transport of Protocol Buffers services.
+package openapi
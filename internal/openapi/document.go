@@ -0,0 +1,86 @@
+package openapi
+
+// Version is the version of the OpenAPI specification that the documents
+// conform to.
+const Version = "3.1.0"
+
+// Document is an OpenAPI document.
+//
+// See https://spec.openapis.org/oas/v3.1.0#openapi-object.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Server describes a server that hosts the API.
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Info contains metadata about the API.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem describes the operations available on a single path.
+type PathItem struct {
+	Post *Operation `json:"post,omitempty"`
+}
+
+// Operation describes a single API operation on a path.
+//
+// RequiredScopes is a specification extension that lists the scopes declared
+// by the method's "protean.required_scopes" option.
+type Operation struct {
+	OperationID    string              `json:"operationId"`
+	Summary        string              `json:"summary,omitempty"`
+	Tags           []string            `json:"tags,omitempty"`
+	RequestBody    *RequestBody        `json:"requestBody,omitempty"`
+	Responses      map[string]Response `json:"responses"`
+	RequiredScopes []string            `json:"x-protean-required-scopes,omitempty"`
+}
+
+// RequestBody describes the request body of an operation.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a single response from an operation. If Ref is
+// non-empty, it refers to a response defined in the document's components.
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType describes the content of a request or response body that uses a
+// specific media-type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds reusable objects that are referred to from elsewhere in
+// the document.
+type Components struct {
+	Schemas   map[string]*Schema  `json:"schemas"`
+	Responses map[string]Response `json:"responses"`
+}
+
+// Schema is a JSON schema, as per JSON Schema draft 2020-12.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
@@ -0,0 +1,320 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.32.0
+// source: github.com/dogmatiq/protean/internal/proteanpb/discovery.proto
+
+package proteanpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ServiceList is the response body of the discovery endpoint. It describes the
+// services that are registered with a handler.
+type ServiceList struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Services describes each of the registered services, ordered by name.
+	Services []*ServiceInfo `protobuf:"bytes,1,rep,name=services,proto3" json:"services,omitempty"`
+	// FileDescriptorSet contains the descriptors of the files that define the
+	// registered services, including their transitive dependencies. It is only
+	// populated if requested by the client.
+	FileDescriptorSet *descriptorpb.FileDescriptorSet `protobuf:"bytes,2,opt,name=file_descriptor_set,json=fileDescriptorSet,proto3" json:"file_descriptor_set,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ServiceList) Reset() {
+	*x = ServiceList{}
+	mi := &file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServiceList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceList) ProtoMessage() {}
+
+func (x *ServiceList) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceList.ProtoReflect.Descriptor instead.
+func (*ServiceList) Descriptor() ([]byte, []int) {
+	return file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ServiceList) GetServices() []*ServiceInfo {
+	if x != nil {
+		return x.Services
+	}
+	return nil
+}
+
+func (x *ServiceList) GetFileDescriptorSet() *descriptorpb.FileDescriptorSet {
+	if x != nil {
+		return x.FileDescriptorSet
+	}
+	return nil
+}
+
+// ServiceInfo describes an RPC service.
+type ServiceInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name is the fully-qualified name of the service, such as
+	// "example.v1.CustomerService".
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Methods describes each of the service's RPC methods, in the order they
+	// are declared.
+	Methods       []*MethodInfo `protobuf:"bytes,2,rep,name=methods,proto3" json:"methods,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServiceInfo) Reset() {
+	*x = ServiceInfo{}
+	mi := &file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServiceInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceInfo) ProtoMessage() {}
+
+func (x *ServiceInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceInfo.ProtoReflect.Descriptor instead.
+func (*ServiceInfo) Descriptor() ([]byte, []int) {
+	return file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ServiceInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ServiceInfo) GetMethods() []*MethodInfo {
+	if x != nil {
+		return x.Methods
+	}
+	return nil
+}
+
+// MethodInfo describes an RPC method.
+type MethodInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name is the unqualified name of the method.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// InputType is the fully-qualified name of the RPC input message type.
+	InputType string `protobuf:"bytes,2,opt,name=input_type,json=inputType,proto3" json:"input_type,omitempty"`
+	// OutputType is the fully-qualified name of the RPC output message type.
+	OutputType string `protobuf:"bytes,3,opt,name=output_type,json=outputType,proto3" json:"output_type,omitempty"`
+	// InputIsStream is true if the method accepts a stream of input messages.
+	InputIsStream bool `protobuf:"varint,4,opt,name=input_is_stream,json=inputIsStream,proto3" json:"input_is_stream,omitempty"`
+	// OutputIsStream is true if the method produces a stream of output
+	// messages.
+	OutputIsStream bool `protobuf:"varint,5,opt,name=output_is_stream,json=outputIsStream,proto3" json:"output_is_stream,omitempty"`
+	// HasSideEffects is false if the method is declared as having no side
+	// effects.
+	HasSideEffects bool `protobuf:"varint,6,opt,name=has_side_effects,json=hasSideEffects,proto3" json:"has_side_effects,omitempty"`
+	// RequiredScopes is the set of scopes that the caller must be granted in
+	// order to call the method, as declared by the "protean.required_scopes"
+	// option.
+	RequiredScopes []string `protobuf:"bytes,7,rep,name=required_scopes,json=requiredScopes,proto3" json:"required_scopes,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MethodInfo) Reset() {
+	*x = MethodInfo{}
+	mi := &file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MethodInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MethodInfo) ProtoMessage() {}
+
+func (x *MethodInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MethodInfo.ProtoReflect.Descriptor instead.
+func (*MethodInfo) Descriptor() ([]byte, []int) {
+	return file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MethodInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *MethodInfo) GetInputType() string {
+	if x != nil {
+		return x.InputType
+	}
+	return ""
+}
+
+func (x *MethodInfo) GetOutputType() string {
+	if x != nil {
+		return x.OutputType
+	}
+	return ""
+}
+
+func (x *MethodInfo) GetInputIsStream() bool {
+	if x != nil {
+		return x.InputIsStream
+	}
+	return false
+}
+
+func (x *MethodInfo) GetOutputIsStream() bool {
+	if x != nil {
+		return x.OutputIsStream
+	}
+	return false
+}
+
+func (x *MethodInfo) GetHasSideEffects() bool {
+	if x != nil {
+		return x.HasSideEffects
+	}
+	return false
+}
+
+func (x *MethodInfo) GetRequiredScopes() []string {
+	if x != nil {
+		return x.RequiredScopes
+	}
+	return nil
+}
+
+var File_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto protoreflect.FileDescriptor
+
+const file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_rawDesc = "" +
+	"\n" +
+	">github.com/dogmatiq/protean/internal/proteanpb/discovery.proto\x12\n" +
+	"protean.v1\x1a google/protobuf/descriptor.proto\"\x96\x01\n" +
+	"\vServiceList\x123\n" +
+	"\bservices\x18\x01 \x03(\v2\x17.protean.v1.ServiceInfoR\bservices\x12R\n" +
+	"\x13file_descriptor_set\x18\x02 \x01(\v2\".google.protobuf.FileDescriptorSetR\x11fileDescriptorSet\"S\n" +
+	"\vServiceInfo\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x120\n" +
+	"\amethods\x18\x02 \x03(\v2\x16.protean.v1.MethodInfoR\amethods\"\x85\x02\n" +
+	"\n" +
+	"MethodInfo\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"input_type\x18\x02 \x01(\tR\tinputType\x12\x1f\n" +
+	"\voutput_type\x18\x03 \x01(\tR\n" +
+	"outputType\x12&\n" +
+	"\x0finput_is_stream\x18\x04 \x01(\bR\rinputIsStream\x12(\n" +
+	"\x10output_is_stream\x18\x05 \x01(\bR\x0eoutputIsStream\x12(\n" +
+	"\x10has_side_effects\x18\x06 \x01(\bR\x0ehasSideEffects\x12'\n" +
+	"\x0frequired_scopes\x18\a \x03(\tR\x0erequiredScopesB0Z.github.com/dogmatiq/protean/internal/proteanpbb\x06proto3"
+
+var (
+	file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_rawDescOnce sync.Once
+	file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_rawDescData []byte
+)
+
+func file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_rawDescGZIP() []byte {
+	file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_rawDescOnce.Do(func() {
+		file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_rawDesc), len(file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_rawDesc)))
+	})
+	return file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_rawDescData
+}
+
+var file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_goTypes = []any{
+	(*ServiceList)(nil),                    // 0: protean.v1.ServiceList
+	(*ServiceInfo)(nil),                    // 1: protean.v1.ServiceInfo
+	(*MethodInfo)(nil),                     // 2: protean.v1.MethodInfo
+	(*descriptorpb.FileDescriptorSet)(nil), // 3: google.protobuf.FileDescriptorSet
+}
+var file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_depIdxs = []int32{
+	1, // 0: protean.v1.ServiceList.services:type_name -> protean.v1.ServiceInfo
+	3, // 1: protean.v1.ServiceList.file_descriptor_set:type_name -> google.protobuf.FileDescriptorSet
+	2, // 2: protean.v1.ServiceInfo.methods:type_name -> protean.v1.MethodInfo
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_init() }
+func file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_init() {
+	if File_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_rawDesc), len(file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_goTypes,
+		DependencyIndexes: file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_depIdxs,
+		MessageInfos:      file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_msgTypes,
+	}.Build()
+	File_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto = out.File
+	file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_goTypes = nil
+	file_github_com_dogmatiq_protean_internal_proteanpb_discovery_proto_depIdxs = nil
+}
@@ -0,0 +1,337 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.32.0
+// source: github.com/dogmatiq/protean/internal/proteanpb/websocket.proto
+
+package proteanpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	anypb "google.golang.org/protobuf/types/known/anypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ClientFrame is the message sent from the client to the server in each frame
+// of a websocket connection or framed request body.
+type ClientFrame struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Frame:
+	//
+	//	*ClientFrame_Input
+	//	*ClientFrame_Done
+	Frame         isClientFrame_Frame `protobuf_oneof:"frame"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClientFrame) Reset() {
+	*x = ClientFrame{}
+	mi := &file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClientFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientFrame) ProtoMessage() {}
+
+func (x *ClientFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientFrame.ProtoReflect.Descriptor instead.
+func (*ClientFrame) Descriptor() ([]byte, []int) {
+	return file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ClientFrame) GetFrame() isClientFrame_Frame {
+	if x != nil {
+		return x.Frame
+	}
+	return nil
+}
+
+func (x *ClientFrame) GetInput() *anypb.Any {
+	if x != nil {
+		if x, ok := x.Frame.(*ClientFrame_Input); ok {
+			return x.Input
+		}
+	}
+	return nil
+}
+
+func (x *ClientFrame) GetDone() *Done {
+	if x != nil {
+		if x, ok := x.Frame.(*ClientFrame_Done); ok {
+			return x.Done
+		}
+	}
+	return nil
+}
+
+type isClientFrame_Frame interface {
+	isClientFrame_Frame()
+}
+
+type ClientFrame_Input struct {
+	// Input is an RPC input message.
+	Input *anypb.Any `protobuf:"bytes,1,opt,name=input,proto3,oneof"`
+}
+
+type ClientFrame_Done struct {
+	// Done indicates that the client will not send any more RPC input
+	// messages.
+	Done *Done `protobuf:"bytes,2,opt,name=done,proto3,oneof"`
+}
+
+func (*ClientFrame_Input) isClientFrame_Frame() {}
+
+func (*ClientFrame_Done) isClientFrame_Frame() {}
+
+// ServerFrame is the message sent from the server to the client in each frame
+// of a websocket connection or framed response body.
+type ServerFrame struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Frame:
+	//
+	//	*ServerFrame_Output
+	//	*ServerFrame_Error
+	//	*ServerFrame_Done
+	Frame         isServerFrame_Frame `protobuf_oneof:"frame"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerFrame) Reset() {
+	*x = ServerFrame{}
+	mi := &file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerFrame) ProtoMessage() {}
+
+func (x *ServerFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerFrame.ProtoReflect.Descriptor instead.
+func (*ServerFrame) Descriptor() ([]byte, []int) {
+	return file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ServerFrame) GetFrame() isServerFrame_Frame {
+	if x != nil {
+		return x.Frame
+	}
+	return nil
+}
+
+func (x *ServerFrame) GetOutput() *anypb.Any {
+	if x != nil {
+		if x, ok := x.Frame.(*ServerFrame_Output); ok {
+			return x.Output
+		}
+	}
+	return nil
+}
+
+func (x *ServerFrame) GetError() *Error {
+	if x != nil {
+		if x, ok := x.Frame.(*ServerFrame_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+func (x *ServerFrame) GetDone() *Done {
+	if x != nil {
+		if x, ok := x.Frame.(*ServerFrame_Done); ok {
+			return x.Done
+		}
+	}
+	return nil
+}
+
+type isServerFrame_Frame interface {
+	isServerFrame_Frame()
+}
+
+type ServerFrame_Output struct {
+	// Output is an RPC output message.
+	Output *anypb.Any `protobuf:"bytes,1,opt,name=output,proto3,oneof"`
+}
+
+type ServerFrame_Error struct {
+	// Error indicates that the RPC method failed. It is always the last frame
+	// sent by the server.
+	Error *Error `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+type ServerFrame_Done struct {
+	// Done indicates that the RPC method completed successfully. It is always
+	// the last frame sent by the server.
+	Done *Done `protobuf:"bytes,3,opt,name=done,proto3,oneof"`
+}
+
+func (*ServerFrame_Output) isServerFrame_Frame() {}
+
+func (*ServerFrame_Error) isServerFrame_Frame() {}
+
+func (*ServerFrame_Done) isServerFrame_Frame() {}
+
+// Done is a marker message that indicates the end of a stream of RPC input or
+// output messages.
+type Done struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Done) Reset() {
+	*x = Done{}
+	mi := &file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Done) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Done) ProtoMessage() {}
+
+func (x *Done) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Done.ProtoReflect.Descriptor instead.
+func (*Done) Descriptor() ([]byte, []int) {
+	return file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_rawDescGZIP(), []int{2}
+}
+
+var File_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto protoreflect.FileDescriptor
+
+const file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_rawDesc = "" +
+	"\n" +
+	">github.com/dogmatiq/protean/internal/proteanpb/websocket.proto\x12\n" +
+	"protean.v1\x1a\x19google/protobuf/any.proto\x1a:github.com/dogmatiq/protean/internal/proteanpb/error.proto\"l\n" +
+	"\vClientFrame\x12,\n" +
+	"\x05input\x18\x01 \x01(\v2\x14.google.protobuf.AnyH\x00R\x05input\x12&\n" +
+	"\x04done\x18\x02 \x01(\v2\x10.protean.v1.DoneH\x00R\x04doneB\a\n" +
+	"\x05frame\"\x99\x01\n" +
+	"\vServerFrame\x12.\n" +
+	"\x06output\x18\x01 \x01(\v2\x14.google.protobuf.AnyH\x00R\x06output\x12)\n" +
+	"\x05error\x18\x02 \x01(\v2\x11.protean.v1.ErrorH\x00R\x05error\x12&\n" +
+	"\x04done\x18\x03 \x01(\v2\x10.protean.v1.DoneH\x00R\x04doneB\a\n" +
+	"\x05frame\"\x06\n" +
+	"\x04DoneB0Z.github.com/dogmatiq/protean/internal/proteanpbb\x06proto3"
+
+var (
+	file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_rawDescOnce sync.Once
+	file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_rawDescData []byte
+)
+
+func file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_rawDescGZIP() []byte {
+	file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_rawDescOnce.Do(func() {
+		file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_rawDesc), len(file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_rawDesc)))
+	})
+	return file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_rawDescData
+}
+
+var file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_goTypes = []any{
+	(*ClientFrame)(nil), // 0: protean.v1.ClientFrame
+	(*ServerFrame)(nil), // 1: protean.v1.ServerFrame
+	(*Done)(nil),        // 2: protean.v1.Done
+	(*anypb.Any)(nil),   // 3: google.protobuf.Any
+	(*Error)(nil),       // 4: protean.v1.Error
+}
+var file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_depIdxs = []int32{
+	3, // 0: protean.v1.ClientFrame.input:type_name -> google.protobuf.Any
+	2, // 1: protean.v1.ClientFrame.done:type_name -> protean.v1.Done
+	3, // 2: protean.v1.ServerFrame.output:type_name -> google.protobuf.Any
+	4, // 3: protean.v1.ServerFrame.error:type_name -> protean.v1.Error
+	2, // 4: protean.v1.ServerFrame.done:type_name -> protean.v1.Done
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_init() }
+func file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_init() {
+	if File_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto != nil {
+		return
+	}
+	file_github_com_dogmatiq_protean_internal_proteanpb_error_proto_init()
+	file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_msgTypes[0].OneofWrappers = []any{
+		(*ClientFrame_Input)(nil),
+		(*ClientFrame_Done)(nil),
+	}
+	file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_msgTypes[1].OneofWrappers = []any{
+		(*ServerFrame_Output)(nil),
+		(*ServerFrame_Error)(nil),
+		(*ServerFrame_Done)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_rawDesc), len(file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_goTypes,
+		DependencyIndexes: file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_depIdxs,
+		MessageInfos:      file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_msgTypes,
+	}.Build()
+	File_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto = out.File
+	file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_goTypes = nil
+	file_github_com_dogmatiq_protean_internal_proteanpb_websocket_proto_depIdxs = nil
+}
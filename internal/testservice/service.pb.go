@@ -7,6 +7,8 @@
 package testservice
 
 import (
+	_ "github.com/dogmatiq/protean/options"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -133,18 +135,20 @@ var File_github_com_dogmatiq_protean_internal_testservice_service_proto protoref
 
 const file_github_com_dogmatiq_protean_internal_testservice_service_proto_rawDesc = "" +
 	"\n" +
-	">github.com/dogmatiq/protean/internal/testservice/service.proto\x12\fprotean.test\"+\n" +
+	">github.com/dogmatiq/protean/internal/testservice/service.proto\x12\fprotean.test\x1a\x1cgoogle/api/annotations.proto\x1a1github.com/dogmatiq/protean/options/options.proto\"+\n" +
 	"\x05Input\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04data\x18\x02 \x01(\tR\x04data\",\n" +
 	"\x06Output\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
-	"\x04data\x18\x02 \x01(\tR\x04data2\x81\x02\n" +
-	"\vTestService\x122\n" +
-	"\x05Unary\x12\x13.protean.test.Input\x1a\x14.protean.test.Output\x12;\n" +
-	"\fClientStream\x12\x13.protean.test.Input\x1a\x14.protean.test.Output(\x01\x12;\n" +
-	"\fServerStream\x12\x13.protean.test.Input\x1a\x14.protean.test.Output0\x01\x12D\n" +
-	"\x13BidirectionalStream\x12\x13.protean.test.Input\x1a\x14.protean.test.Output(\x010\x01B2Z0github.com/dogmatiq/protean/internal/testserviceb\x06proto3"
+	"\x04data\x18\x02 \x01(\tR\x04data2\xec\x03\n" +
+	"\vTestService\x12p\n" +
+	"\x05Unary\x12\x13.protean.test.Input\x1a\x14.protean.test.Output\"<\x82\xd3\xe4\x93\x026:\x01*Z!:\x04datab\x04data\x1a\x13/v1/items/{id}/data\"\x0e/v1/items/{id}\x12\x8d\x01\n" +
+	"\rNoSideEffects\x12\x13.protean.test.Input\x1a\x14.protean.test.Output\"Q\xf2\xf9\x18\ttest:read\xf2\xf9\x18\ttest:list\x82\xd3\xe4\x93\x02.Z\x1c\x12\x1a/v1/{id=folders/**}:lookup\x12\x0e/v1/items/{id}\x90\x02\x01\x12;\n" +
+	"\fClientStream\x12\x13.protean.test.Input\x1a\x14.protean.test.Output(\x01\x12G\n" +
+	"\fServerStream\x12\x13.protean.test.Input\x1a\x14.protean.test.Output\"\n" +
+	"\xe0\xf9\x18\x80\b\xe8\xf9\x18\x80\b0\x01\x12U\n" +
+	"\x13BidirectionalStream\x12\x13.protean.test.Input\x1a\x14.protean.test.Output\"\x0f\xf2\xf9\x18\vtest:stream(\x010\x01B2Z0github.com/dogmatiq/protean/internal/testserviceb\x06proto3"
 
 var (
 	file_github_com_dogmatiq_protean_internal_testservice_service_proto_rawDescOnce sync.Once
@@ -165,15 +169,17 @@ var file_github_com_dogmatiq_protean_internal_testservice_service_proto_goTypes
 }
 var file_github_com_dogmatiq_protean_internal_testservice_service_proto_depIdxs = []int32{
 	0, // 0: protean.test.TestService.Unary:input_type -> protean.test.Input
-	0, // 1: protean.test.TestService.ClientStream:input_type -> protean.test.Input
-	0, // 2: protean.test.TestService.ServerStream:input_type -> protean.test.Input
-	0, // 3: protean.test.TestService.BidirectionalStream:input_type -> protean.test.Input
-	1, // 4: protean.test.TestService.Unary:output_type -> protean.test.Output
-	1, // 5: protean.test.TestService.ClientStream:output_type -> protean.test.Output
-	1, // 6: protean.test.TestService.ServerStream:output_type -> protean.test.Output
-	1, // 7: protean.test.TestService.BidirectionalStream:output_type -> protean.test.Output
-	4, // [4:8] is the sub-list for method output_type
-	0, // [0:4] is the sub-list for method input_type
+	0, // 1: protean.test.TestService.NoSideEffects:input_type -> protean.test.Input
+	0, // 2: protean.test.TestService.ClientStream:input_type -> protean.test.Input
+	0, // 3: protean.test.TestService.ServerStream:input_type -> protean.test.Input
+	0, // 4: protean.test.TestService.BidirectionalStream:input_type -> protean.test.Input
+	1, // 5: protean.test.TestService.Unary:output_type -> protean.test.Output
+	1, // 6: protean.test.TestService.NoSideEffects:output_type -> protean.test.Output
+	1, // 7: protean.test.TestService.ClientStream:output_type -> protean.test.Output
+	1, // 8: protean.test.TestService.ServerStream:output_type -> protean.test.Output
+	1, // 9: protean.test.TestService.BidirectionalStream:output_type -> protean.test.Output
+	5, // [5:10] is the sub-list for method output_type
+	0, // [0:5] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -5,6 +5,7 @@ import "context"
 // Stub is a test implementation of the API interface.
 type Stub struct {
 	UnaryFunc               func(context.Context, *Input) (*Output, error)
+	NoSideEffectsFunc       func(context.Context, *Input) (*Output, error)
 	ServerStreamFunc        func(context.Context, *Input, chan<- *Output) error
 	ClientStreamFunc        func(context.Context, <-chan *Input) (*Output, error)
 	BidirectionalStreamFunc func(context.Context, <-chan *Input, chan<- *Output) error
@@ -20,6 +21,16 @@ func (s *Stub) Unary(ctx context.Context, in *Input) (*Output, error) {
 	return &Output{}, nil
 }
 
+// NoSideEffects calls s.NoSideEffectsFunc(ctx, in) if s.NoSideEffectsFunc is
+// not nil. Otherwise, it returns a zero-value output message.
+func (s *Stub) NoSideEffects(ctx context.Context, in *Input) (*Output, error) {
+	if s.NoSideEffectsFunc != nil {
+		return s.NoSideEffectsFunc(ctx, in)
+	}
+
+	return &Output{}, nil
+}
+
 // ServerStream calls s.ServerStreamFunc(ctx, in, out) if s.ServerStreamFunc is
 // not nil. Otherwise, it returns nil without producing any output messages.
 func (s *Stub) ServerStream(ctx context.Context, in *Input, out chan<- *Output) error {
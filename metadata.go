@@ -0,0 +1,211 @@
+package protean
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/dogmatiq/protean/runtime"
+)
+
+// Metadata contains information about the HTTP request that initiated an RPC
+// call, beyond the RPC input message itself.
+type Metadata struct {
+	// Header contains the HTTP request headers. It must not be modified.
+	Header http.Header
+
+	// RemoteAddr is the network address of the client that sent the request,
+	// typically in "IP:port" form. It does not take into account headers set
+	// by proxies, such as X-Forwarded-For.
+	RemoteAddr string
+
+	// TLS contains information about the TLS connection on which the request
+	// was received. It is nil if the request was not made via TLS.
+	TLS *tls.ConnectionState
+}
+
+// Cookie returns the named cookie provided in the request, or
+// http.ErrNoCookie if it is not found.
+func (m Metadata) Cookie(name string) (*http.Cookie, error) {
+	r := http.Request{Header: m.Header}
+	return r.Cookie(name)
+}
+
+// Cookies returns the cookies provided in the request.
+func (m Metadata) Cookies() []*http.Cookie {
+	r := http.Request{Header: m.Header}
+	return r.Cookies()
+}
+
+// ClientIP returns the IP address of the client that sent the request, as
+// determined from RemoteAddr. It returns nil if the address is not known.
+func (m Metadata) ClientIP() net.IP {
+	host, _, err := net.SplitHostPort(m.RemoteAddr)
+	if err != nil {
+		host = m.RemoteAddr
+	}
+
+	return net.ParseIP(host)
+}
+
+var (
+	// ErrNoMetadata is returned when attempting to set response metadata using
+	// a context that is not associated with an RPC call made via a Handler.
+	ErrNoMetadata = errors.New("the context is not associated with an RPC call made via a protean handler")
+
+	// ErrHeaderSent is returned when attempting to set response headers after
+	// they have already been sent to the client.
+	ErrHeaderSent = errors.New("the response headers have already been sent")
+)
+
+// metadataKey is the context key used to store the callMetadata of an RPC call.
+type metadataKey struct{}
+
+// callMetadata is the request and response metadata of an RPC call.
+type callMetadata struct {
+	request Metadata
+	url     *url.URL
+
+	m       sync.Mutex
+	header  http.Header
+	trailer http.Header
+	sent    bool
+}
+
+// RequestMetadata returns information about the HTTP request that initiated
+// the RPC call associated with ctx.
+//
+// It returns false if ctx is not associated with an RPC call made via a
+// Handler.
+func RequestMetadata(ctx context.Context) (Metadata, bool) {
+	if md, ok := ctx.Value(metadataKey{}).(*callMetadata); ok {
+		return md.request, true
+	}
+
+	return Metadata{}, false
+}
+
+// SetResponseHeader sets an HTTP header in the response to the RPC call
+// associated with ctx, replacing any existing values for the same key.
+//
+// Headers must be set before the transport sends the response headers. For
+// unary RPC methods, this is any time before the method returns. For streaming
+// methods, the headers may be sent before the method is invoked, depending on
+// the transport. Headers that are set by the handler itself, such as
+// Content-Type, take precedence over those set by the RPC method.
+//
+// It returns ErrHeaderSent if the response headers have already been sent, or
+// ErrNoMetadata if ctx is not associated with an RPC call made via a Handler.
+func SetResponseHeader(ctx context.Context, key, value string) error {
+	return modifyResponseMetadata(ctx, func(md *callMetadata) error {
+		if md.sent {
+			return ErrHeaderSent
+		}
+
+		md.header.Set(key, value)
+		return nil
+	})
+}
+
+// AddResponseHeader adds an HTTP header to the response to the RPC call
+// associated with ctx, in addition to any existing values for the same key.
+//
+// See SetResponseHeader() for details of when headers may be set.
+func AddResponseHeader(ctx context.Context, key, value string) error {
+	return modifyResponseMetadata(ctx, func(md *callMetadata) error {
+		if md.sent {
+			return ErrHeaderSent
+		}
+
+		md.header.Add(key, value)
+		return nil
+	})
+}
+
+// SetResponseCookie adds a Set-Cookie header to the response to the RPC call
+// associated with ctx.
+//
+// See SetResponseHeader() for details of when headers may be set.
+func SetResponseCookie(ctx context.Context, c *http.Cookie) error {
+	if err := c.Valid(); err != nil {
+		return err
+	}
+
+	return AddResponseHeader(ctx, "Set-Cookie", c.String())
+}
+
+// SetResponseTrailer sets an HTTP trailer in the response to the RPC call
+// associated with ctx, replacing any existing values for the same key.
+//
+// Trailers may be set at any time before the RPC method returns. They are only
+// sent if the transport and the HTTP protocol version in use support trailers,
+// which is not the case for responses with a fixed Content-Length.
+func SetResponseTrailer(ctx context.Context, key, value string) error {
+	return modifyResponseMetadata(ctx, func(md *callMetadata) error {
+		md.trailer.Set(key, value)
+		return nil
+	})
+}
+
+// modifyResponseMetadata calls fn with the metadata of the RPC call
+// associated with ctx, while holding its lock.
+func modifyResponseMetadata(
+	ctx context.Context,
+	fn func(*callMetadata) error,
+) error {
+	md, ok := ctx.Value(metadataKey{}).(*callMetadata)
+	if !ok {
+		return ErrNoMetadata
+	}
+
+	md.m.Lock()
+	defer md.m.Unlock()
+
+	return fn(md)
+}
+
+// WithRequestHeader returns a copy of ctx that causes RPC clients to send an
+// additional HTTP header with each request made using the returned context.
+//
+// Headers that are set by the client itself, such as Content-Type, can not be
+// overridden.
+func WithRequestHeader(ctx context.Context, key, value string) context.Context {
+	h := runtime.OutgoingHeader(ctx).Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+
+	h.Add(key, value)
+
+	return runtime.WithOutgoingHeader(ctx, h)
+}
+
+// ResponseMetadata contains information about the HTTP response to an RPC
+// call made by an RPC client.
+type ResponseMetadata struct {
+	// Header contains the HTTP response headers.
+	Header http.Header
+
+	// Trailer contains the HTTP response trailers, if any.
+	Trailer http.Header
+}
+
+// CaptureResponseMetadata returns a copy of ctx that causes RPC clients to
+// populate md with the metadata of the response to each call made using the
+// returned context.
+//
+// md is populated whenever the client receives a response, regardless of
+// whether the RPC call succeeds.
+func CaptureResponseMetadata(ctx context.Context, md *ResponseMetadata) context.Context {
+	return runtime.WithResponseHeaderReceiver(
+		ctx,
+		func(header, trailer http.Header) {
+			md.Header = header
+			md.Trailer = trailer
+		},
+	)
+}
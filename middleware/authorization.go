@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/dogmatiq/protean/rpcerror"
+	"google.golang.org/protobuf/proto"
+)
+
+// ScopedPrincipal is a Principal that has been granted a set of scopes, such
+// as the scopes of an OAuth 2.0 access token.
+type ScopedPrincipal interface {
+	Principal
+
+	// HasScope returns true if the principal has been granted the given scope.
+	HasScope(scope string) bool
+}
+
+// Authorizer is an implementation of ServerInterceptor and
+// StreamServerInterceptor that requires the caller to be granted the scopes
+// declared by an RPC method's "protean.required_scopes" option.
+//
+// Calls to methods that declare required scopes fail with an
+// rpcerror.Unauthenticated error if the call is not associated with a
+// Principal, or with an rpcerror.PermissionDenied error if the principal does
+// not implement ScopedPrincipal or has not been granted all of the scopes.
+// Calls to other methods are always permitted.
+//
+// The Authorizer must be installed after the interceptor that authenticates
+// the caller.
+type Authorizer struct{}
+
+// InterceptUnaryRPC returns an error if the caller has not been granted the
+// method's required scopes, otherwise it calls next().
+func (Authorizer) InterceptUnaryRPC(
+	ctx context.Context,
+	info UnaryServerInfo,
+	in proto.Message,
+	next func(ctx context.Context) (out proto.Message, err error),
+) (proto.Message, error) {
+	if err := authorize(ctx, info.RequiredScopes); err != nil {
+		return nil, err
+	}
+
+	return next(ctx)
+}
+
+// InterceptStreamRPC returns an error if the caller has not been granted the
+// method's required scopes, otherwise it calls next().
+func (Authorizer) InterceptStreamRPC(
+	ctx context.Context,
+	info StreamServerInfo,
+	stream ServerStream,
+	next func(ctx context.Context, stream ServerStream) error,
+) error {
+	if err := authorize(ctx, info.RequiredScopes); err != nil {
+		return err
+	}
+
+	return next(ctx, stream)
+}
+
+// authorize returns an error if the principal associated with ctx has not been
+// granted all of the given scopes.
+func authorize(ctx context.Context, scopes []string) error {
+	if len(scopes) == 0 {
+		return nil
+	}
+
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return rpcerror.New(
+			rpcerror.Unauthenticated,
+			"the RPC call requires authentication",
+		)
+	}
+
+	sp, ok := p.(ScopedPrincipal)
+	if !ok {
+		return rpcerror.New(
+			rpcerror.PermissionDenied,
+			"the caller has not been granted any scopes",
+		)
+	}
+
+	for _, s := range scopes {
+		if !sp.HasScope(s) {
+			return rpcerror.New(
+				rpcerror.PermissionDenied,
+				"the caller has not been granted the '%s' scope",
+				s,
+			)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package middleware_test
+
+import (
+	"context"
+
+	"github.com/dogmatiq/protean/internal/testservice"
+	. "github.com/dogmatiq/protean/middleware"
+	"github.com/dogmatiq/protean/rpcerror"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ = Describe("type Authorizer", func() {
+	var authorizer Authorizer
+
+	// call makes a unary call to a method that requires the given scopes.
+	call := func(ctx context.Context, scopes ...string) error {
+		_, err := authorizer.InterceptUnaryRPC(
+			ctx,
+			UnaryServerInfo{
+				Package:        "protean.test",
+				Service:        "TestService",
+				Method:         "Unary",
+				RequiredScopes: scopes,
+			},
+			&testservice.Input{},
+			func(ctx context.Context) (proto.Message, error) {
+				return &testservice.Output{}, nil
+			},
+		)
+
+		return err
+	}
+
+	Describe("func InterceptUnaryRPC()", func() {
+		It("permits calls to methods that do not require any scopes", func() {
+			Expect(call(context.Background())).To(Succeed())
+		})
+
+		It("permits calls by principals that have all of the required scopes", func() {
+			ctx := WithPrincipal(
+				context.Background(),
+				scopedPrincipal{"orders:read", "orders:write"},
+			)
+
+			Expect(call(ctx, "orders:write", "orders:read")).To(Succeed())
+		})
+
+		DescribeTable(
+			"it rejects calls that are not authorized",
+			func(p Principal, expect rpcerror.Error) {
+				ctx := context.Background()
+				if p != nil {
+					ctx = WithPrincipal(ctx, p)
+				}
+
+				Expect(call(ctx, "orders:read", "orders:write")).To(Equal(expect))
+			},
+			Entry(
+				"no principal",
+				nil,
+				rpcerror.New(
+					rpcerror.Unauthenticated,
+					"the RPC call requires authentication",
+				),
+			),
+			Entry(
+				"principal without scopes",
+				principal("<id>"),
+				rpcerror.New(
+					rpcerror.PermissionDenied,
+					"the caller has not been granted any scopes",
+				),
+			),
+			Entry(
+				"principal missing a scope",
+				scopedPrincipal{"orders:read"},
+				rpcerror.New(
+					rpcerror.PermissionDenied,
+					"the caller has not been granted the 'orders:write' scope",
+				),
+			),
+		)
+	})
+
+	Describe("func InterceptStreamRPC()", func() {
+		It("rejects calls by principals that do not have the required scopes", func() {
+			err := authorizer.InterceptStreamRPC(
+				WithPrincipal(context.Background(), scopedPrincipal{"orders:read"}),
+				StreamServerInfo{
+					Package:        "protean.test",
+					Service:        "TestService",
+					Method:         "ServerStream",
+					OutputIsStream: true,
+					RequiredScopes: []string{"orders:write"},
+				},
+				PassThroughStream{},
+				func(context.Context, ServerStream) error {
+					Fail("unexpected call")
+					return nil
+				},
+			)
+
+			Expect(err).To(Equal(
+				rpcerror.New(
+					rpcerror.PermissionDenied,
+					"the caller has not been granted the 'orders:write' scope",
+				),
+			))
+		})
+
+		It("permits calls by principals that have the required scopes", func() {
+			called := false
+
+			err := authorizer.InterceptStreamRPC(
+				WithPrincipal(context.Background(), scopedPrincipal{"orders:write"}),
+				StreamServerInfo{
+					RequiredScopes: []string{"orders:write"},
+				},
+				PassThroughStream{},
+				func(context.Context, ServerStream) error {
+					called = true
+					return nil
+				},
+			)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(called).To(BeTrue())
+		})
+	})
+})
+
+// scopedPrincipal is a ScopedPrincipal that has been granted a fixed set of
+// scopes.
+type scopedPrincipal []string
+
+func (p scopedPrincipal) ID() string {
+	return "<scoped>"
+}
+
+func (p scopedPrincipal) HasScope(scope string) bool {
+	for _, s := range p {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
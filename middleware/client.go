@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryClientInfo encapsulates information about a call to unary RPC method and
+// makes it available to a ClientInterceptor implementation.
+type UnaryClientInfo struct {
+	// Package is the name of the Protocol Buffers package that contains the
+	// service definition.
+	Package string
+
+	// Service is the name of the RPC service.
+	Service string
+
+	// Method is the name of the RPC method being invoked.
+	Method string
+}
+
+// ClientInterceptor is an interface for intercepting RPC method calls on the
+// client-side.
+type ClientInterceptor interface {
+	// InterceptUnaryRPC is called before the RPC input message is sent to the
+	// server.
+	//
+	// It must call next() to forward the call to the next interceptor in the
+	// chain, or ultimately to the server.
+	//
+	// It returns the output that should be returned to the caller. If the
+	// server responds with an error, next() returns an rpcerror.Error.
+	//
+	// The RPC input message may be mutated in place. The output message
+	// returned by next() must not be modified. To produce different RPC output,
+	// return a new output message of the same type, or an error.
+	InterceptUnaryRPC(
+		ctx context.Context,
+		info UnaryClientInfo,
+		in proto.Message,
+		next func(ctx context.Context) (out proto.Message, err error),
+	) (proto.Message, error)
+}
+
+// ClientChain is a ClientInterceptor that chains multiple interceptors to be
+// applied sequentially.
+type ClientChain []ClientInterceptor
+
+// InterceptUnaryRPC is called before the RPC input message is sent to the
+// server.
+//
+// It must call next() to forward the call to the next interceptor in the
+// chain, or ultimately to the server.
+//
+// It returns the output that should be returned to the caller. If the server
+// responds with an error, next() returns an rpcerror.Error.
+//
+// The RPC input message may be mutated in place. The output message returned
+// by next() must not be modified. To produce different RPC output, return a
+// new output message of the same type, or an error.
+func (c ClientChain) InterceptUnaryRPC(
+	ctx context.Context,
+	info UnaryClientInfo,
+	in proto.Message,
+	next func(ctx context.Context) (out proto.Message, err error),
+) (proto.Message, error) {
+	if len(c) == 0 {
+		return next(ctx)
+	}
+
+	head, tail := c[0], c[1:]
+
+	return head.InterceptUnaryRPC(
+		ctx,
+		info,
+		in,
+		func(ctx context.Context) (out proto.Message, err error) {
+			return tail.InterceptUnaryRPC(ctx, info, in, next)
+		},
+	)
+}
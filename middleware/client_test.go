@@ -0,0 +1,113 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dogmatiq/protean/internal/testservice"
+	. "github.com/dogmatiq/protean/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ = Describe("type ClientChain", func() {
+	Describe("func InterceptUnaryRPC()", func() {
+		It("calls each interceptor in the chain", func() {
+			info := UnaryClientInfo{
+				Package: "<package>",
+				Service: "<service>",
+				Method:  "<method>",
+			}
+
+			chain := ClientChain{
+				&clientStub{
+					InterceptUnaryRPCFunc: func(
+						ctx context.Context,
+						i UnaryClientInfo,
+						in proto.Message,
+						next func(ctx context.Context) (out proto.Message, err error),
+					) (proto.Message, error) {
+						Expect(i).To(Equal(info))
+
+						m := in.(*testservice.Input)
+						Expect(m.GetData()).To(Equal("<input one>"))
+						m.Data = "<input two>"
+
+						out, err := next(ctx)
+						Expect(err).To(MatchError("<error two>"))
+						Expect(out.(*testservice.Output).GetData()).To(Equal("<output two>"))
+
+						return &testservice.Output{
+							Data: "<output three>",
+						}, errors.New("<error three>")
+					},
+				},
+				&clientStub{
+					InterceptUnaryRPCFunc: func(
+						ctx context.Context,
+						i UnaryClientInfo,
+						in proto.Message,
+						next func(ctx context.Context) (out proto.Message, err error),
+					) (proto.Message, error) {
+						Expect(i).To(Equal(info))
+
+						m := in.(*testservice.Input)
+						Expect(m.GetData()).To(Equal("<input two>"))
+						m.Data = "<input three>"
+
+						out, err := next(ctx)
+						Expect(err).To(MatchError("<error one>"))
+						Expect(out.(*testservice.Output).GetData()).To(Equal("<output one>"))
+
+						return &testservice.Output{
+							Data: "<output two>",
+						}, errors.New("<error two>")
+					},
+				},
+			}
+
+			in := &testservice.Input{
+				Data: "<input one>",
+			}
+
+			out, err := chain.InterceptUnaryRPC(
+				context.Background(),
+				info,
+				in,
+				func(ctx context.Context) (out proto.Message, err error) {
+					Expect(in.GetData()).To(Equal("<input three>"))
+
+					return &testservice.Output{
+						Data: "<output one>",
+					}, errors.New("<error one>")
+				},
+			)
+
+			Expect(err).To(MatchError("<error three>"))
+			Expect(out.(*testservice.Output).GetData()).To(Equal("<output three>"))
+		})
+	})
+})
+
+type clientStub struct {
+	InterceptUnaryRPCFunc func(
+		ctx context.Context,
+		info UnaryClientInfo,
+		in proto.Message,
+		next func(ctx context.Context) (out proto.Message, err error),
+	) (proto.Message, error)
+}
+
+func (s *clientStub) InterceptUnaryRPC(
+	ctx context.Context,
+	info UnaryClientInfo,
+	in proto.Message,
+	next func(ctx context.Context) (out proto.Message, err error),
+) (proto.Message, error) {
+	if s.InterceptUnaryRPCFunc != nil {
+		return s.InterceptUnaryRPCFunc(ctx, info, in, next)
+	}
+
+	return next(ctx)
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import "context"
+
+// Principal is the identity of an authenticated RPC caller, such as a user or
+// a service account.
+type Principal interface {
+	// ID returns a string that uniquely identifies the principal.
+	ID() string
+}
+
+// principalKey is the context key used to store the Principal associated with
+// an RPC call.
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx that is associated with the given
+// principal.
+//
+// It is typically called by authentication interceptors, before forwarding the
+// call to the next interceptor in the chain.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal associated with ctx.
+//
+// It returns false if ctx is not associated with an authenticated caller.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,31 @@
+package middleware_test
+
+import (
+	"context"
+
+	. "github.com/dogmatiq/protean/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("func PrincipalFromContext()", func() {
+	It("returns the principal associated with the context", func() {
+		ctx := WithPrincipal(context.Background(), principal("<id>"))
+
+		p, ok := PrincipalFromContext(ctx)
+		Expect(ok).To(BeTrue())
+		Expect(p.ID()).To(Equal("<id>"))
+	})
+
+	It("returns false if the context is not associated with a principal", func() {
+		_, ok := PrincipalFromContext(context.Background())
+		Expect(ok).To(BeFalse())
+	})
+})
+
+// principal is a Principal identified by a string.
+type principal string
+
+func (p principal) ID() string {
+	return string(p)
+}
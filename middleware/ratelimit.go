@@ -0,0 +1,300 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/dogmatiq/protean/rpcerror"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RateLimit describes the rate at which calls are permitted, in terms of a
+// token bucket.
+//
+// Each call consumes one token. Tokens are added to the bucket at a constant
+// rate, up to a maximum.
+type RateLimit struct {
+	// Rate is the number of tokens added to the bucket per second. It must be
+	// positive.
+	Rate float64
+
+	// Burst is the maximum number of tokens in the bucket, and hence the
+	// maximum number of calls that are permitted in quick succession. It must
+	// be positive.
+	Burst int
+}
+
+// RateLimitStore is an interface for a store of token buckets.
+//
+// Implementations must be safe for concurrent use.
+type RateLimitStore interface {
+	// Take removes a token from the bucket identified by key, creating a full
+	// bucket if it does not already exist.
+	//
+	// If the bucket is empty, ok is false and retryAfter is the amount of time
+	// until a token becomes available.
+	//
+	// If err is an rpcerror.Error it is returned to the caller of the RPC
+	// method unchanged.
+	Take(
+		ctx context.Context,
+		key string,
+		limit RateLimit,
+	) (ok bool, retryAfter time.Duration, err error)
+}
+
+// RateLimitKey is a function that returns the key of the token bucket used to
+// rate limit an RPC call.
+//
+// method is the fully-qualified name of the RPC method, in the form
+// "<package>.<service>/<method>".
+type RateLimitKey func(ctx context.Context, method string) string
+
+// KeyByMethod is a RateLimitKey that uses a separate token bucket for each RPC
+// method, shared by all callers.
+func KeyByMethod(_ context.Context, method string) string {
+	return method
+}
+
+// KeyByCaller returns a RateLimitKey that uses a separate token bucket for
+// each caller, shared by all RPC methods.
+//
+// identity returns a string that identifies the caller associated with ctx,
+// such as a user ID or API key. Calls for which identity returns an empty
+// string share a single bucket.
+func KeyByCaller(identity func(ctx context.Context) string) RateLimitKey {
+	return func(ctx context.Context, _ string) string {
+		return identity(ctx)
+	}
+}
+
+// KeyByPrincipal is a RateLimitKey that uses a separate token bucket for each
+// authenticated principal, shared by all RPC methods.
+//
+// Calls that are not associated with a principal share a single bucket.
+func KeyByPrincipal(ctx context.Context, _ string) string {
+	if p, ok := PrincipalFromContext(ctx); ok {
+		return p.ID()
+	}
+
+	return ""
+}
+
+// RateLimiter is an implementation of ServerInterceptor and
+// StreamServerInterceptor that limits the rate at which RPC methods may be
+// called.
+//
+// Each call to a unary or streaming RPC method consumes a single token from a
+// token bucket. Calls that are made when the bucket is empty fail with an
+// rpcerror.ResourceExhausted error that has an rpcerror.RetryInfo details
+// value, which the handler uses to set the Retry-After header.
+//
+// It is safe for concurrent use. It must not be copied after first use.
+type RateLimiter struct {
+	// Limit is the rate limit applied to each token bucket.
+	Limit RateLimit
+
+	// Key returns the key of the token bucket used for each call. If it is
+	// nil, KeyByMethod is used.
+	Key RateLimitKey
+
+	// Store is the store that contains the token buckets. If it is nil, the
+	// buckets are held in memory by a MemoryRateLimitStore.
+	Store RateLimitStore
+
+	once  sync.Once
+	store RateLimitStore
+}
+
+// InterceptUnaryRPC returns an error if the rate limit for the call has been
+// exceeded, otherwise it calls next().
+func (l *RateLimiter) InterceptUnaryRPC(
+	ctx context.Context,
+	info UnaryServerInfo,
+	in proto.Message,
+	next func(ctx context.Context) (out proto.Message, err error),
+) (proto.Message, error) {
+	if err := l.take(ctx, info.Package, info.Service, info.Method); err != nil {
+		return nil, err
+	}
+
+	return next(ctx)
+}
+
+// InterceptStreamRPC returns an error if the rate limit for the call has been
+// exceeded, otherwise it calls next().
+//
+// The call consumes a single token, regardless of the number of messages sent
+// or received.
+func (l *RateLimiter) InterceptStreamRPC(
+	ctx context.Context,
+	info StreamServerInfo,
+	stream ServerStream,
+	next func(ctx context.Context, stream ServerStream) error,
+) error {
+	if err := l.take(ctx, info.Package, info.Service, info.Method); err != nil {
+		return err
+	}
+
+	return next(ctx, stream)
+}
+
+// take removes a token from the bucket used for a call to the given method.
+func (l *RateLimiter) take(ctx context.Context, pkg, service, method string) error {
+	l.once.Do(func() {
+		l.store = l.Store
+		if l.store == nil {
+			l.store = &MemoryRateLimitStore{}
+		}
+	})
+
+	name := pkg + "." + service + "/" + method
+
+	key := KeyByMethod
+	if l.Key != nil {
+		key = l.Key
+	}
+
+	ok, retryAfter, err := l.store.Take(ctx, key(ctx, name), l.Limit)
+	if err != nil {
+		if rpcErr, ok := err.(rpcerror.Error); ok {
+			return rpcErr
+		}
+
+		return rpcerror.New(
+			rpcerror.Unavailable,
+			"the rate limit could not be checked",
+		).WithCause(err)
+	}
+
+	if ok {
+		return nil
+	}
+
+	return rpcerror.New(
+		rpcerror.ResourceExhausted,
+		"the rate limit has been exceeded",
+	).WithDetails(
+		&rpcerror.RetryInfo{
+			RetryDelay: durationpb.New(retryAfter),
+		},
+	)
+}
+
+// memoryStoreSweepInterval is the number of calls to
+// MemoryRateLimitStore.Take() between each removal of full buckets.
+const memoryStoreSweepInterval = 1000
+
+// MemoryRateLimitStore is a RateLimitStore that keeps token buckets in memory.
+//
+// Buckets that have been refilled are discarded periodically, such that the
+// memory used is proportional to the number of recently active keys.
+//
+// Each bucket is identified by both its key and its limit, such that rate
+// limiters that share a store and produce the same keys do not affect each
+// other's limits.
+//
+// It is safe for concurrent use. The zero value is ready to use.
+type MemoryRateLimitStore struct {
+	m       sync.Mutex
+	buckets map[tokenBucketKey]*tokenBucket
+	calls   int
+}
+
+// tokenBucketKey identifies a token bucket held by a MemoryRateLimitStore.
+type tokenBucketKey struct {
+	key   string
+	limit RateLimit
+}
+
+// tokenBucket is a token bucket held by a MemoryRateLimitStore.
+type tokenBucket struct {
+	limit  RateLimit
+	tokens float64
+	at     time.Time
+}
+
+// Take removes a token from the bucket identified by key, creating a full
+// bucket if it does not already exist.
+//
+// If the bucket is empty, ok is false and retryAfter is the amount of time
+// until a token becomes available.
+//
+// It returns an rpcerror.Unknown error if the limit's rate or burst is not
+// positive.
+func (s *MemoryRateLimitStore) Take(
+	_ context.Context,
+	key string,
+	limit RateLimit,
+) (ok bool, retryAfter time.Duration, err error) {
+	if limit.Rate <= 0 || limit.Burst <= 0 {
+		return false, 0, rpcerror.New(
+			rpcerror.Unknown,
+			"the rate limit must be positive",
+		)
+	}
+
+	now := time.Now()
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.calls++
+	if s.calls%memoryStoreSweepInterval == 0 {
+		s.sweep(now)
+	}
+
+	k := tokenBucketKey{key, limit}
+
+	b, exists := s.buckets[k]
+	if !exists {
+		if s.buckets == nil {
+			s.buckets = map[tokenBucketKey]*tokenBucket{}
+		}
+
+		b = &tokenBucket{
+			limit:  limit,
+			tokens: float64(limit.Burst),
+		}
+		s.buckets[k] = b
+	} else {
+		b.refill(now)
+	}
+
+	b.at = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+
+	wait := (1 - b.tokens) / limit.Rate * float64(time.Second)
+
+	return false, time.Duration(math.Ceil(wait)), nil
+}
+
+// sweep discards any buckets that are full as of now.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	for k, b := range s.buckets {
+		b.refill(now)
+
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(s.buckets, k)
+		}
+	}
+}
+
+// refill adds the tokens that have accumulated since the bucket was last
+// updated.
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.at).Seconds()
+	b.at = now
+
+	b.tokens = math.Min(
+		b.tokens+elapsed*b.limit.Rate,
+		float64(b.limit.Burst),
+	)
+}
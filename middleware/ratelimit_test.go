@@ -0,0 +1,328 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dogmatiq/protean/internal/testservice"
+	. "github.com/dogmatiq/protean/middleware"
+	"github.com/dogmatiq/protean/rpcerror"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ = Describe("type RateLimiter", func() {
+	var (
+		limiter *RateLimiter
+		info    UnaryServerInfo
+	)
+
+	BeforeEach(func() {
+		limiter = &RateLimiter{
+			Limit: RateLimit{
+				Rate:  1.0 / 3600, // one call per hour
+				Burst: 2,
+			},
+		}
+
+		info = UnaryServerInfo{
+			Package: "protean.test",
+			Service: "TestService",
+			Method:  "Unary",
+		}
+	})
+
+	// call makes a unary call via the limiter.
+	call := func(ctx context.Context, info UnaryServerInfo) error {
+		_, err := limiter.InterceptUnaryRPC(
+			ctx,
+			info,
+			&testservice.Input{},
+			func(ctx context.Context) (proto.Message, error) {
+				return &testservice.Output{}, nil
+			},
+		)
+		return err
+	}
+
+	// expectRateLimited expects err to be a rate limit error with a retry
+	// delay close to the given duration.
+	expectRateLimited := func(err error, delay time.Duration) {
+		rpcErr, ok := err.(rpcerror.Error)
+		Expect(ok).To(BeTrue(), "expected an rpcerror.Error")
+		Expect(rpcErr.Code()).To(Equal(rpcerror.ResourceExhausted))
+		Expect(rpcErr.Message()).To(Equal("the rate limit has been exceeded"))
+
+		details, ok, err := rpcErr.Details()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+
+		retry, ok := details.(*rpcerror.RetryInfo)
+		Expect(ok).To(BeTrue(), "expected an rpcerror.RetryInfo")
+		Expect(retry.GetRetryDelay().AsDuration()).To(BeNumerically("~", delay, time.Second))
+	}
+
+	Describe("func InterceptUnaryRPC()", func() {
+		It("allows calls until the bucket is empty", func() {
+			Expect(call(context.Background(), info)).To(Succeed())
+			Expect(call(context.Background(), info)).To(Succeed())
+
+			err := call(context.Background(), info)
+			expectRateLimited(err, time.Hour)
+		})
+
+		It("does not call next() if the rate limit is exceeded", func() {
+			limiter.Limit.Burst = 1
+			Expect(call(context.Background(), info)).To(Succeed())
+
+			_, err := limiter.InterceptUnaryRPC(
+				context.Background(),
+				info,
+				&testservice.Input{},
+				func(ctx context.Context) (proto.Message, error) {
+					Fail("unexpected call")
+					return nil, nil
+				},
+			)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("uses a separate bucket for each method by default", func() {
+			limiter.Limit.Burst = 1
+			Expect(call(context.Background(), info)).To(Succeed())
+
+			other := info
+			other.Method = "NoSideEffects"
+			Expect(call(context.Background(), other)).To(Succeed())
+
+			Expect(call(context.Background(), info)).ShouldNot(Succeed())
+			Expect(call(context.Background(), other)).ShouldNot(Succeed())
+		})
+
+		It("uses the key function to select the bucket", func() {
+			type callerKey struct{}
+
+			limiter.Limit.Burst = 1
+			limiter.Key = KeyByCaller(func(ctx context.Context) string {
+				id, _ := ctx.Value(callerKey{}).(string)
+				return id
+			})
+
+			alice := context.WithValue(context.Background(), callerKey{}, "alice")
+			bob := context.WithValue(context.Background(), callerKey{}, "bob")
+
+			other := info
+			other.Method = "NoSideEffects"
+
+			Expect(call(alice, info)).To(Succeed())
+			Expect(call(alice, other)).ShouldNot(Succeed())
+			Expect(call(bob, other)).To(Succeed())
+			Expect(call(context.Background(), info)).To(Succeed())
+			Expect(call(context.Background(), info)).ShouldNot(Succeed())
+		})
+
+		It("can use a separate bucket for each principal", func() {
+			limiter.Limit.Burst = 1
+			limiter.Key = KeyByPrincipal
+
+			alice := WithPrincipal(context.Background(), principal("alice"))
+			bob := WithPrincipal(context.Background(), principal("bob"))
+
+			Expect(call(alice, info)).To(Succeed())
+			Expect(call(alice, info)).ShouldNot(Succeed())
+			Expect(call(bob, info)).To(Succeed())
+			Expect(call(context.Background(), info)).To(Succeed())
+			Expect(call(context.Background(), info)).ShouldNot(Succeed())
+		})
+
+		It("passes the fully-qualified method name to the key function", func() {
+			limiter.Key = func(_ context.Context, method string) string {
+				Expect(method).To(Equal("protean.test.TestService/Unary"))
+				return method
+			}
+
+			Expect(call(context.Background(), info)).To(Succeed())
+		})
+
+		It("uses the store if one is provided", func() {
+			store := &MemoryRateLimitStore{}
+			limiter.Limit.Burst = 1
+			limiter.Store = store
+
+			Expect(call(context.Background(), info)).To(Succeed())
+
+			ok, _, err := store.Take(context.Background(), "protean.test.TestService/Unary", limiter.Limit)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("returns an error if the store fails", func() {
+			limiter.Store = rateLimitStoreFunc(func(
+				context.Context,
+				string,
+				RateLimit,
+			) (bool, time.Duration, error) {
+				return false, 0, errors.New("<error>")
+			})
+
+			err := call(context.Background(), info)
+			Expect(err).To(Equal(
+				rpcerror.New(
+					rpcerror.Unavailable,
+					"the rate limit could not be checked",
+				).WithCause(errors.New("<error>")),
+			))
+		})
+
+		It("returns the error produced by the store if it is an rpcerror.Error", func() {
+			limiter.Limit = RateLimit{}
+
+			err := call(context.Background(), info)
+			Expect(err).To(Equal(
+				rpcerror.New(
+					rpcerror.Unknown,
+					"the rate limit must be positive",
+				),
+			))
+		})
+
+		It("is safe for concurrent use", func() {
+			limiter.Limit.Burst = 10
+
+			var (
+				g         sync.WaitGroup
+				succeeded atomic.Int32
+			)
+
+			for i := 0; i < 50; i++ {
+				g.Add(1)
+				go func() {
+					defer g.Done()
+					if call(context.Background(), info) == nil {
+						succeeded.Add(1)
+					}
+				}()
+			}
+
+			g.Wait()
+			Expect(succeeded.Load()).To(BeEquivalentTo(10))
+		})
+	})
+
+	Describe("func InterceptStreamRPC()", func() {
+		It("consumes a single token for each call", func() {
+			limiter.Limit.Burst = 1
+
+			streamInfo := StreamServerInfo{
+				Package:        info.Package,
+				Service:        info.Service,
+				Method:         "ServerStream",
+				OutputIsStream: true,
+			}
+
+			err := limiter.InterceptStreamRPC(
+				context.Background(),
+				streamInfo,
+				PassThroughStream{},
+				func(ctx context.Context, stream ServerStream) error {
+					for i := 0; i < 3; i++ {
+						if _, err := stream.SendOutput(ctx, &testservice.Output{}); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			err = limiter.InterceptStreamRPC(
+				context.Background(),
+				streamInfo,
+				PassThroughStream{},
+				func(context.Context, ServerStream) error {
+					Fail("unexpected call")
+					return nil
+				},
+			)
+			expectRateLimited(err, time.Hour)
+		})
+	})
+})
+
+var _ = Describe("type MemoryRateLimitStore", func() {
+	var store *MemoryRateLimitStore
+
+	BeforeEach(func() {
+		store = &MemoryRateLimitStore{}
+	})
+
+	Describe("func Take()", func() {
+		It("refills the bucket over time", func() {
+			limit := RateLimit{
+				Rate:  50,
+				Burst: 1,
+			}
+
+			ok, _, err := store.Take(context.Background(), "<key>", limit)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+
+			ok, retryAfter, err := store.Take(context.Background(), "<key>", limit)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+			Expect(retryAfter).To(BeNumerically("~", 20*time.Millisecond, 5*time.Millisecond))
+
+			Eventually(func() bool {
+				ok, _, _ := store.Take(context.Background(), "<key>", limit)
+				return ok
+			}).Should(BeTrue())
+		})
+
+		It("uses a separate bucket for each limit", func() {
+			a := RateLimit{Rate: 1.0 / 3600, Burst: 1}
+			b := RateLimit{Rate: 1.0 / 3600, Burst: 2}
+
+			ok, _, err := store.Take(context.Background(), "<key>", a)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+
+			ok, _, err = store.Take(context.Background(), "<key>", a)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+
+			ok, _, err = store.Take(context.Background(), "<key>", b)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+		})
+
+		It("returns an error if the limit is not positive", func() {
+			_, _, err := store.Take(context.Background(), "<key>", RateLimit{})
+			Expect(err).To(Equal(
+				rpcerror.New(
+					rpcerror.Unknown,
+					"the rate limit must be positive",
+				),
+			))
+		})
+	})
+})
+
+// rateLimitStoreFunc is an adaptor that allows a function to be used as a
+// RateLimitStore.
+type rateLimitStoreFunc func(
+	ctx context.Context,
+	key string,
+	limit RateLimit,
+) (bool, time.Duration, error)
+
+func (fn rateLimitStoreFunc) Take(
+	ctx context.Context,
+	key string,
+	limit RateLimit,
+) (bool, time.Duration, error) {
+	return fn(ctx, key, limit)
+}
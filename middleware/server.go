@@ -18,6 +18,11 @@ type UnaryServerInfo struct {
 
 	// Method is the name of the RPC method being invoked.
 	Method string
+
+	// RequiredScopes is the set of scopes that the caller must be granted in
+	// order to call the method, as declared by the "protean.required_scopes"
+	// option. It is enforced by the Authorizer interceptor.
+	RequiredScopes []string
 }
 
 // ServerInterceptor is an interface intercepting RPC method calls on the
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// StreamServerInfo encapsulates information about a call to a streaming RPC
+// method and makes it available to a StreamServerInterceptor implementation.
+type StreamServerInfo struct {
+	// Package is the name of the Protocol Buffers package that contains the
+	// service definition.
+	Package string
+
+	// Service is the name of the RPC service.
+	Service string
+
+	// Method is the name of the RPC method being invoked.
+	Method string
+
+	// InputIsStream is true if the method accepts a stream of input messages,
+	// as opposed to a single input message.
+	InputIsStream bool
+
+	// OutputIsStream is true if the method produces a stream of output
+	// messages, as opposed to a single output message.
+	OutputIsStream bool
+
+	// RequiredScopes is the set of scopes that the caller must be granted in
+	// order to call the method, as declared by the "protean.required_scopes"
+	// option. It is enforced by the Authorizer interceptor.
+	RequiredScopes []string
+}
+
+// ServerStream is a set of hooks that are called for each message sent or
+// received during a call to a streaming RPC method.
+//
+// A StreamServerInterceptor typically wraps the ServerStream it is given in
+// order to inspect, modify or reject individual messages.
+type ServerStream interface {
+	// RecvInput is called with each RPC input message received from the
+	// client, before it is passed to the RPC method.
+	//
+	// It returns the message to pass to the RPC method, which may be in, a
+	// modified version of in, or an entirely new message of the same type. If
+	// it returns an error the call fails with that error.
+	//
+	// An implementation that wraps another ServerStream should call the
+	// wrapped stream's RecvInput() method before inspecting the message.
+	RecvInput(ctx context.Context, in proto.Message) (proto.Message, error)
+
+	// SendOutput is called with each RPC output message produced by the RPC
+	// method, before it is sent to the client.
+	//
+	// It returns the message to send to the client, which may be out or an
+	// entirely new message of the same type. The message produced by the RPC
+	// method must not be modified in place. If it returns an error the call
+	// fails with that error.
+	//
+	// An implementation that wraps another ServerStream should inspect the
+	// message before calling the wrapped stream's SendOutput() method.
+	SendOutput(ctx context.Context, out proto.Message) (proto.Message, error)
+}
+
+// StreamServerInterceptor is an interface for intercepting calls to streaming
+// RPC methods on the server-side.
+type StreamServerInterceptor interface {
+	// InterceptStreamRPC is called before the RPC method is invoked.
+	//
+	// It must call next() to forward the call to the next interceptor in the
+	// chain, or ultimately to the application-defined server implementation.
+	// next() blocks until the RPC method returns.
+	//
+	// stream is the set of hooks that are called for each message. To
+	// intercept individual messages, pass a ServerStream that wraps stream to
+	// next(), otherwise pass stream itself.
+	//
+	// It returns the error that should be sent to the client, if any.
+	InterceptStreamRPC(
+		ctx context.Context,
+		info StreamServerInfo,
+		stream ServerStream,
+		next func(ctx context.Context, stream ServerStream) error,
+	) error
+}
+
+// StreamServerChain is a StreamServerInterceptor that chains multiple
+// interceptors to be applied sequentially.
+//
+// Input messages are passed through the hooks of each interceptor in the order
+// that they appear in the chain. Output messages are passed through the hooks
+// in the reverse order.
+type StreamServerChain []StreamServerInterceptor
+
+// InterceptStreamRPC is called before the RPC method is invoked.
+//
+// It must call next() to forward the call to the next interceptor in the
+// chain, or ultimately to the application-defined server implementation.
+// next() blocks until the RPC method returns.
+//
+// stream is the set of hooks that are called for each message. To intercept
+// individual messages, pass a ServerStream that wraps stream to next(),
+// otherwise pass stream itself.
+//
+// It returns the error that should be sent to the client, if any.
+func (c StreamServerChain) InterceptStreamRPC(
+	ctx context.Context,
+	info StreamServerInfo,
+	stream ServerStream,
+	next func(ctx context.Context, stream ServerStream) error,
+) error {
+	if len(c) == 0 {
+		return next(ctx, stream)
+	}
+
+	head, tail := c[0], c[1:]
+
+	return head.InterceptStreamRPC(
+		ctx,
+		info,
+		stream,
+		func(ctx context.Context, stream ServerStream) error {
+			return tail.InterceptStreamRPC(ctx, info, stream, next)
+		},
+	)
+}
+
+// PassThroughStream is a ServerStream that passes each message through
+// unchanged.
+//
+// It is the innermost ServerStream passed to a StreamServerInterceptor.
+type PassThroughStream struct{}
+
+// RecvInput returns in unchanged.
+func (PassThroughStream) RecvInput(_ context.Context, in proto.Message) (proto.Message, error) {
+	return in, nil
+}
+
+// SendOutput returns out unchanged.
+func (PassThroughStream) SendOutput(_ context.Context, out proto.Message) (proto.Message, error) {
+	return out, nil
+}
@@ -0,0 +1,163 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dogmatiq/protean/internal/testservice"
+	. "github.com/dogmatiq/protean/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ = Describe("type StreamServerChain", func() {
+	Describe("func InterceptStreamRPC()", func() {
+		It("calls each interceptor in the chain", func() {
+			info := StreamServerInfo{
+				Package:        "<package>",
+				Service:        "<service>",
+				Method:         "<method>",
+				InputIsStream:  true,
+				OutputIsStream: true,
+			}
+
+			var order []string
+
+			// interceptor returns an interceptor that wraps the stream it is
+			// given, recording the order in which the hooks are called.
+			interceptor := func(name string) StreamServerInterceptor {
+				return &streamServerStub{
+					InterceptStreamRPCFunc: func(
+						ctx context.Context,
+						i StreamServerInfo,
+						stream ServerStream,
+						next func(ctx context.Context, stream ServerStream) error,
+					) error {
+						Expect(i).To(Equal(info))
+
+						order = append(order, name+" before")
+						err := next(ctx, &serverStreamStub{
+							ServerStream: stream,
+							RecvInputFunc: func(in proto.Message) (proto.Message, error) {
+								order = append(order, name+" input")
+								return in, nil
+							},
+							SendOutputFunc: func(out proto.Message) (proto.Message, error) {
+								order = append(order, name+" output")
+								return out, nil
+							},
+						})
+						order = append(order, name+" after")
+
+						return err
+					},
+				}
+			}
+
+			chain := StreamServerChain{
+				interceptor("<one>"),
+				interceptor("<two>"),
+			}
+
+			err := chain.InterceptStreamRPC(
+				context.Background(),
+				info,
+				PassThroughStream{},
+				func(ctx context.Context, stream ServerStream) error {
+					in := &testservice.Input{}
+					m, err := stream.RecvInput(ctx, in)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(m).To(BeIdenticalTo(in))
+
+					out := &testservice.Output{}
+					m, err = stream.SendOutput(ctx, out)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(m).To(BeIdenticalTo(out))
+
+					return errors.New("<error>")
+				},
+			)
+
+			Expect(err).To(MatchError("<error>"))
+			Expect(order).To(Equal([]string{
+				"<one> before",
+				"<two> before",
+				"<one> input",
+				"<two> input",
+				"<two> output",
+				"<one> output",
+				"<two> after",
+				"<one> after",
+			}))
+		})
+
+		It("calls next() directly if the chain is empty", func() {
+			called := false
+
+			err := StreamServerChain{}.InterceptStreamRPC(
+				context.Background(),
+				StreamServerInfo{},
+				PassThroughStream{},
+				func(ctx context.Context, stream ServerStream) error {
+					called = true
+					Expect(stream).To(Equal(PassThroughStream{}))
+					return nil
+				},
+			)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(called).To(BeTrue())
+		})
+	})
+})
+
+type streamServerStub struct {
+	InterceptStreamRPCFunc func(
+		ctx context.Context,
+		info StreamServerInfo,
+		stream ServerStream,
+		next func(ctx context.Context, stream ServerStream) error,
+	) error
+}
+
+func (s *streamServerStub) InterceptStreamRPC(
+	ctx context.Context,
+	info StreamServerInfo,
+	stream ServerStream,
+	next func(ctx context.Context, stream ServerStream) error,
+) error {
+	if s.InterceptStreamRPCFunc != nil {
+		return s.InterceptStreamRPCFunc(ctx, info, stream, next)
+	}
+
+	return next(ctx, stream)
+}
+
+type serverStreamStub struct {
+	ServerStream
+
+	RecvInputFunc  func(proto.Message) (proto.Message, error)
+	SendOutputFunc func(proto.Message) (proto.Message, error)
+}
+
+func (s *serverStreamStub) RecvInput(ctx context.Context, in proto.Message) (proto.Message, error) {
+	in, err := s.ServerStream.RecvInput(ctx, in)
+	if err != nil || s.RecvInputFunc == nil {
+		return in, err
+	}
+
+	return s.RecvInputFunc(in)
+}
+
+func (s *serverStreamStub) SendOutput(ctx context.Context, out proto.Message) (proto.Message, error) {
+	if s.SendOutputFunc != nil {
+		var err error
+		out, err = s.SendOutputFunc(out)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.ServerStream.SendOutput(ctx, out)
+}
@@ -19,8 +19,9 @@ type ValidatableMessage interface {
 	Validate() error
 }
 
-// Validator is an implementation of ServerInterceptor that validates RPC input
-// and messages by calling their Validate() method, if present.
+// Validator is an implementation of ServerInterceptor and
+// StreamServerInterceptor that validates RPC input and output messages by
+// calling their Validate() method, if present.
 //
 // The Validator interceptor is installed by default.
 type Validator struct{}
@@ -33,9 +34,67 @@ func (Validator) InterceptUnaryRPC(
 	in proto.Message,
 	next func(ctx context.Context) (out proto.Message, err error),
 ) (proto.Message, error) {
+	if err := validateInput(in); err != nil {
+		return nil, err
+	}
+
+	out, err := next(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateOutput(out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// InterceptStreamRPC causes the call to fail if any RPC input or output
+// message that implements ValidatableMessage is invalid.
+func (Validator) InterceptStreamRPC(
+	ctx context.Context,
+	info StreamServerInfo,
+	stream ServerStream,
+	next func(ctx context.Context, stream ServerStream) error,
+) error {
+	return next(ctx, validatingStream{stream})
+}
+
+// validatingStream is a ServerStream that validates each message.
+type validatingStream struct {
+	ServerStream
+}
+
+// RecvInput returns an error if in is invalid.
+func (s validatingStream) RecvInput(ctx context.Context, in proto.Message) (proto.Message, error) {
+	in, err := s.ServerStream.RecvInput(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateInput(in); err != nil {
+		return nil, err
+	}
+
+	return in, nil
+}
+
+// SendOutput returns an error if out is invalid.
+func (s validatingStream) SendOutput(ctx context.Context, out proto.Message) (proto.Message, error) {
+	if err := validateOutput(out); err != nil {
+		return nil, err
+	}
+
+	return s.ServerStream.SendOutput(ctx, out)
+}
+
+// validateInput returns an error if in implements ValidatableMessage and is
+// invalid.
+func validateInput(in proto.Message) error {
 	if in, ok := in.(ValidatableMessage); ok {
 		if err := in.Validate(); err != nil {
-			return nil, rpcerror.New(
+			return rpcerror.New(
 				rpcerror.InvalidInput,
 				"the RPC input message is invalid: %s",
 				err.Error(),
@@ -43,19 +102,20 @@ func (Validator) InterceptUnaryRPC(
 		}
 	}
 
-	out, err := next(ctx)
-	if err != nil {
-		return nil, err
-	}
+	return nil
+}
 
+// validateOutput returns an error if out implements ValidatableMessage and is
+// invalid.
+func validateOutput(out proto.Message) error {
 	if out, ok := out.(ValidatableMessage); ok {
 		if err := out.Validate(); err != nil {
-			return nil, rpcerror.New(
+			return rpcerror.New(
 				rpcerror.Unknown,
 				"the server produced an invalid RPC output message",
 			).WithCause(err)
 		}
 	}
 
-	return out, nil
+	return nil
 }
@@ -115,4 +115,89 @@ var _ = Describe("type Validator", func() {
 			Expect(err).To(MatchError("<error>"))
 		})
 	})
+
+	Describe("func InterceptStreamRPC()", func() {
+		// intercept calls InterceptStreamRPC() and returns the stream that is
+		// passed to next().
+		intercept := func() ServerStream {
+			var stream ServerStream
+
+			err := validator.InterceptStreamRPC(
+				context.Background(),
+				StreamServerInfo{},
+				PassThroughStream{},
+				func(ctx context.Context, s ServerStream) error {
+					stream = s
+					return nil
+				},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			return stream
+		}
+
+		It("accepts valid input messages", func() {
+			in := &testservice.Input{
+				Data: "<data>",
+			}
+
+			m, err := intercept().RecvInput(context.Background(), in)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(m).To(BeIdenticalTo(in))
+		})
+
+		It("returns an error if an input message is invalid", func() {
+			_, err := intercept().RecvInput(
+				context.Background(),
+				&testservice.Input{
+					Data: "", // invalid
+				},
+			)
+
+			Expect(err).To(Equal(
+				rpcerror.New(
+					rpcerror.InvalidInput,
+					"the RPC input message is invalid: input data must not be empty",
+				),
+			))
+		})
+
+		It("accepts valid output messages", func() {
+			out := &testservice.Output{
+				Data: "<data>",
+			}
+
+			m, err := intercept().SendOutput(context.Background(), out)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(m).To(BeIdenticalTo(out))
+		})
+
+		It("returns an error if an output message is invalid", func() {
+			_, err := intercept().SendOutput(
+				context.Background(),
+				&testservice.Output{
+					Data: "", // invalid
+				},
+			)
+
+			Expect(err).To(Equal(
+				rpcerror.New(
+					rpcerror.Unknown,
+					"the server produced an invalid RPC output message",
+				).WithCause(
+					errors.New("output data must not be empty"),
+				),
+			))
+		})
+
+		It("accepts messages that do not implement ValidatableMessage", func() {
+			stream := intercept()
+
+			_, err := stream.RecvInput(context.Background(), &stringservice.ToUpperRequest{})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = stream.SendOutput(context.Background(), &stringservice.ToUpperResponse{})
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
 })
@@ -0,0 +1,3 @@
+// Package options contains the Go definitions of Protean's custom Protocol
+// Buffers options, which are defined in options.proto.
+package options
@@ -0,0 +1,167 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.32.0
+// source: github.com/dogmatiq/protean/options/options.proto
+
+package options
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var file_github_com_dogmatiq_protean_options_options_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*int64)(nil),
+		Field:         51100,
+		Name:          "protean.max_input_size",
+		Tag:           "varint,51100,opt,name=max_input_size",
+		Filename:      "github.com/dogmatiq/protean/options/options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*int64)(nil),
+		Field:         51101,
+		Name:          "protean.max_output_size",
+		Tag:           "varint,51101,opt,name=max_output_size",
+		Filename:      "github.com/dogmatiq/protean/options/options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: ([]string)(nil),
+		Field:         51102,
+		Name:          "protean.required_scopes",
+		Tag:           "bytes,51102,rep,name=required_scopes",
+		Filename:      "github.com/dogmatiq/protean/options/options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.ServiceOptions)(nil),
+		ExtensionType: (*int64)(nil),
+		Field:         51100,
+		Name:          "protean.service_max_input_size",
+		Tag:           "varint,51100,opt,name=service_max_input_size",
+		Filename:      "github.com/dogmatiq/protean/options/options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.ServiceOptions)(nil),
+		ExtensionType: (*int64)(nil),
+		Field:         51101,
+		Name:          "protean.service_max_output_size",
+		Tag:           "varint,51101,opt,name=service_max_output_size",
+		Filename:      "github.com/dogmatiq/protean/options/options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.ServiceOptions)(nil),
+		ExtensionType: ([]string)(nil),
+		Field:         51102,
+		Name:          "protean.service_required_scopes",
+		Tag:           "bytes,51102,rep,name=service_required_scopes",
+		Filename:      "github.com/dogmatiq/protean/options/options.proto",
+	},
+}
+
+// Extension fields to descriptorpb.MethodOptions.
+var (
+	// MaxInputSize is the maximum size of each RPC input message accepted by
+	// the method, in bytes.
+	//
+	// optional int64 max_input_size = 51100;
+	E_MaxInputSize = &file_github_com_dogmatiq_protean_options_options_proto_extTypes[0]
+	// MaxOutputSize is the maximum size of each RPC output message produced by
+	// the method, in bytes, when encoded in the Protocol Buffers binary format.
+	//
+	// optional int64 max_output_size = 51101;
+	E_MaxOutputSize = &file_github_com_dogmatiq_protean_options_options_proto_extTypes[1]
+	// RequiredScopes is the set of scopes that the caller must be granted in
+	// order to call the method. The option may be repeated to require multiple
+	// scopes.
+	//
+	// repeated string required_scopes = 51102;
+	E_RequiredScopes = &file_github_com_dogmatiq_protean_options_options_proto_extTypes[2]
+)
+
+// Extension fields to descriptorpb.ServiceOptions.
+var (
+	// ServiceMaxInputSize is the default value of the max_input_size option for
+	// each of the service's methods.
+	//
+	// optional int64 service_max_input_size = 51100;
+	E_ServiceMaxInputSize = &file_github_com_dogmatiq_protean_options_options_proto_extTypes[3]
+	// ServiceMaxOutputSize is the default value of the max_output_size option
+	// for each of the service's methods.
+	//
+	// optional int64 service_max_output_size = 51101;
+	E_ServiceMaxOutputSize = &file_github_com_dogmatiq_protean_options_options_proto_extTypes[4]
+	// ServiceRequiredScopes is the default value of the required_scopes option
+	// for each of the service's methods. It does not apply to methods that
+	// declare their own required scopes.
+	//
+	// repeated string service_required_scopes = 51102;
+	E_ServiceRequiredScopes = &file_github_com_dogmatiq_protean_options_options_proto_extTypes[5]
+)
+
+var File_github_com_dogmatiq_protean_options_options_proto protoreflect.FileDescriptor
+
+const file_github_com_dogmatiq_protean_options_options_proto_rawDesc = "" +
+	"\n" +
+	"1github.com/dogmatiq/protean/options/options.proto\x12\aprotean\x1a google/protobuf/descriptor.proto:F\n" +
+	"\x0emax_input_size\x12\x1e.google.protobuf.MethodOptions\x18\x9c\x8f\x03 \x01(\x03R\fmaxInputSize:H\n" +
+	"\x0fmax_output_size\x12\x1e.google.protobuf.MethodOptions\x18\x9d\x8f\x03 \x01(\x03R\rmaxOutputSize:I\n" +
+	"\x0frequired_scopes\x12\x1e.google.protobuf.MethodOptions\x18\x9e\x8f\x03 \x03(\tR\x0erequiredScopes:V\n" +
+	"\x16service_max_input_size\x12\x1f.google.protobuf.ServiceOptions\x18\x9c\x8f\x03 \x01(\x03R\x13serviceMaxInputSize:X\n" +
+	"\x17service_max_output_size\x12\x1f.google.protobuf.ServiceOptions\x18\x9d\x8f\x03 \x01(\x03R\x14serviceMaxOutputSize:Y\n" +
+	"\x17service_required_scopes\x12\x1f.google.protobuf.ServiceOptions\x18\x9e\x8f\x03 \x03(\tR\x15serviceRequiredScopesB%Z#github.com/dogmatiq/protean/optionsb\x06proto3"
+
+var file_github_com_dogmatiq_protean_options_options_proto_goTypes = []any{
+	(*descriptorpb.MethodOptions)(nil),  // 0: google.protobuf.MethodOptions
+	(*descriptorpb.ServiceOptions)(nil), // 1: google.protobuf.ServiceOptions
+}
+var file_github_com_dogmatiq_protean_options_options_proto_depIdxs = []int32{
+	0, // 0: protean.max_input_size:extendee -> google.protobuf.MethodOptions
+	0, // 1: protean.max_output_size:extendee -> google.protobuf.MethodOptions
+	0, // 2: protean.required_scopes:extendee -> google.protobuf.MethodOptions
+	1, // 3: protean.service_max_input_size:extendee -> google.protobuf.ServiceOptions
+	1, // 4: protean.service_max_output_size:extendee -> google.protobuf.ServiceOptions
+	1, // 5: protean.service_required_scopes:extendee -> google.protobuf.ServiceOptions
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	0, // [0:6] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_github_com_dogmatiq_protean_options_options_proto_init() }
+func file_github_com_dogmatiq_protean_options_options_proto_init() {
+	if File_github_com_dogmatiq_protean_options_options_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_github_com_dogmatiq_protean_options_options_proto_rawDesc), len(file_github_com_dogmatiq_protean_options_options_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 6,
+			NumServices:   0,
+		},
+		GoTypes:           file_github_com_dogmatiq_protean_options_options_proto_goTypes,
+		DependencyIndexes: file_github_com_dogmatiq_protean_options_options_proto_depIdxs,
+		ExtensionInfos:    file_github_com_dogmatiq_protean_options_options_proto_extTypes,
+	}.Build()
+	File_github_com_dogmatiq_protean_options_options_proto = out.File
+	file_github_com_dogmatiq_protean_options_options_proto_goTypes = nil
+	file_github_com_dogmatiq_protean_options_options_proto_depIdxs = nil
+}
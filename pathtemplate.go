@@ -0,0 +1,262 @@
+package protean
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// pathTemplate is a parsed URL path template, as used by "google.api.http"
+// rules.
+//
+// The template syntax is:
+//
+//	Template = "/" Segments [ Verb ] ;
+//	Segments = Segment { "/" Segment } ;
+//	Segment  = "*" | "**" | LITERAL | Variable ;
+//	Variable = "{" FieldPath [ "=" Segments ] "}" ;
+//	FieldPath = IDENT { "." IDENT } ;
+//	Verb     = ":" LITERAL ;
+//
+// See https://github.com/googleapis/googleapis/blob/master/google/api/http.proto.
+type pathTemplate struct {
+	segments  []templateSegment
+	variables []templateVariable
+	verb      string
+}
+
+// templateSegment is a single segment of a pathTemplate.
+type templateSegment struct {
+	// literal is the text that the segment must match exactly. It is empty if
+	// the segment is a wildcard.
+	literal string
+
+	// multi is true if the segment is a "**" wildcard, which matches zero or
+	// more segments.
+	multi bool
+}
+
+// templateVariable is a variable within a pathTemplate that binds the text
+// matched by one or more segments to a field of the RPC input message.
+type templateVariable struct {
+	// fieldPath is the path to the field that the variable binds to.
+	fieldPath []string
+
+	// begin and end are the indices of the first segment, and one past the
+	// last segment matched by the variable.
+	begin, end int
+}
+
+// templateVariableValue is the value of a templateVariable within a specific
+// URL path.
+type templateVariableValue struct {
+	fieldPath []string
+	value     string
+}
+
+// parsePathTemplate parses a URL path template.
+func parsePathTemplate(t string) (pathTemplate, error) {
+	p := &templateParser{input: t}
+
+	if !p.consume('/') {
+		return pathTemplate{}, errors.New("the path template must begin with a slash")
+	}
+
+	if err := p.parseSegments(false); err != nil {
+		return pathTemplate{}, err
+	}
+
+	if p.consume(':') {
+		p.tmpl.verb = p.parseLiteral()
+		if p.tmpl.verb == "" {
+			return pathTemplate{}, errors.New("the path template has an empty verb")
+		}
+	}
+
+	if p.pos != len(p.input) {
+		return pathTemplate{}, fmt.Errorf("unexpected '%c' at position %d of the path template", p.input[p.pos], p.pos)
+	}
+
+	for i, seg := range p.tmpl.segments {
+		if seg.multi && i != len(p.tmpl.segments)-1 {
+			return pathTemplate{}, errors.New("the '**' wildcard must be the last segment of the path template")
+		}
+	}
+
+	return p.tmpl, nil
+}
+
+// templateParser is a recursive-descent parser for path templates.
+type templateParser struct {
+	input string
+	pos   int
+	tmpl  pathTemplate
+}
+
+// consume advances past the next character if it is c.
+func (p *templateParser) consume(c byte) bool {
+	if p.pos < len(p.input) && p.input[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// parseSegments parses one or more slash-separated segments.
+func (p *templateParser) parseSegments(inVariable bool) error {
+	for {
+		if err := p.parseSegment(inVariable); err != nil {
+			return err
+		}
+
+		if !p.consume('/') {
+			return nil
+		}
+	}
+}
+
+// parseSegment parses a single segment, which may be a variable.
+func (p *templateParser) parseSegment(inVariable bool) error {
+	switch {
+	case strings.HasPrefix(p.input[p.pos:], "**"):
+		p.pos += 2
+		p.tmpl.segments = append(p.tmpl.segments, templateSegment{multi: true})
+		return nil
+
+	case p.consume('*'):
+		p.tmpl.segments = append(p.tmpl.segments, templateSegment{})
+		return nil
+
+	case p.consume('{'):
+		if inVariable {
+			return errors.New("path template variables can not be nested")
+		}
+		return p.parseVariable()
+	}
+
+	lit := p.parseLiteral()
+	if lit == "" {
+		return fmt.Errorf("expected a path segment at position %d of the path template", p.pos)
+	}
+
+	p.tmpl.segments = append(p.tmpl.segments, templateSegment{literal: lit})
+
+	return nil
+}
+
+// parseVariable parses a variable, after its opening brace.
+func (p *templateParser) parseVariable() error {
+	begin := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] != '=' && p.input[p.pos] != '}' {
+		p.pos++
+	}
+
+	v := templateVariable{
+		fieldPath: strings.Split(p.input[begin:p.pos], "."),
+		begin:     len(p.tmpl.segments),
+	}
+
+	for _, n := range v.fieldPath {
+		if n == "" {
+			return errors.New("path template variables must refer to a field")
+		}
+	}
+
+	if p.consume('=') {
+		if err := p.parseSegments(true); err != nil {
+			return err
+		}
+	} else {
+		p.tmpl.segments = append(p.tmpl.segments, templateSegment{})
+	}
+
+	if !p.consume('}') {
+		return errors.New("path template variable is missing its closing brace")
+	}
+
+	v.end = len(p.tmpl.segments)
+	p.tmpl.variables = append(p.tmpl.variables, v)
+
+	return nil
+}
+
+// parseLiteral parses the literal text of a segment or verb.
+func (p *templateParser) parseLiteral() string {
+	begin := p.pos
+	for p.pos < len(p.input) && !strings.ContainsRune("/{}=*:", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	return p.input[begin:p.pos]
+}
+
+// match matches the escaped URL path p against the template.
+//
+// If it matches, it returns the values of the template's variables.
+func (t pathTemplate) match(p string) ([]templateVariableValue, bool) {
+	if t.verb != "" {
+		var ok bool
+		p, ok = strings.CutSuffix(p, ":"+t.verb)
+		if !ok {
+			return nil, false
+		}
+	}
+
+	p, ok := strings.CutPrefix(p, "/")
+	if !ok {
+		return nil, false
+	}
+
+	var parts []string
+	if p != "" {
+		parts = strings.Split(p, "/")
+	}
+
+	for i := range parts {
+		part, err := url.PathUnescape(parts[i])
+		if err != nil {
+			return nil, false
+		}
+		parts[i] = part
+	}
+
+	// ends holds the index one past the last part matched by each segment.
+	ends := make([]int, len(t.segments))
+	n := 0
+
+	for i, seg := range t.segments {
+		switch {
+		case seg.multi:
+			n = len(parts)
+		case n >= len(parts):
+			return nil, false
+		case seg.literal != "" && seg.literal != parts[n]:
+			return nil, false
+		case parts[n] == "":
+			return nil, false
+		default:
+			n++
+		}
+
+		ends[i] = n
+	}
+
+	if n != len(parts) {
+		return nil, false
+	}
+
+	values := make([]templateVariableValue, len(t.variables))
+	for i, v := range t.variables {
+		begin := 0
+		if v.begin > 0 {
+			begin = ends[v.begin-1]
+		}
+
+		values[i] = templateVariableValue{
+			fieldPath: v.fieldPath,
+			value:     strings.Join(parts[begin:ends[v.end-1]], "/"),
+		}
+	}
+
+	return values, true
+}
@@ -0,0 +1,241 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.32.0
+// source: github.com/dogmatiq/protean/rpcerror/details.proto
+
+package rpcerror
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// InputSizeLimitExceeded is the details value of the error returned when an
+// RPC input message is larger than the method's maximum input size.
+//
+// The error has the InvalidInput code.
+type InputSizeLimitExceeded struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Limit is the maximum allowable size of the input message, in bytes.
+	Limit         int64 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InputSizeLimitExceeded) Reset() {
+	*x = InputSizeLimitExceeded{}
+	mi := &file_github_com_dogmatiq_protean_rpcerror_details_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InputSizeLimitExceeded) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InputSizeLimitExceeded) ProtoMessage() {}
+
+func (x *InputSizeLimitExceeded) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_dogmatiq_protean_rpcerror_details_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InputSizeLimitExceeded.ProtoReflect.Descriptor instead.
+func (*InputSizeLimitExceeded) Descriptor() ([]byte, []int) {
+	return file_github_com_dogmatiq_protean_rpcerror_details_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *InputSizeLimitExceeded) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// OutputSizeLimitExceeded is the details value of the error returned when an
+// RPC method produces an output message that is larger than the method's
+// maximum output size.
+//
+// The error has the Unknown code.
+type OutputSizeLimitExceeded struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Limit is the maximum allowable size of the output message, in bytes, when
+	// encoded in the Protocol Buffers binary format.
+	Limit         int64 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OutputSizeLimitExceeded) Reset() {
+	*x = OutputSizeLimitExceeded{}
+	mi := &file_github_com_dogmatiq_protean_rpcerror_details_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OutputSizeLimitExceeded) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutputSizeLimitExceeded) ProtoMessage() {}
+
+func (x *OutputSizeLimitExceeded) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_dogmatiq_protean_rpcerror_details_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutputSizeLimitExceeded.ProtoReflect.Descriptor instead.
+func (*OutputSizeLimitExceeded) Descriptor() ([]byte, []int) {
+	return file_github_com_dogmatiq_protean_rpcerror_details_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *OutputSizeLimitExceeded) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// RetryInfo is a details value that indicates how long the client should wait
+// before retrying a failed call.
+//
+// It is used by errors with the ResourceExhausted code that are returned when
+// a call is rejected by a rate limiter. When sending such an error in an HTTP
+// response the handler also sets the Retry-After header.
+type RetryInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// RetryDelay is the minimum amount of time that the client should wait
+	// before retrying the call.
+	RetryDelay    *durationpb.Duration `protobuf:"bytes,1,opt,name=retry_delay,json=retryDelay,proto3" json:"retry_delay,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetryInfo) Reset() {
+	*x = RetryInfo{}
+	mi := &file_github_com_dogmatiq_protean_rpcerror_details_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetryInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetryInfo) ProtoMessage() {}
+
+func (x *RetryInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_dogmatiq_protean_rpcerror_details_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetryInfo.ProtoReflect.Descriptor instead.
+func (*RetryInfo) Descriptor() ([]byte, []int) {
+	return file_github_com_dogmatiq_protean_rpcerror_details_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RetryInfo) GetRetryDelay() *durationpb.Duration {
+	if x != nil {
+		return x.RetryDelay
+	}
+	return nil
+}
+
+var File_github_com_dogmatiq_protean_rpcerror_details_proto protoreflect.FileDescriptor
+
+const file_github_com_dogmatiq_protean_rpcerror_details_proto_rawDesc = "" +
+	"\n" +
+	"2github.com/dogmatiq/protean/rpcerror/details.proto\x12\n" +
+	"protean.v1\x1a\x1egoogle/protobuf/duration.proto\".\n" +
+	"\x16InputSizeLimitExceeded\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x03R\x05limit\"/\n" +
+	"\x17OutputSizeLimitExceeded\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x03R\x05limit\"G\n" +
+	"\tRetryInfo\x12:\n" +
+	"\vretry_delay\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\n" +
+	"retryDelayB&Z$github.com/dogmatiq/protean/rpcerrorb\x06proto3"
+
+var (
+	file_github_com_dogmatiq_protean_rpcerror_details_proto_rawDescOnce sync.Once
+	file_github_com_dogmatiq_protean_rpcerror_details_proto_rawDescData []byte
+)
+
+func file_github_com_dogmatiq_protean_rpcerror_details_proto_rawDescGZIP() []byte {
+	file_github_com_dogmatiq_protean_rpcerror_details_proto_rawDescOnce.Do(func() {
+		file_github_com_dogmatiq_protean_rpcerror_details_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_github_com_dogmatiq_protean_rpcerror_details_proto_rawDesc), len(file_github_com_dogmatiq_protean_rpcerror_details_proto_rawDesc)))
+	})
+	return file_github_com_dogmatiq_protean_rpcerror_details_proto_rawDescData
+}
+
+var file_github_com_dogmatiq_protean_rpcerror_details_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_github_com_dogmatiq_protean_rpcerror_details_proto_goTypes = []any{
+	(*InputSizeLimitExceeded)(nil),  // 0: protean.v1.InputSizeLimitExceeded
+	(*OutputSizeLimitExceeded)(nil), // 1: protean.v1.OutputSizeLimitExceeded
+	(*RetryInfo)(nil),               // 2: protean.v1.RetryInfo
+	(*durationpb.Duration)(nil),     // 3: google.protobuf.Duration
+}
+var file_github_com_dogmatiq_protean_rpcerror_details_proto_depIdxs = []int32{
+	3, // 0: protean.v1.RetryInfo.retry_delay:type_name -> google.protobuf.Duration
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_github_com_dogmatiq_protean_rpcerror_details_proto_init() }
+func file_github_com_dogmatiq_protean_rpcerror_details_proto_init() {
+	if File_github_com_dogmatiq_protean_rpcerror_details_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_github_com_dogmatiq_protean_rpcerror_details_proto_rawDesc), len(file_github_com_dogmatiq_protean_rpcerror_details_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_github_com_dogmatiq_protean_rpcerror_details_proto_goTypes,
+		DependencyIndexes: file_github_com_dogmatiq_protean_rpcerror_details_proto_depIdxs,
+		MessageInfos:      file_github_com_dogmatiq_protean_rpcerror_details_proto_msgTypes,
+	}.Build()
+	File_github_com_dogmatiq_protean_rpcerror_details_proto = out.File
+	file_github_com_dogmatiq_protean_rpcerror_details_proto_goTypes = nil
+	file_github_com_dogmatiq_protean_rpcerror_details_proto_depIdxs = nil
+}
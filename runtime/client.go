@@ -12,18 +12,47 @@ import (
 	"path"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/dogmatiq/protean/compression"
 	"github.com/dogmatiq/protean/internal/proteanpb"
 	"github.com/dogmatiq/protean/internal/protomime"
+	"github.com/dogmatiq/protean/middleware"
 	"github.com/dogmatiq/protean/rpcerror"
 	"google.golang.org/protobuf/proto"
 )
 
+// DefaultMaxOutputSize is the default maximum size of a decompressed HTTP
+// response body, in bytes.
+const DefaultMaxOutputSize = 4_000_000
+
 // ClientOptions contains options for a client.
 type ClientOptions struct {
 	HTTPClient      *http.Client
 	InputMediaType  string
 	OutputMediaType string
+
+	// InputCompression is the algorithm used to compress request bodies. If it
+	// is nil, request bodies are not compressed.
+	InputCompression compression.Algorithm
+
+	// InputCompressionThreshold is the minimum size of a request body, in
+	// bytes, before it is compressed.
+	InputCompressionThreshold int
+
+	// OutputCompression is the set of algorithms that the server may use to
+	// compress response bodies, in order of preference. If it is nil, gzip and
+	// deflate are accepted. If it is empty, compressed responses are not
+	// accepted.
+	OutputCompression []compression.Algorithm
+
+	// MaxOutputSize is the maximum size of a decompressed response body, in
+	// bytes. If it is zero, DefaultMaxOutputSize is used.
+	MaxOutputSize int
+
+	// Interceptor is a hook that intercepts calls to RPC methods and their
+	// input/output values. If it is nil, calls are not intercepted.
+	Interceptor middleware.ClientInterceptor
 }
 
 // Client implements the common logic for generated clients.
@@ -50,6 +79,17 @@ func NewClient(
 		opts.OutputMediaType = protomime.MediaTypes[0]
 	}
 
+	if opts.OutputCompression == nil {
+		opts.OutputCompression = []compression.Algorithm{
+			compression.Gzip,
+			compression.Deflate,
+		}
+	}
+
+	if opts.MaxOutputSize == 0 {
+		opts.MaxOutputSize = DefaultMaxOutputSize
+	}
+
 	return &Client{
 		baseURL: baseURL,
 		opts:    opts,
@@ -57,6 +97,10 @@ func NewClient(
 }
 
 // CallUnary invokes a unary RPC method.
+//
+// methodPath is the path of the method relative to the client's base URL, in
+// the form "/<package>/<service>/<method>". On success, out is populated with
+// the RPC output message.
 func (c *Client) CallUnary(
 	ctx context.Context,
 	methodPath string,
@@ -66,11 +110,62 @@ func (c *Client) CallUnary(
 	opts := c.opts
 	c.m.Unlock()
 
+	if opts.Interceptor == nil {
+		return c.callUnary(ctx, opts, methodPath, in, out)
+	}
+
+	result, err := opts.Interceptor.InterceptUnaryRPC(
+		ctx,
+		unaryClientInfo(methodPath),
+		in,
+		func(ctx context.Context) (proto.Message, error) {
+			if err := c.callUnary(ctx, opts, methodPath, in, out); err != nil {
+				return nil, err
+			}
+			return out, nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result == out {
+		return nil
+	}
+
+	if result == nil || result.ProtoReflect().Descriptor() != out.ProtoReflect().Descriptor() {
+		return errors.New("interceptor produced an RPC output message of the wrong type")
+	}
+
+	proto.Reset(out)
+	proto.Merge(out, result)
+
+	return nil
+}
+
+// callUnary invokes a unary RPC method by sending an HTTP request to the
+// server.
+func (c *Client) callUnary(
+	ctx context.Context,
+	opts ClientOptions,
+	methodPath string,
+	in, out proto.Message,
+) error {
 	data, err := c.marshal(opts.InputMediaType, in)
 	if err != nil {
 		return fmt.Errorf("unable to marshal RPC input message: %w", err)
 	}
 
+	contentEncoding := ""
+	if opts.InputCompression != nil && len(data) >= opts.InputCompressionThreshold {
+		data, err = compression.Compress(opts.InputCompression, data)
+		if err != nil {
+			return fmt.Errorf("unable to compress RPC input message: %w", err)
+		}
+
+		contentEncoding = opts.InputCompression.Name()
+	}
+
 	methodURL := *c.baseURL // clone
 	methodURL.Path = path.Join(methodURL.Path, methodPath)
 
@@ -89,7 +184,24 @@ func (c *Client) CallUnary(
 	req.Header.Set("Content-Type", protomime.FormatMediaType(opts.InputMediaType, in))
 	req.Header.Set("Accept", acceptHeader(opts.OutputMediaType))
 
-	res, err := c.opts.HTTPClient.Do(req)
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+
+	// Propagate the deadline to the server so that it can stop working on the
+	// call once the client is no longer waiting for the result.
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Header.Set(TimeoutHeader, FormatTimeout(time.Until(deadline)))
+	}
+
+	// Setting the Accept-Encoding header explicitly disables the transparent
+	// decompression performed by the HTTP client, so the response body is
+	// decompressed below.
+	req.Header.Set("Accept-Encoding", acceptEncodingHeader(opts.OutputCompression))
+
+	applyOutgoingHeader(ctx, req)
+
+	res, err := opts.HTTPClient.Do(req)
 	if err != nil {
 		return unwrapContextError(
 			err,
@@ -106,6 +218,15 @@ func (c *Client) CallUnary(
 		)
 	}
 
+	receiveResponseHeader(ctx, res)
+
+	if enc := res.Header.Get("Content-Encoding"); enc != "" && enc != "identity" {
+		data, err = decompress(opts.OutputCompression, enc, data, opts.MaxOutputSize)
+		if err != nil {
+			return fmt.Errorf("unable to decompress HTTP response body: %w", err)
+		}
+	}
+
 	contentType := res.Header.Get("Content-Type")
 	if contentType == "" {
 		return fmt.Errorf("unable to unmarshal RPC output message: response has no Content-Type header")
@@ -140,6 +261,21 @@ func (c *Client) CallUnary(
 	return rpcErr
 }
 
+// unaryClientInfo returns the middleware.UnaryClientInfo for the method at
+// the given path.
+func unaryClientInfo(methodPath string) middleware.UnaryClientInfo {
+	parts := strings.SplitN(strings.TrimPrefix(methodPath, "/"), "/", 3)
+
+	var info middleware.UnaryClientInfo
+	if len(parts) == 3 {
+		info.Package = parts[0]
+		info.Service = parts[1]
+		info.Method = parts[2]
+	}
+
+	return info
+}
+
 // marshal unmarshals a Protocol Buffers message based on the given media type.
 func (c *Client) marshal(mediaType string, in proto.Message) ([]byte, error) {
 	m, ok := protomime.MarshalerForMediaType(mediaType)
@@ -184,6 +320,45 @@ func acceptHeader(preferredMediaType string) string {
 	return header.String()
 }
 
+// acceptEncodingHeader builds an HTTP Accept-Encoding header value that allows
+// the given compression algorithms, in order of preference.
+func acceptEncodingHeader(algorithms []compression.Algorithm) string {
+	if len(algorithms) == 0 {
+		return "identity"
+	}
+
+	var names []string
+	for _, a := range algorithms {
+		names = append(names, a.Name())
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// decompress decompresses data that was compressed using the named
+// content-coding, which must be one of the given algorithms.
+//
+// It returns an error if the decompressed data exceeds limit bytes.
+func decompress(
+	algorithms []compression.Algorithm,
+	contentEncoding string,
+	data []byte,
+	limit int,
+) ([]byte, error) {
+	for _, a := range algorithms {
+		if strings.EqualFold(a.Name(), contentEncoding) {
+			data, err := compression.Decompress(a, data, limit)
+			if errors.Is(err, compression.ErrTooLarge) {
+				return nil, fmt.Errorf("decompressed body exceeds the maximum output size of %d bytes", limit)
+			}
+
+			return data, err
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported content-coding (%s)", contentEncoding)
+}
+
 // unwrapContextError checks if err wraps one of the context errors, and if so
 // returns the context error instead. Otherwise, it returns elseErr.
 func unwrapContextError(err, elseErr error) error {
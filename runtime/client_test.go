@@ -5,14 +5,18 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/dogmatiq/protean"
+	"github.com/dogmatiq/protean/compression"
 	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/middleware"
 	"github.com/dogmatiq/protean/rpcerror"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/format"
+	"google.golang.org/protobuf/proto"
 )
 
 var _ = Describe("type Client", func() {
@@ -99,6 +103,255 @@ var _ = Describe("type Client", func() {
 				})
 			})
 
+			When("compression is enabled", func() {
+				It("compresses the RPC input message and decompresses the RPC output message", func() {
+					large := strings.Repeat("<data>", 1000)
+
+					service.UnaryFunc = func(
+						_ context.Context,
+						in *testservice.Input,
+					) (*testservice.Output, error) {
+						return &testservice.Output{
+							Data: in.GetData(),
+						}, nil
+					}
+
+					var (
+						contentEncoding  string
+						responseEncoding string
+					)
+
+					server.Config.Handler = http.HandlerFunc(
+						func(w http.ResponseWriter, r *http.Request) {
+							contentEncoding = r.Header.Get("Content-Encoding")
+							handler.ServeHTTP(w, r)
+							responseEncoding = w.Header().Get("Content-Encoding")
+						},
+					)
+
+					baseURL, err := url.Parse(server.URL)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					client = testservice.NewProteanTestServiceClient(
+						baseURL,
+						protean.WithInputCompression(compression.Deflate, compression.DefaultThreshold),
+						protean.WithOutputCompression(compression.Gzip),
+					)
+
+					out, err := client.Unary(ctx, &testservice.Input{Data: large})
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(out.GetData()).To(Equal(large))
+					Expect(contentEncoding).To(Equal("deflate"))
+					Expect(responseEncoding).To(Equal("gzip"))
+				})
+			})
+
+			When("the context has a deadline", func() {
+				It("propagates the deadline to the server", func() {
+					service.UnaryFunc = func(
+						ctx context.Context,
+						_ *testservice.Input,
+					) (*testservice.Output, error) {
+						defer GinkgoRecover()
+
+						deadline, ok := ctx.Deadline()
+						Expect(ok).To(BeTrue())
+						Expect(time.Until(deadline)).To(BeNumerically("~", 3*time.Second, 500*time.Millisecond))
+
+						return output, nil
+					}
+
+					var timeout string
+
+					server.Config.Handler = http.HandlerFunc(
+						func(w http.ResponseWriter, r *http.Request) {
+							timeout = r.Header.Get("Protean-Timeout")
+							handler.ServeHTTP(w, r)
+						},
+					)
+
+					_, err := client.Unary(ctx, input)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(timeout).To(MatchRegexp(`^\d+$`))
+				})
+
+				It("returns an rpcerror.Error if the server can not complete the call in time", func() {
+					service.UnaryFunc = func(
+						ctx context.Context,
+						_ *testservice.Input,
+					) (*testservice.Output, error) {
+						<-ctx.Done()
+						return nil, ctx.Err()
+					}
+
+					handler = protean.NewHandler(protean.WithMaxTimeout(10 * time.Millisecond))
+					testservice.RegisterProteanTestService(handler, service)
+					server.Config.Handler = handler
+
+					_, err := client.Unary(ctx, input)
+					Expect(err).To(Equal(
+						rpcerror.New(
+							rpcerror.DeadlineExceeded,
+							"the RPC call did not complete before its deadline",
+						),
+					))
+				})
+			})
+
+			When("the context carries metadata", func() {
+				It("sends request headers and captures response metadata", func() {
+					service.UnaryFunc = func(
+						ctx context.Context,
+						_ *testservice.Input,
+					) (*testservice.Output, error) {
+						defer GinkgoRecover()
+
+						md, ok := protean.RequestMetadata(ctx)
+						Expect(ok).To(BeTrue())
+						Expect(md.Header.Values("X-Custom")).To(Equal([]string{"<one>", "<two>"}))
+						Expect(md.Header.Get("Content-Type")).To(HavePrefix("application/vnd.google.protobuf"))
+
+						Expect(protean.SetResponseHeader(ctx, "X-Response", "<value>")).To(Succeed())
+
+						return output, nil
+					}
+
+					ctx := protean.WithRequestHeader(ctx, "X-Custom", "<one>")
+					ctx = protean.WithRequestHeader(ctx, "X-Custom", "<two>")
+					ctx = protean.WithRequestHeader(ctx, "Content-Type", "text/plain")
+
+					var md protean.ResponseMetadata
+					ctx = protean.CaptureResponseMetadata(ctx, &md)
+
+					_, err := client.Unary(ctx, input)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(md.Header.Get("X-Response")).To(Equal("<value>"))
+				})
+			})
+
+			When("interceptors are installed", func() {
+				var baseURL *url.URL
+
+				BeforeEach(func() {
+					var err error
+					baseURL, err = url.Parse(server.URL)
+					Expect(err).ShouldNot(HaveOccurred())
+				})
+
+				It("calls each interceptor in the order they are installed", func() {
+					var order []string
+
+					// interceptor returns an interceptor that records the
+					// order in which it is called.
+					interceptor := func(name string) middleware.ClientInterceptor {
+						return clientInterceptorFunc(func(
+							ctx context.Context,
+							info middleware.UnaryClientInfo,
+							in proto.Message,
+							next func(context.Context) (proto.Message, error),
+						) (proto.Message, error) {
+							Expect(info).To(Equal(middleware.UnaryClientInfo{
+								Package: "protean.test",
+								Service: "TestService",
+								Method:  "Unary",
+							}))
+
+							order = append(order, name+" before")
+							out, err := next(ctx)
+							order = append(order, name+" after")
+
+							return out, err
+						})
+					}
+
+					client = testservice.NewProteanTestServiceClient(
+						baseURL,
+						protean.WithInterceptor(interceptor("<one>")),
+						protean.WithInterceptor(interceptor("<two>")),
+					)
+
+					out, err := client.Unary(ctx, input)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(out.GetData()).To(Equal("<output>"))
+					Expect(order).To(Equal([]string{
+						"<one> before",
+						"<two> before",
+						"<two> after",
+						"<one> after",
+					}))
+				})
+
+				It("allows the interceptor to modify the input and output messages", func() {
+					client = testservice.NewProteanTestServiceClient(
+						baseURL,
+						protean.WithInterceptor(clientInterceptorFunc(func(
+							ctx context.Context,
+							_ middleware.UnaryClientInfo,
+							in proto.Message,
+							next func(context.Context) (proto.Message, error),
+						) (proto.Message, error) {
+							in.(*testservice.Input).Data = "<input>"
+
+							out, err := next(ctx)
+							Expect(err).ShouldNot(HaveOccurred())
+							Expect(out.(*testservice.Output).GetData()).To(Equal("<output>"))
+
+							return &testservice.Output{Data: "<replaced>"}, nil
+						})),
+					)
+
+					out, err := client.Unary(ctx, &testservice.Input{Data: "<original>"})
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(out.GetData()).To(Equal("<replaced>"))
+				})
+
+				It("passes errors returned by the server to the interceptor", func() {
+					expect := rpcerror.New(rpcerror.NotFound, "<error>")
+
+					service.UnaryFunc = func(
+						context.Context,
+						*testservice.Input,
+					) (*testservice.Output, error) {
+						return nil, expect
+					}
+
+					client = testservice.NewProteanTestServiceClient(
+						baseURL,
+						protean.WithInterceptor(clientInterceptorFunc(func(
+							ctx context.Context,
+							_ middleware.UnaryClientInfo,
+							_ proto.Message,
+							next func(context.Context) (proto.Message, error),
+						) (proto.Message, error) {
+							_, err := next(ctx)
+							Expect(err).To(Equal(expect))
+
+							return nil, rpcerror.New(rpcerror.Unavailable, "<replaced>")
+						})),
+					)
+
+					_, err := client.Unary(ctx, input)
+					Expect(err).To(Equal(rpcerror.New(rpcerror.Unavailable, "<replaced>")))
+				})
+
+				It("returns an error if the interceptor produces an output message of the wrong type", func() {
+					client = testservice.NewProteanTestServiceClient(
+						baseURL,
+						protean.WithInterceptor(clientInterceptorFunc(func(
+							context.Context,
+							middleware.UnaryClientInfo,
+							proto.Message,
+							func(context.Context) (proto.Message, error),
+						) (proto.Message, error) {
+							return &testservice.Input{}, nil
+						})),
+					)
+
+					_, err := client.Unary(ctx, input)
+					Expect(err).To(MatchError("interceptor produced an RPC output message of the wrong type"))
+				})
+			})
+
 			When("the RPC input message can not be marshaled", func() {
 				BeforeEach(func() {
 					input.Data = "\xc3\x28" // invalid UTF-8
@@ -176,6 +429,33 @@ var _ = Describe("type Client", func() {
 				})
 			})
 
+			When("the decompressed HTTP response body exceeds the maximum output size", func() {
+				BeforeEach(func() {
+					server.Config.Handler = http.HandlerFunc(
+						func(w http.ResponseWriter, r *http.Request) {
+							data, _ := compression.Compress(compression.Gzip, make([]byte, 10_000))
+
+							w.Header().Set("Content-Type", "application/json")
+							w.Header().Set("Content-Encoding", "gzip")
+							w.Write(data)
+						},
+					)
+
+					baseURL, err := url.Parse(server.URL)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					client = testservice.NewProteanTestServiceClient(
+						baseURL,
+						protean.WithMaxOutputSize(1_000),
+					)
+				})
+
+				It("returns an error", func() {
+					_, err := client.Unary(ctx, input)
+					Expect(err).To(MatchError("unable to decompress HTTP response body: decompressed body exceeds the maximum output size of 1000 bytes"))
+				})
+			})
+
 			When("the HTTP response has no Content-Type header", func() {
 				BeforeEach(func() {
 					server.Config.Handler = http.HandlerFunc(
@@ -237,3 +517,21 @@ var _ = Describe("type Client", func() {
 		})
 	})
 })
+
+// clientInterceptorFunc is an adaptor that allows a function to be used as a
+// middleware.ClientInterceptor.
+type clientInterceptorFunc func(
+	ctx context.Context,
+	info middleware.UnaryClientInfo,
+	in proto.Message,
+	next func(context.Context) (proto.Message, error),
+) (proto.Message, error)
+
+func (fn clientInterceptorFunc) InterceptUnaryRPC(
+	ctx context.Context,
+	info middleware.UnaryClientInfo,
+	in proto.Message,
+	next func(context.Context) (proto.Message, error),
+) (proto.Message, error) {
+	return fn(ctx, info, in, next)
+}
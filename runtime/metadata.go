@@ -0,0 +1,71 @@
+package runtime
+
+import (
+	"context"
+	"net/http"
+)
+
+type (
+	// outgoingHeaderKey is the context key used to store the HTTP headers that
+	// the client sends with each request.
+	outgoingHeaderKey struct{}
+
+	// responseHeaderReceiverKey is the context key used to store the function
+	// that is called with the headers of each response received by the
+	// client.
+	responseHeaderReceiverKey struct{}
+)
+
+// WithOutgoingHeader returns a copy of ctx that causes the client to send the
+// headers in h with each request that it makes using the returned context.
+//
+// h must not be modified after this function is called.
+func WithOutgoingHeader(ctx context.Context, h http.Header) context.Context {
+	return context.WithValue(ctx, outgoingHeaderKey{}, h)
+}
+
+// OutgoingHeader returns the headers associated with ctx by
+// WithOutgoingHeader(), or nil if there are none.
+func OutgoingHeader(ctx context.Context) http.Header {
+	h, _ := ctx.Value(outgoingHeaderKey{}).(http.Header)
+	return h
+}
+
+// WithResponseHeaderReceiver returns a copy of ctx that causes the client to
+// call fn with the headers and trailers of the response to each request that
+// it makes using the returned context.
+func WithResponseHeaderReceiver(
+	ctx context.Context,
+	fn func(header, trailer http.Header),
+) context.Context {
+	return context.WithValue(ctx, responseHeaderReceiverKey{}, fn)
+}
+
+// applyOutgoingHeader adds the headers associated with ctx to req.
+//
+// Headers that are already present in req, such as those set by the client
+// itself, are not overridden.
+func applyOutgoingHeader(ctx context.Context, req *http.Request) {
+	for k, values := range OutgoingHeader(ctx) {
+		k = http.CanonicalHeaderKey(k)
+
+		if _, ok := req.Header[k]; ok {
+			continue
+		}
+
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+}
+
+// receiveResponseHeader passes the headers and trailers of res to the
+// function associated with ctx by WithResponseHeaderReceiver(), if any.
+//
+// The response body must have been read in full before calling this function,
+// otherwise the trailers are not yet available.
+func receiveResponseHeader(ctx context.Context, res *http.Response) {
+	if fn, ok := ctx.Value(responseHeaderReceiverKey{}).(func(header, trailer http.Header)); ok {
+		fn(res.Header, res.Trailer)
+	}
+}
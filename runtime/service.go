@@ -5,6 +5,7 @@ import (
 
 	"github.com/dogmatiq/protean/middleware"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // Registry is a type that allows services to be registered.
@@ -24,6 +25,9 @@ type Service interface {
 	// defined.
 	Package() string
 
+	// Descriptor returns the Protocol Buffers descriptor for the service.
+	Descriptor() protoreflect.ServiceDescriptor
+
 	// MethodByName returns the method with the given name.
 	//
 	// If no such method exists, ok is false.
@@ -43,6 +47,42 @@ type Method interface {
 	// messages, as opposed to a single output message.
 	OutputIsStream() bool
 
+	// HasSideEffects returns true if calling the method may have side effects.
+	//
+	// It returns false only if the method's definition explicitly declares that
+	// it has no side effects, by setting the "idempotency_level" option to
+	// NO_SIDE_EFFECTS.
+	HasSideEffects() bool
+
+	// Descriptor returns the Protocol Buffers descriptor for the method.
+	//
+	// It provides access to the method's options, such as "google.api.http"
+	// annotations, and to the descriptors of its input and output messages.
+	Descriptor() protoreflect.MethodDescriptor
+
+	// MaxInputSize returns the maximum size of each RPC input message, in
+	// bytes, as declared by the "protean.max_input_size" or
+	// "protean.service_max_input_size" options.
+	//
+	// It returns zero if neither option is set, in which case the handler's
+	// default applies.
+	MaxInputSize() int
+
+	// MaxOutputSize returns the maximum size of each RPC output message, in
+	// bytes, as declared by the "protean.max_output_size" or
+	// "protean.service_max_output_size" options.
+	//
+	// It returns zero if neither option is set, in which case the handler's
+	// default applies.
+	MaxOutputSize() int
+
+	// RequiredScopes returns the scopes that the caller must be granted in
+	// order to call the method, as declared by the "protean.required_scopes"
+	// or "protean.service_required_scopes" options.
+	//
+	// It returns nil if neither option is set.
+	RequiredScopes() []string
+
 	// NewCall starts a new call to the method.
 	//
 	// ctx is the context for the lifetime of the call, including any time taken
@@ -56,6 +96,10 @@ type CallOptions struct {
 	// input/output values.
 	Interceptor middleware.ServerInterceptor
 
+	// StreamInterceptor is a hook that intercepts calls to streaming RPC
+	// methods and each of their input/output messages.
+	StreamInterceptor middleware.StreamServerInterceptor
+
 	// InputChannelCapacity is the capacity of the "inputs" channel for RPC
 	// methods that use client-streaming.
 	InputChannelCapacity int
@@ -0,0 +1,335 @@
+package runtime
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dogmatiq/protean/middleware"
+	"github.com/dogmatiq/protean/rpcerror"
+	"google.golang.org/protobuf/proto"
+)
+
+// InterceptServerStream calls a server streaming RPC method via interceptor.
+//
+// in is the RPC input message. out is the channel on which output messages are
+// delivered to the transport. It is closed once the RPC method has closed its
+// own output channel, or if the interceptor does not invoke the method.
+//
+// If interceptor is nil, the method is called directly.
+func InterceptServerStream[In, Out proto.Message](
+	ctx context.Context,
+	interceptor middleware.StreamServerInterceptor,
+	info middleware.StreamServerInfo,
+	in In,
+	out chan<- Out,
+	method func(context.Context, In, chan<- Out) error,
+) error {
+	if interceptor == nil {
+		return method(ctx, in, out)
+	}
+
+	invoked := false
+
+	err := interceptor.InterceptStreamRPC(
+		ctx,
+		info,
+		middleware.PassThroughStream{},
+		func(ctx context.Context, stream middleware.ServerStream) error {
+			invoked = true
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			h := &streamHooks{stream: stream, cancel: cancel}
+
+			in, ok := recvInput(ctx, h, in)
+			if !ok {
+				close(out)
+				return h.result(nil)
+			}
+
+			outputs, done := forwardOutputs(ctx, h, out)
+			err := method(ctx, in, outputs)
+			<-done
+
+			return h.result(err)
+		},
+	)
+
+	if !invoked {
+		close(out)
+	}
+
+	return err
+}
+
+// InterceptClientStream calls a client streaming RPC method via interceptor.
+//
+// in is the channel on which input messages are received from the transport.
+//
+// If interceptor is nil, the method is called directly.
+func InterceptClientStream[In, Out proto.Message](
+	ctx context.Context,
+	interceptor middleware.StreamServerInterceptor,
+	info middleware.StreamServerInfo,
+	in <-chan In,
+	method func(context.Context, <-chan In) (Out, error),
+) (Out, error) {
+	if interceptor == nil {
+		return method(ctx, in)
+	}
+
+	var out Out
+
+	err := interceptor.InterceptStreamRPC(
+		ctx,
+		info,
+		middleware.PassThroughStream{},
+		func(ctx context.Context, stream middleware.ServerStream) error {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			h := &streamHooks{stream: stream, cancel: cancel}
+
+			o, err := method(ctx, forwardInputs(ctx, h, in))
+			if err := h.result(err); err != nil {
+				return err
+			}
+
+			o, ok := sendOutput(ctx, h, o)
+			if !ok {
+				return h.result(nil)
+			}
+
+			out = o
+			return nil
+		},
+	)
+
+	if err != nil {
+		var zero Out
+		return zero, err
+	}
+
+	return out, nil
+}
+
+// InterceptBidirectionalStream calls a bidirectional streaming RPC method via
+// interceptor.
+//
+// in is the channel on which input messages are received from the transport.
+// out is the channel on which output messages are delivered to the transport.
+// It is closed once the RPC method has closed its own output channel, or if
+// the interceptor does not invoke the method.
+//
+// If interceptor is nil, the method is called directly.
+func InterceptBidirectionalStream[In, Out proto.Message](
+	ctx context.Context,
+	interceptor middleware.StreamServerInterceptor,
+	info middleware.StreamServerInfo,
+	in <-chan In,
+	out chan<- Out,
+	method func(context.Context, <-chan In, chan<- Out) error,
+) error {
+	if interceptor == nil {
+		return method(ctx, in, out)
+	}
+
+	invoked := false
+
+	err := interceptor.InterceptStreamRPC(
+		ctx,
+		info,
+		middleware.PassThroughStream{},
+		func(ctx context.Context, stream middleware.ServerStream) error {
+			invoked = true
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			h := &streamHooks{stream: stream, cancel: cancel}
+
+			outputs, done := forwardOutputs(ctx, h, out)
+			err := method(ctx, forwardInputs(ctx, h, in), outputs)
+			<-done
+
+			return h.result(err)
+		},
+	)
+
+	if !invoked {
+		close(out)
+	}
+
+	return err
+}
+
+// streamHooks applies the hooks of a middleware.ServerStream to the messages
+// of a single call.
+type streamHooks struct {
+	stream middleware.ServerStream
+	cancel context.CancelFunc
+
+	m   sync.Mutex
+	err error
+}
+
+// fail records err as the result of the call and cancels the RPC method's
+// context.
+//
+// Only the first error is recorded.
+func (h *streamHooks) fail(err error) {
+	h.m.Lock()
+	if h.err == nil {
+		h.err = err
+	}
+	h.m.Unlock()
+
+	h.cancel()
+}
+
+// result returns the result of the call, given the error returned by the RPC
+// method.
+//
+// An error produced by one of the hooks takes precedence, as the RPC method
+// most likely failed because its context was canceled.
+func (h *streamHooks) result(err error) error {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	if h.err != nil {
+		return h.err
+	}
+
+	return err
+}
+
+// recvInput passes an input message through the RecvInput() hook.
+//
+// It returns false if the hook fails.
+func recvInput[In proto.Message](
+	ctx context.Context,
+	h *streamHooks,
+	in In,
+) (In, bool) {
+	m, err := h.stream.RecvInput(ctx, in)
+	if err != nil {
+		h.fail(err)
+		return in, false
+	}
+
+	if in, ok := m.(In); ok {
+		return in, true
+	}
+
+	h.fail(rpcerror.New(
+		rpcerror.Unknown,
+		"an interceptor replaced the RPC input message with a message of a different type",
+	))
+
+	return in, false
+}
+
+// sendOutput passes an output message through the SendOutput() hook.
+//
+// It returns false if the hook fails.
+func sendOutput[Out proto.Message](
+	ctx context.Context,
+	h *streamHooks,
+	out Out,
+) (Out, bool) {
+	m, err := h.stream.SendOutput(ctx, out)
+	if err != nil {
+		h.fail(err)
+		return out, false
+	}
+
+	if out, ok := m.(Out); ok {
+		return out, true
+	}
+
+	h.fail(rpcerror.New(
+		rpcerror.Unknown,
+		"an interceptor replaced the RPC output message with a message of a different type",
+	))
+
+	return out, false
+}
+
+// forwardInputs starts a goroutine that passes each message received on src
+// through the RecvInput() hook before forwarding it to the returned channel.
+//
+// The returned channel is closed when src is closed, a hook fails or ctx is
+// canceled.
+func forwardInputs[In proto.Message](
+	ctx context.Context,
+	h *streamHooks,
+	src <-chan In,
+) <-chan In {
+	dst := make(chan In)
+
+	go func() {
+		defer close(dst)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case in, ok := <-src:
+				if !ok {
+					return
+				}
+
+				in, ok = recvInput(ctx, h, in)
+				if !ok {
+					return
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case dst <- in:
+				}
+			}
+		}
+	}()
+
+	return dst
+}
+
+// forwardOutputs starts a goroutine that passes each message sent on the
+// returned channel through the SendOutput() hook before forwarding it to dst.
+//
+// dst is closed when the returned channel is closed. Messages sent after a hook
+// fails or ctx is canceled are discarded. done is closed after dst is closed.
+func forwardOutputs[Out proto.Message](
+	ctx context.Context,
+	h *streamHooks,
+	dst chan<- Out,
+) (_ chan<- Out, done <-chan struct{}) {
+	src := make(chan Out, cap(dst))
+	d := make(chan struct{})
+
+	go func() {
+		defer close(d)
+		defer close(dst)
+
+		for out := range src {
+			if ctx.Err() != nil {
+				continue
+			}
+
+			out, ok := sendOutput(ctx, h, out)
+			if !ok {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+			case dst <- out:
+			}
+		}
+	}()
+
+	return src, d
+}
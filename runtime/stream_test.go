@@ -0,0 +1,465 @@
+package runtime_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/dogmatiq/protean/internal/stringservice"
+	"github.com/dogmatiq/protean/internal/testservice"
+	"github.com/dogmatiq/protean/middleware"
+	"github.com/dogmatiq/protean/rpcerror"
+	. "github.com/dogmatiq/protean/runtime"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ = Describe("func InterceptServerStream()", func() {
+	var (
+		info   middleware.StreamServerInfo
+		method func(context.Context, *testservice.Input, chan<- *testservice.Output) error
+	)
+
+	BeforeEach(func() {
+		info = middleware.StreamServerInfo{
+			Package:        "protean.test",
+			Service:        "TestService",
+			Method:         "ServerStream",
+			OutputIsStream: true,
+		}
+
+		method = func(
+			ctx context.Context,
+			in *testservice.Input,
+			outputs chan<- *testservice.Output,
+		) error {
+			defer close(outputs)
+
+			for _, r := range in.GetData() {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case outputs <- &testservice.Output{Data: string(r)}:
+				}
+			}
+
+			return nil
+		}
+	})
+
+	// call invokes the method via the given interceptor and returns the
+	// output data and error.
+	call := func(
+		interceptor middleware.StreamServerInterceptor,
+		data string,
+	) ([]string, error) {
+		out := make(chan *testservice.Output)
+		result := make(chan error, 1)
+
+		go func() {
+			result <- InterceptServerStream(
+				context.Background(),
+				interceptor,
+				info,
+				&testservice.Input{Data: data},
+				out,
+				method,
+			)
+		}()
+
+		var outputs []string
+		for o := range out {
+			outputs = append(outputs, o.GetData())
+		}
+
+		return outputs, <-result
+	}
+
+	It("calls the method directly if the interceptor is nil", func() {
+		outputs, err := call(nil, "abc")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(outputs).To(Equal([]string{"a", "b", "c"}))
+	})
+
+	It("passes the method information to the interceptor", func() {
+		_, err := call(
+			streamInterceptorFunc(func(
+				ctx context.Context,
+				i middleware.StreamServerInfo,
+				stream middleware.ServerStream,
+				next func(context.Context, middleware.ServerStream) error,
+			) error {
+				Expect(i).To(Equal(info))
+				return next(ctx, stream)
+			}),
+			"abc",
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("allows the interceptor to modify the input and output messages", func() {
+		outputs, err := call(
+			wrapStream(&streamHooksStub{
+				RecvInputFunc: func(in proto.Message) (proto.Message, error) {
+					return &testservice.Input{
+						Data: strings.ToUpper(in.(*testservice.Input).GetData()),
+					}, nil
+				},
+				SendOutputFunc: func(out proto.Message) (proto.Message, error) {
+					return &testservice.Output{
+						Data: "<" + out.(*testservice.Output).GetData() + ">",
+					}, nil
+				},
+			}),
+			"abc",
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(outputs).To(Equal([]string{"<A>", "<B>", "<C>"}))
+	})
+
+	It("fails the call if the interceptor rejects the input message", func() {
+		outputs, err := call(
+			wrapStream(&streamHooksStub{
+				RecvInputFunc: func(proto.Message) (proto.Message, error) {
+					return nil, rpcerror.New(rpcerror.InvalidInput, "<error>")
+				},
+			}),
+			"abc",
+		)
+		Expect(err).To(Equal(rpcerror.New(rpcerror.InvalidInput, "<error>")))
+		Expect(outputs).To(BeEmpty())
+	})
+
+	It("fails the call if the interceptor rejects an output message", func() {
+		outputs, err := call(
+			wrapStream(&streamHooksStub{
+				SendOutputFunc: func(out proto.Message) (proto.Message, error) {
+					if out.(*testservice.Output).GetData() == "b" {
+						return nil, rpcerror.New(rpcerror.PermissionDenied, "<error>")
+					}
+					return out, nil
+				},
+			}),
+			"abc",
+		)
+		Expect(err).To(Equal(rpcerror.New(rpcerror.PermissionDenied, "<error>")))
+		Expect(outputs).To(Equal([]string{"a"}))
+	})
+
+	It("fails the call if the interceptor replaces a message with a message of a different type", func() {
+		_, err := call(
+			wrapStream(&streamHooksStub{
+				SendOutputFunc: func(proto.Message) (proto.Message, error) {
+					return &stringservice.ToUpperResponse{}, nil
+				},
+			}),
+			"abc",
+		)
+		Expect(err).To(Equal(rpcerror.New(
+			rpcerror.Unknown,
+			"an interceptor replaced the RPC output message with a message of a different type",
+		)))
+	})
+
+	It("closes the output channel if the interceptor does not call the method", func() {
+		outputs, err := call(
+			streamInterceptorFunc(func(
+				context.Context,
+				middleware.StreamServerInfo,
+				middleware.ServerStream,
+				func(context.Context, middleware.ServerStream) error,
+			) error {
+				return errors.New("<error>")
+			}),
+			"abc",
+		)
+		Expect(err).To(MatchError("<error>"))
+		Expect(outputs).To(BeEmpty())
+	})
+})
+
+var _ = Describe("func InterceptClientStream()", func() {
+	var method func(context.Context, <-chan *testservice.Input) (*testservice.Output, error)
+
+	BeforeEach(func() {
+		method = func(
+			ctx context.Context,
+			inputs <-chan *testservice.Input,
+		) (*testservice.Output, error) {
+			var data []string
+
+			for {
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case in, ok := <-inputs:
+					if !ok {
+						return &testservice.Output{
+							Data: strings.Join(data, ","),
+						}, nil
+					}
+
+					data = append(data, in.GetData())
+				}
+			}
+		}
+	})
+
+	// call invokes the method via the given interceptor with each of the
+	// given inputs.
+	call := func(
+		interceptor middleware.StreamServerInterceptor,
+		inputs ...string,
+	) (*testservice.Output, error) {
+		in := make(chan *testservice.Input, len(inputs))
+		for _, data := range inputs {
+			in <- &testservice.Input{Data: data}
+		}
+		close(in)
+
+		return InterceptClientStream(
+			context.Background(),
+			interceptor,
+			middleware.StreamServerInfo{InputIsStream: true},
+			in,
+			method,
+		)
+	}
+
+	It("calls the method directly if the interceptor is nil", func() {
+		out, err := call(nil, "a", "b")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out.GetData()).To(Equal("a,b"))
+	})
+
+	It("allows the interceptor to modify the input and output messages", func() {
+		out, err := call(
+			wrapStream(&streamHooksStub{
+				RecvInputFunc: func(in proto.Message) (proto.Message, error) {
+					return &testservice.Input{
+						Data: strings.ToUpper(in.(*testservice.Input).GetData()),
+					}, nil
+				},
+				SendOutputFunc: func(out proto.Message) (proto.Message, error) {
+					return &testservice.Output{
+						Data: "<" + out.(*testservice.Output).GetData() + ">",
+					}, nil
+				},
+			}),
+			"a", "b",
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out.GetData()).To(Equal("<A,B>"))
+	})
+
+	It("fails the call if the interceptor rejects an input message", func() {
+		_, err := call(
+			wrapStream(&streamHooksStub{
+				RecvInputFunc: func(in proto.Message) (proto.Message, error) {
+					if in.(*testservice.Input).GetData() == "b" {
+						return nil, rpcerror.New(rpcerror.InvalidInput, "<error>")
+					}
+					return in, nil
+				},
+			}),
+			"a", "b", "c",
+		)
+		Expect(err).To(Equal(rpcerror.New(rpcerror.InvalidInput, "<error>")))
+	})
+
+	It("fails the call if the interceptor rejects the output message", func() {
+		_, err := call(
+			wrapStream(&streamHooksStub{
+				SendOutputFunc: func(proto.Message) (proto.Message, error) {
+					return nil, rpcerror.New(rpcerror.Unknown, "<error>")
+				},
+			}),
+			"a",
+		)
+		Expect(err).To(Equal(rpcerror.New(rpcerror.Unknown, "<error>")))
+	})
+})
+
+var _ = Describe("func InterceptBidirectionalStream()", func() {
+	var method func(context.Context, <-chan *testservice.Input, chan<- *testservice.Output) error
+
+	BeforeEach(func() {
+		method = func(
+			ctx context.Context,
+			inputs <-chan *testservice.Input,
+			outputs chan<- *testservice.Output,
+		) error {
+			defer close(outputs)
+
+			for {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case in, ok := <-inputs:
+					if !ok {
+						return nil
+					}
+
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case outputs <- &testservice.Output{Data: in.GetData()}:
+					}
+				}
+			}
+		}
+	})
+
+	// call invokes the method via the given interceptor with each of the
+	// given inputs and returns the output data and error.
+	call := func(
+		interceptor middleware.StreamServerInterceptor,
+		inputs ...string,
+	) ([]string, error) {
+		in := make(chan *testservice.Input, len(inputs))
+		for _, data := range inputs {
+			in <- &testservice.Input{Data: data}
+		}
+		close(in)
+
+		out := make(chan *testservice.Output)
+		result := make(chan error, 1)
+
+		go func() {
+			result <- InterceptBidirectionalStream(
+				context.Background(),
+				interceptor,
+				middleware.StreamServerInfo{InputIsStream: true, OutputIsStream: true},
+				in,
+				out,
+				method,
+			)
+		}()
+
+		var outputs []string
+		for o := range out {
+			outputs = append(outputs, o.GetData())
+		}
+
+		return outputs, <-result
+	}
+
+	It("calls the method directly if the interceptor is nil", func() {
+		outputs, err := call(nil, "a", "b")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(outputs).To(Equal([]string{"a", "b"}))
+	})
+
+	It("allows the interceptor to modify the input and output messages", func() {
+		outputs, err := call(
+			wrapStream(&streamHooksStub{
+				RecvInputFunc: func(in proto.Message) (proto.Message, error) {
+					return &testservice.Input{
+						Data: strings.ToUpper(in.(*testservice.Input).GetData()),
+					}, nil
+				},
+				SendOutputFunc: func(out proto.Message) (proto.Message, error) {
+					return &testservice.Output{
+						Data: "<" + out.(*testservice.Output).GetData() + ">",
+					}, nil
+				},
+			}),
+			"a", "b",
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(outputs).To(Equal([]string{"<A>", "<B>"}))
+	})
+
+	It("fails the call if the interceptor rejects an input message", func() {
+		_, err := call(
+			wrapStream(&streamHooksStub{
+				RecvInputFunc: func(in proto.Message) (proto.Message, error) {
+					if in.(*testservice.Input).GetData() == "b" {
+						return nil, rpcerror.New(rpcerror.InvalidInput, "<error>")
+					}
+					return in, nil
+				},
+			}),
+			"a", "b", "c",
+		)
+		Expect(err).To(Equal(rpcerror.New(rpcerror.InvalidInput, "<error>")))
+	})
+
+	It("closes the output channel if the interceptor does not call the method", func() {
+		outputs, err := call(
+			streamInterceptorFunc(func(
+				context.Context,
+				middleware.StreamServerInfo,
+				middleware.ServerStream,
+				func(context.Context, middleware.ServerStream) error,
+			) error {
+				return errors.New("<error>")
+			}),
+			"a",
+		)
+		Expect(err).To(MatchError("<error>"))
+		Expect(outputs).To(BeEmpty())
+	})
+})
+
+// streamInterceptorFunc is an adaptor that allows a function to be used as a
+// middleware.StreamServerInterceptor.
+type streamInterceptorFunc func(
+	ctx context.Context,
+	info middleware.StreamServerInfo,
+	stream middleware.ServerStream,
+	next func(context.Context, middleware.ServerStream) error,
+) error
+
+func (fn streamInterceptorFunc) InterceptStreamRPC(
+	ctx context.Context,
+	info middleware.StreamServerInfo,
+	stream middleware.ServerStream,
+	next func(context.Context, middleware.ServerStream) error,
+) error {
+	return fn(ctx, info, stream, next)
+}
+
+// wrapStream returns an interceptor that wraps each call's stream with s.
+func wrapStream(s *streamHooksStub) middleware.StreamServerInterceptor {
+	return streamInterceptorFunc(func(
+		ctx context.Context,
+		_ middleware.StreamServerInfo,
+		stream middleware.ServerStream,
+		next func(context.Context, middleware.ServerStream) error,
+	) error {
+		w := *s
+		w.ServerStream = stream
+		return next(ctx, &w)
+	})
+}
+
+// streamHooksStub is a middleware.ServerStream that wraps another stream.
+type streamHooksStub struct {
+	middleware.ServerStream
+
+	RecvInputFunc  func(proto.Message) (proto.Message, error)
+	SendOutputFunc func(proto.Message) (proto.Message, error)
+}
+
+func (s *streamHooksStub) RecvInput(ctx context.Context, in proto.Message) (proto.Message, error) {
+	in, err := s.ServerStream.RecvInput(ctx, in)
+	if err != nil || s.RecvInputFunc == nil {
+		return in, err
+	}
+
+	return s.RecvInputFunc(in)
+}
+
+func (s *streamHooksStub) SendOutput(ctx context.Context, out proto.Message) (proto.Message, error) {
+	if s.SendOutputFunc != nil {
+		var err error
+		out, err = s.SendOutputFunc(out)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.ServerStream.SendOutput(ctx, out)
+}
@@ -0,0 +1,47 @@
+package runtime
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// TimeoutHeader is the name of the HTTP request header that conveys the amount
+// of time that the client is willing to wait for an RPC call to complete.
+//
+// The value is a non-negative integer number of milliseconds, as produced by
+// FormatTimeout().
+const TimeoutHeader = "Protean-Timeout"
+
+// maxTimeoutDigits is the maximum number of digits in the value of the timeout
+// header. It is sufficient to represent timeouts of over 100 days.
+const maxTimeoutDigits = 10
+
+// FormatTimeout returns the value of the timeout header that represents the
+// timeout d.
+//
+// d is rounded up to the nearest millisecond, such that a non-zero timeout is
+// never represented as zero.
+func FormatTimeout(d time.Duration) string {
+	if d <= 0 {
+		return "0"
+	}
+
+	ms := (d + time.Millisecond - 1) / time.Millisecond
+
+	return strconv.FormatInt(int64(ms), 10)
+}
+
+// ParseTimeout parses the value of the timeout header.
+func ParseTimeout(v string) (time.Duration, error) {
+	if v == "" || len(v) > maxTimeoutDigits {
+		return 0, errors.New("timeout must be between 1 and 10 digits")
+	}
+
+	ms, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, errors.New("timeout must be a non-negative integer")
+	}
+
+	return time.Duration(ms) * time.Millisecond, nil
+}
@@ -0,0 +1,45 @@
+package runtime_test
+
+import (
+	"time"
+
+	. "github.com/dogmatiq/protean/runtime"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("func FormatTimeout()", func() {
+	DescribeTable(
+		"it returns the timeout in milliseconds",
+		func(d time.Duration, expect string) {
+			Expect(FormatTimeout(d)).To(Equal(expect))
+		},
+		Entry("zero", time.Duration(0), "0"),
+		Entry("negative", -time.Second, "0"),
+		Entry("whole milliseconds", 1500*time.Millisecond, "1500"),
+		Entry("fractional milliseconds", time.Millisecond+1, "2"),
+		Entry("less than one millisecond", time.Nanosecond, "1"),
+	)
+})
+
+var _ = Describe("func ParseTimeout()", func() {
+	It("parses the timeout in milliseconds", func() {
+		d, err := ParseTimeout("1500")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(d).To(Equal(1500 * time.Millisecond))
+	})
+
+	DescribeTable(
+		"it returns an error if the value is invalid",
+		func(v string) {
+			_, err := ParseTimeout(v)
+			Expect(err).Should(HaveOccurred())
+		},
+		Entry("empty", ""),
+		Entry("negative", "-1"),
+		Entry("fractional", "1.5"),
+		Entry("unit suffix", "10ms"),
+		Entry("too many digits", "12345678901"),
+	)
+})